@@ -0,0 +1,353 @@
+// Package dnsgen generates the AAAA records that name the endpoints of
+// an ENF domain, and the matching PTR records in an ip6.arpa zone, from
+// an endpoint inventory and a naming template, and keeps them in sync
+// with the inventory.
+package dnsgen
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+var (
+	ErrMissingTemplate = errors.New("Missing required template")
+	ErrMissingZone     = errors.New("Missing required zone")
+	ErrMissingPrefix   = errors.New("Missing required prefix")
+	ErrDuplicateName   = errors.New("Duplicate name")
+)
+
+// Endpoint is an entry of the inventory.
+type Endpoint struct {
+	Addr enf.EndpointAddr
+
+	// Fields are other attributes of the endpoint, such as the columns
+	// of a CSV file, available to the template.
+	Fields map[string]string
+}
+
+// Options specifies the parameters to Generate and Sync.
+type Options struct {
+	// Template is a text/template that produces the name of an
+	// endpoint, such as
+	//
+	//	sensor-{{printf "%03d" .Host}}.plant1.example
+	//
+	// It is executed with the fields Addr and Network, the endpoint and
+	// network addresses as strings, Host, the last 64 bits of the
+	// address as an integer, and Fields, the fields of the endpoint.
+	// Names that don't end with the forward zone are relative to it.
+	Template string
+
+	// ForwardZone is the zone of the AAAA records.
+	ForwardZone string
+
+	// ReverseZone is the ip6.arpa zone of the PTR records, such as
+	// 0.0.0.0.0.8.f.8.0.0.d.f.ip6.arpa. If empty, no PTR records are
+	// generated.
+	ReverseZone string
+
+	// Prefix is the address range the records are generated for. Sync
+	// only changes the AAAA and PTR records of addresses within it. It
+	// defaults to the prefix of the reverse zone.
+	Prefix netip.Prefix
+
+	// TTL is the TTL of the records. If nil, they get the zone's
+	// default.
+	TTL *int
+
+	// DryRun makes Sync compute the plan without applying it.
+	DryRun bool
+
+	// MaxDeletes is the number of records Sync may delete, which may be
+	// 0. If nil, it is enf.DefaultMaxRecordDeletes.
+	MaxDeletes *int
+
+	// AllowDeletes lifts the MaxDeletes limit, and lets Sync delete the
+	// records of every endpoint when the inventory is empty.
+	AllowDeletes bool
+}
+
+// prefix returns the managed prefix.
+func (o *Options) prefix() (netip.Prefix, error) {
+	if o.Prefix.IsValid() {
+		if o.Prefix.Bits() == 0 {
+			return netip.Prefix{}, fmt.Errorf("Invalid prefix %v: it covers every address", o.Prefix)
+		}
+		return o.Prefix.Masked(), nil
+	}
+	if o.ReverseZone == "" {
+		return netip.Prefix{}, ErrMissingPrefix
+	}
+	p, ok := reverseZonePrefix(o.ReverseZone)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("Invalid reverse zone %q", o.ReverseZone)
+	}
+	return p, nil
+}
+
+// check returns an error matching enf.ErrUnsafeReconcile if the plan
+// deletes more records than allowed, or deletes records for an empty
+// inventory.
+func (o *Options) check(plan *enf.ReconcilePlan, endpoints int) error {
+	deletes := 0
+	for _, step := range plan.Steps {
+		if step.Op == enf.SyncDelete {
+			deletes++
+		}
+	}
+
+	max := enf.DefaultMaxRecordDeletes
+	if o.MaxDeletes != nil {
+		max = *o.MaxDeletes
+	}
+	switch {
+	case o.AllowDeletes || deletes == 0:
+		return nil
+	case endpoints == 0:
+		return fmt.Errorf("%w: the inventory is empty and the plan deletes %d records", enf.ErrUnsafeReconcile, deletes)
+	case deletes > max:
+		return fmt.Errorf("%w: the plan deletes %d records, more than %d", enf.ErrUnsafeReconcile, deletes, max)
+	}
+	return nil
+}
+
+// Result holds the generated records. Their names are relative to
+// their zones.
+type Result struct {
+	Forward []enf.CreateRecordRequest
+	Reverse []enf.CreateRecordRequest
+}
+
+// templateData is the data the template is executed with.
+type templateData struct {
+	Addr    string
+	Network string
+	Host    uint64
+	Fields  map[string]string
+}
+
+// Generate returns the AAAA and PTR records of the endpoints, sorted by
+// address. Every endpoint must be within the prefix and get a distinct,
+// valid name.
+func Generate(endpoints []Endpoint, opts *Options) (*Result, error) {
+	if opts == nil || opts.Template == "" {
+		return nil, ErrMissingTemplate
+	}
+	if opts.ForwardZone == "" {
+		return nil, fmt.Errorf("%w: forward zone", ErrMissingZone)
+	}
+	prefix, err := opts.prefix()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]Endpoint(nil), endpoints...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Addr.Addr().Less(sorted[j].Addr.Addr())
+	})
+
+	forward := zoneName(opts.ForwardZone)
+	res := &Result{}
+	names := make(map[string]enf.EndpointAddr)
+	for _, e := range sorted {
+		addr := e.Addr.Addr()
+		if !e.Addr.IsValid() || !prefix.Contains(addr) {
+			return nil, fmt.Errorf("Endpoint %v is outside %v", e.Addr, prefix)
+		}
+
+		name, err := execute(tmpl, e)
+		if err != nil {
+			return nil, fmt.Errorf("Endpoint %v: %w", e.Addr, err)
+		}
+		name = relative(name, forward)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%w %v for endpoints %v and %v", ErrDuplicateName, name, other, e.Addr)
+		}
+		names[name] = e.Addr
+
+		res.Forward = append(res.Forward, enf.CreateRecordRequest{
+			Name: enf.String(name),
+			Type: enf.RecordTypeAAAA.Ptr(),
+			TTL:  opts.TTL,
+			Data: &enf.RecordData{IPv6: enf.String(addr.String())},
+		})
+		if opts.ReverseZone != "" {
+			res.Reverse = append(res.Reverse, enf.CreateRecordRequest{
+				Name: enf.String(relative(ReverseName(addr), zoneName(opts.ReverseZone))),
+				Type: enf.RecordTypePTR.Ptr(),
+				TTL:  opts.TTL,
+				Data: &enf.RecordData{PTRDName: enf.String(absolute(name, forward))},
+			})
+		}
+	}
+	if err := enf.ValidateRecords(opts.ForwardZone, res.Forward); err != nil {
+		return nil, err
+	}
+	if opts.ReverseZone != "" {
+		if err := enf.ValidateRecords(opts.ReverseZone, res.Reverse); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func execute(tmpl *template.Template, e Endpoint) (string, error) {
+	var buf bytes.Buffer
+	addr := e.Addr.Addr().As16()
+	var host uint64
+	for _, b := range addr[8:] {
+		host = host<<8 | uint64(b)
+	}
+	data := &templateData{Addr: e.Addr.String(), Network: e.Addr.Network().String(), Host: host, Fields: e.Fields}
+	if data.Fields == nil {
+		data.Fields = map[string]string{}
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	name := strings.ToLower(strings.TrimSpace(buf.String()))
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return "", fmt.Errorf("invalid name %q", name)
+	}
+	return name, nil
+}
+
+// ReadCSV reads an inventory from a CSV file with a header row. The
+// column named addr, address or endpoint holds the endpoint addresses,
+// and every column is available to the template in Fields.
+func ReadCSV(r io.Reader) ([]Endpoint, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "addr", "address", "endpoint":
+			col = i
+		}
+	}
+	if col < 0 {
+		return nil, fmt.Errorf("CSV header has no addr, address or endpoint column")
+	}
+
+	var endpoints []Endpoint
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return endpoints, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(col)
+		addr, err := enf.ParseEndpointAddr(strings.TrimSpace(record[col]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fields := make(map[string]string, len(header))
+		for i, h := range header {
+			fields[strings.TrimSpace(h)] = record[i]
+		}
+		endpoints = append(endpoints, Endpoint{Addr: addr, Fields: fields})
+	}
+}
+
+// ReverseName returns the fully qualified ip6.arpa name of an address,
+// with one label per nibble.
+func ReverseName(addr netip.Addr) string {
+	const hex = "0123456789abcdef"
+	b := addr.As16()
+	var sb strings.Builder
+	for i := len(b) - 1; i >= 0; i-- {
+		sb.WriteByte(hex[b[i]&0xf])
+		sb.WriteByte('.')
+		sb.WriteByte(hex[b[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String()
+}
+
+// parseReverseName returns the address of a fully qualified ip6.arpa
+// name with 32 nibbles.
+func parseReverseName(name string) (netip.Addr, bool) {
+	p, ok := reverseZonePrefix(name)
+	if !ok || p.Bits() != 128 {
+		return netip.Addr{}, false
+	}
+	return p.Addr(), true
+}
+
+// reverseZonePrefix returns the prefix an ip6.arpa name stands for. The
+// name must have at least one nibble label, so that ip6.arpa itself,
+// which stands for every address, is rejected.
+func reverseZonePrefix(name string) (netip.Prefix, bool) {
+	name = zoneName(name)
+	if !strings.HasSuffix(name, ".ip6.arpa.") {
+		return netip.Prefix{}, false
+	}
+	labels := strings.Split(strings.TrimSuffix(name, ".ip6.arpa."), ".")
+	if len(labels) > 32 {
+		return netip.Prefix{}, false
+	}
+
+	var b [16]byte
+	for i, l := range labels {
+		n := len(labels) - 1 - i
+		if len(l) != 1 || !strings.Contains("0123456789abcdef", l) {
+			return netip.Prefix{}, false
+		}
+		v := byte(strings.Index("0123456789abcdef", l))
+		if n%2 == 0 {
+			v <<= 4
+		}
+		b[n/2] |= v
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(b), 4*len(labels)), true
+}
+
+// zoneName returns the fully qualified, lower case form of a zone name.
+func zoneName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+}
+
+// relative returns the name relative to the zone, which is fully
+// qualified. Names outside the zone are returned unchanged.
+func relative(name, zone string) string {
+	n := strings.ToLower(name)
+	if !strings.HasSuffix(n, ".") {
+		n += "."
+	}
+	switch {
+	case n == zone:
+		return "@"
+	case strings.HasSuffix(n, "."+zone):
+		return strings.TrimSuffix(n, "."+zone)
+	}
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// absolute returns the fully qualified form of a name relative to the
+// zone.
+func absolute(name, zone string) string {
+	if name == "@" {
+		return zone
+	}
+	return name + "." + zone
+}
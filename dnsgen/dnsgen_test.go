@@ -0,0 +1,311 @@
+package dnsgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+const reverseZone = "0.0.0.8.0.8.f.8.0.0.d.f.ip6.arpa"
+
+func testEndpoints() []Endpoint {
+	var endpoints []Endpoint
+	for _, addr := range []string{"fd00:8f80:8000:1::2b", "fd00:8f80:8000:1::2a", "fd00:8f80:8000:1::7"} {
+		endpoints = append(endpoints, Endpoint{Addr: enf.MustParseEndpointAddr(addr)})
+	}
+	return endpoints
+}
+
+func testOptions() *Options {
+	return &Options{
+		Template:    `sensor-{{printf "%03d" .Host}}.Plant1.example.`,
+		ForwardZone: "plant1.example",
+		ReverseZone: reverseZone,
+	}
+}
+
+// describe returns the records as "name type data" lines.
+func describe(records []enf.CreateRecordRequest) []string {
+	var lines []string
+	for _, r := range records {
+		data, _ := json.Marshal(r.Data)
+		lines = append(lines, fmt.Sprintf("%v %v %s", *r.Name, *r.Type, data))
+	}
+	return lines
+}
+
+func TestGenerate(t *testing.T) {
+	res, err := Generate(testEndpoints(), testOptions())
+	if err != nil {
+		t.Fatalf("Generate returned error %v", err)
+	}
+
+	want := []string{
+		`sensor-007 AAAA {"ipv6":"fd00:8f80:8000:1::7"}`,
+		`sensor-042 AAAA {"ipv6":"fd00:8f80:8000:1::2a"}`,
+		`sensor-043 AAAA {"ipv6":"fd00:8f80:8000:1::2b"}`,
+	}
+	if got := describe(res.Forward); !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate returned forward records\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	want = []string{
+		`7.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR {"ptrdname":"sensor-007.plant1.example."}`,
+		`a.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR {"ptrdname":"sensor-042.plant1.example."}`,
+		`b.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR {"ptrdname":"sensor-043.plant1.example."}`,
+	}
+	if got := describe(res.Reverse); !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate returned reverse records\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	opts := testOptions()
+	opts.Template = `sensor`
+	if _, err := Generate(testEndpoints(), opts); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Generate returned error %v for duplicate names", err)
+	}
+
+	opts = testOptions()
+	opts.Template = `sensor-{{.Host}}-`
+	if _, err := Generate(testEndpoints(), opts); !errors.Is(err, enf.ErrValidation) {
+		t.Errorf("Generate returned error %v for invalid names", err)
+	}
+
+	opts = testOptions()
+	opts.Prefix = netip.MustParsePrefix("fd00:8f80:8000:2::/64")
+	if _, err := Generate(testEndpoints(), opts); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("Generate returned error %v for endpoints outside the prefix", err)
+	}
+
+	for _, zone := range []string{"ip6.arpa", "xip6.arpa."} {
+		opts = testOptions()
+		opts.ReverseZone = zone
+		if _, err := Generate(testEndpoints(), opts); err == nil || !strings.Contains(err.Error(), "Invalid reverse zone") {
+			t.Errorf("Generate returned error %v for reverse zone %v", err, zone)
+		}
+	}
+
+	opts = testOptions()
+	opts.ReverseZone = ""
+	opts.Prefix = netip.MustParsePrefix("::/0")
+	if _, err := Generate(testEndpoints(), opts); err == nil {
+		t.Errorf("Generate returned no error for a prefix of every address")
+	}
+
+	opts = testOptions()
+	opts.ReverseZone = ""
+	if _, err := Generate(testEndpoints(), opts); !errors.Is(err, ErrMissingPrefix) {
+		t.Errorf("Generate returned error %v without a prefix", err)
+	}
+
+	if _, err := Generate(testEndpoints(), nil); !errors.Is(err, ErrMissingTemplate) {
+		t.Errorf("Generate returned error %v without options", err)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	in := "name, Address\nboiler,fd00:8f80:8000:1::2a/128\npump, fd00:8f80:8000:1::7\n"
+	endpoints, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadCSV returned error %v", err)
+	}
+	if len(endpoints) != 2 || endpoints[1].Addr.String() != "fd00:8f80:8000:1::7" || endpoints[0].Fields["name"] != "boiler" {
+		t.Errorf("ReadCSV returned %+v", endpoints)
+	}
+
+	opts := testOptions()
+	opts.Template = `{{.Fields.name}}`
+	res, err := Generate(endpoints, opts)
+	if err != nil || *res.Forward[1].Name != "boiler" {
+		t.Errorf("Generate returned %v, %v for CSV endpoints", res, err)
+	}
+
+	if _, err := ReadCSV(strings.NewReader("name,addr\nx,10.0.0.1\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2") {
+		t.Errorf("ReadCSV returned error %v for an invalid address", err)
+	}
+	if _, err := ReadCSV(strings.NewReader("name,ip\n")); err == nil {
+		t.Errorf("ReadCSV returned no error without an address column")
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	addr := netip.MustParseAddr("fd00:8f80:8000:1::2a")
+	name := ReverseName(addr)
+	if name != "a.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0."+reverseZone+"." {
+		t.Errorf("ReverseName returned %v", name)
+	}
+	if got, ok := parseReverseName(strings.ToUpper(name)); !ok || got != addr {
+		t.Errorf("parseReverseName returned %v, %v", got, ok)
+	}
+
+	tests := []struct {
+		zone string
+		want string
+	}{
+		{reverseZone, "fd00:8f80:8000::/48"},
+		{"1.0.0.0." + reverseZone + ".", "fd00:8f80:8000:1::/64"},
+		{"f.ip6.arpa", "f000::/4"},
+		{"ip6.arpa", ""},
+		{"0.xip6.arpa", ""},
+		{"10.0.0.ip6.arpa", ""},
+		{"in-addr.arpa", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if p, ok := reverseZonePrefix(tt.zone); ok {
+			got = p.String()
+		}
+		if got != tt.want {
+			t.Errorf("reverseZonePrefix(%q) returned %q, want %q", tt.zone, got, tt.want)
+		}
+	}
+}
+
+// mockZones serves the forward and reverse zones and records every
+// change made to them.
+func mockZones(t *testing.T) (*enf.Client, *[]string, func()) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	var calls []string
+	mux.HandleFunc("/api/xdns/2019-05-27/zones", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": [
+			{"id": "fwd", "zone_domain_name": "Plant1.example"},
+			{"id": "rev", "zone_domain_name": %q}
+		]}`, reverseZone+".")
+	})
+	records := map[string]string{
+		"fwd": `[
+			{"id": "f1", "name": "sensor-042", "type": "AAAA", "rdata": {"ipv6": "fd00:8f80:8000:1:0::2a"}},
+			{"id": "f2", "name": "sensor-043", "type": "AAAA", "rdata": {"ipv6": "fd00:8f80:8000:1::99"}},
+			{"id": "f3", "name": "sensor-001", "type": "AAAA", "rdata": {"ipv6": "fd00:8f80:8000:1::1"}},
+			{"id": "f4", "name": "www", "type": "AAAA", "rdata": {"ipv6": "fd00:ffff::1"}}
+		]`,
+		"rev": `[
+			{"id": "r1", "name": "a.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0", "type": "PTR", "rdata": {"ptrdname": "SENSOR-042.plant1.example."}},
+			{"id": "r2", "name": "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0", "type": "PTR", "rdata": {"ptrdname": "sensor-001.plant1.example."}},
+			{"id": "r3", "name": "1.0.0.0", "type": "PTR", "rdata": {"ptrdname": "subnet.plant1.example."}}
+		]`,
+	}
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/xdns/2019-05-27/zones/")
+		zone := strings.Split(path, "/")[0]
+		if r.Method == "GET" {
+			fmt.Fprintf(w, `{"data": %s}`, records[zone])
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body) > 0 {
+			data, _ := json.Marshal(body)
+			path += " " + string(data)
+		}
+		calls = append(calls, r.Method+" "+path)
+		if r.Method != "DELETE" {
+			fmt.Fprint(w, `{"data": [{"id": "new"}]}`)
+		}
+	})
+
+	client, _ := enf.NewClient(server.URL, nil)
+	return client, &calls, server.Close
+}
+
+func TestSync(t *testing.T) {
+	client, calls, teardown := mockZones(t)
+	defer teardown()
+
+	opts := testOptions()
+	opts.DryRun = true
+	plan, err := Sync(context.Background(), client, testEndpoints(), opts)
+	if err != nil {
+		t.Fatalf("Sync returned error %v", err)
+	}
+	var steps []string
+	for _, s := range plan.Steps {
+		steps = append(steps, s.String())
+	}
+	want := []string{
+		"update record sensor-043 AAAA in zone Plant1.example",
+		"delete record sensor-001 AAAA in zone Plant1.example",
+		"create record sensor-007 AAAA in zone Plant1.example",
+		"delete record 1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR in zone " + reverseZone,
+		"create record 7.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR in zone " + reverseZone,
+		"create record b.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0 PTR in zone " + reverseZone,
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("Sync returned plan\n%v\nwant\n%v", strings.Join(steps, "\n"), strings.Join(want, "\n"))
+	}
+	if len(*calls) != 0 {
+		t.Errorf("Sync made changes %v in a dry run", *calls)
+	}
+
+	opts.DryRun = false
+	opts.Template = `sensor-{{printf "%03d" .Host}}`
+	plan, err = Sync(context.Background(), client, testEndpoints(), opts)
+	if err != nil {
+		t.Fatalf("Sync returned error %v", err)
+	}
+	wantCalls := []string{
+		`PUT fwd/records/f2 {"rdata":{"ipv6":"fd00:8f80:8000:1::2b"}}`,
+		`DELETE fwd/records/f3`,
+		`POST fwd/records {"name":"sensor-007","rdata":{"ipv6":"fd00:8f80:8000:1::7"},"type":"AAAA"}`,
+		`DELETE rev/records/r2`,
+		`POST rev/records {"name":"7.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0","rdata":{"ptrdname":"sensor-007.plant1.example."},"type":"PTR"}`,
+		`POST rev/records {"name":"b.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.1.0.0.0","rdata":{"ptrdname":"sensor-043.plant1.example."},"type":"PTR"}`,
+	}
+	if !reflect.DeepEqual(*calls, wantCalls) {
+		t.Errorf("Sync made changes\n%v\nwant\n%v", strings.Join(*calls, "\n"), strings.Join(wantCalls, "\n"))
+	}
+	if plan.Applied != len(plan.Steps) {
+		t.Errorf("Sync applied %d of %d steps", plan.Applied, len(plan.Steps))
+	}
+
+	opts.ForwardZone = "plant2.example"
+	if _, err := Sync(context.Background(), client, nil, opts); !errors.Is(err, ErrMissingZone) {
+		t.Errorf("Sync returned error %v for a missing zone", err)
+	}
+}
+
+func TestSync_safety(t *testing.T) {
+	client, calls, teardown := mockZones(t)
+	defer teardown()
+
+	opts := testOptions()
+	plan, err := Sync(context.Background(), client, nil, opts)
+	if !errors.Is(err, enf.ErrUnsafeReconcile) {
+		t.Errorf("Sync returned error %v for an empty inventory", err)
+	}
+	if plan == nil || len(plan.Steps) != 5 || plan.Applied != 0 {
+		t.Errorf("Sync returned plan %+v for an empty inventory", plan)
+	}
+
+	for _, max := range []int{0, 1} {
+		opts.MaxDeletes = enf.Int(max)
+		if _, err := Sync(context.Background(), client, testEndpoints(), opts); !errors.Is(err, enf.ErrUnsafeReconcile) {
+			t.Errorf("Sync returned error %v for a plan that deletes 2 records with a limit of %d", err, max)
+		}
+	}
+	opts.MaxDeletes, opts.DryRun = enf.Int(2), true
+	if _, err := Sync(context.Background(), client, testEndpoints(), opts); err != nil {
+		t.Errorf("Sync returned error %v for a plan that deletes 2 records with a limit of 2", err)
+	}
+	opts.DryRun = false
+	if len(*calls) != 0 {
+		t.Errorf("Sync made changes %v for an unsafe plan", *calls)
+	}
+
+	opts.AllowDeletes = true
+	plan, err = Sync(context.Background(), client, nil, opts)
+	if err != nil || plan.Applied != 5 {
+		t.Errorf("Sync returned %v and applied %d steps with deletes allowed", err, plan.Applied)
+	}
+}
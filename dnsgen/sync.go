@@ -0,0 +1,209 @@
+package dnsgen
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Sync generates the records of the endpoints and brings the forward
+// and reverse zones in line with them. It owns the AAAA records in the
+// forward zone and the PTR records in the reverse zone of addresses
+// within the prefix: records of endpoints that have disappeared are
+// deleted, records of renamed or readdressed endpoints are updated and
+// records of new endpoints are created. Other records of the zones are
+// left alone, and the zones must exist.
+//
+// The returned plan lists the changes in the order they are applied;
+// with DryRun set, none are. Sync refuses to apply a plan that deletes
+// more than MaxDeletes records, or deletes records for an empty
+// inventory, such as a truncated CSV file, unless AllowDeletes is set:
+// it returns the plan with an error matching enf.ErrUnsafeReconcile,
+// even with DryRun set. If a change fails, the error is returned along
+// with the plan, whose Applied field counts the changes made.
+func Sync(ctx context.Context, client *enf.Client, endpoints []Endpoint, opts *Options) (*enf.ReconcilePlan, error) {
+	res, err := Generate(endpoints, opts)
+	if err != nil {
+		return nil, err
+	}
+	prefix, _ := opts.prefix()
+
+	zones, _, err := client.DNS.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forward, err := findZone(zones, opts.ForwardZone)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &enf.ReconcilePlan{}
+	current, _, err := client.DNS.ListRecords(ctx, *forward.ID, &enf.ListRecordsOptions{Type: enf.RecordTypeAAAA})
+	if err != nil {
+		return nil, err
+	}
+	owned := filterRecords(current, func(r *enf.Record) bool {
+		addr, err := netip.ParseAddr(stringValue(dataIPv6(r)))
+		return err == nil && prefix.Contains(addr)
+	})
+	plan.Steps = append(plan.Steps, planRecords(forward, owned, res.Forward)...)
+
+	if opts.ReverseZone != "" {
+		reverse, err := findZone(zones, opts.ReverseZone)
+		if err != nil {
+			return nil, err
+		}
+		current, _, err := client.DNS.ListRecords(ctx, *reverse.ID, &enf.ListRecordsOptions{Type: enf.RecordTypePTR})
+		if err != nil {
+			return nil, err
+		}
+		zone := zoneName(opts.ReverseZone)
+		owned := filterRecords(current, func(r *enf.Record) bool {
+			addr, ok := parseReverseName(absolute(stringValue(r.Name), zone))
+			return ok && prefix.Contains(addr)
+		})
+		plan.Steps = append(plan.Steps, planRecords(reverse, owned, res.Reverse)...)
+	}
+
+	if err := opts.check(plan, len(endpoints)); err != nil {
+		return plan, err
+	}
+	if opts.DryRun {
+		return plan, nil
+	}
+	for _, step := range plan.Steps {
+		if err := apply(ctx, client, step); err != nil {
+			return plan, fmt.Errorf("Failed to %v: %w", step, err)
+		}
+		plan.Applied++
+	}
+	return plan, nil
+}
+
+// findZone returns the zone with the name.
+func findZone(zones []*enf.Zone, name string) (*enf.Zone, error) {
+	for _, z := range zones {
+		if z.ZoneDomainName != nil && z.ID != nil && zoneName(*z.ZoneDomainName) == zoneName(name) {
+			return z, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: zone %v does not exist", ErrMissingZone, strings.TrimSuffix(name, "."))
+}
+
+func filterRecords(records []*enf.Record, keep func(*enf.Record) bool) []*enf.Record {
+	var kept []*enf.Record
+	for _, r := range records {
+		if r.ID != nil && r.Type != nil && keep(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// planRecords returns the steps that turn the current records of the
+// zone into the desired ones, all of which have the same type: updates
+// of records whose name is desired, then deletes, then creates.
+func planRecords(zone *enf.Zone, current []*enf.Record, desired []enf.CreateRecordRequest) []*enf.ReconcileStep {
+	byName := make(map[string][]*enf.Record)
+	for _, r := range current {
+		name := strings.ToLower(stringValue(r.Name))
+		byName[name] = append(byName[name], r)
+	}
+
+	var updates, deletes, creates []*enf.ReconcileStep
+	step := func(op enf.SyncOp, cur *enf.Record, want *enf.CreateRecordRequest) *enf.ReconcileStep {
+		return &enf.ReconcileStep{
+			Op:            op,
+			Zone:          strings.TrimSuffix(*zone.ZoneDomainName, "."),
+			CurrentZone:   zone,
+			CurrentRecord: cur,
+			DesiredRecord: want,
+		}
+	}
+	for i := range desired {
+		want := &desired[i]
+		name := *want.Name
+		matches := byName[name]
+		if len(matches) == 0 {
+			creates = append(creates, step(enf.SyncCreate, nil, want))
+			continue
+		}
+
+		// Keep the record that already has the desired data, if any,
+		// and delete the others with the same name.
+		keep := 0
+		for j, r := range matches {
+			if sameData(r, want) {
+				keep = j
+				break
+			}
+		}
+		cur := matches[keep]
+		if !sameData(cur, want) || (want.TTL != nil && (cur.TTL == nil || *cur.TTL != *want.TTL)) {
+			updates = append(updates, step(enf.SyncUpdate, cur, want))
+		}
+		byName[name] = append(matches[:keep:keep], matches[keep+1:]...)
+	}
+
+	var names []string
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, r := range byName[name] {
+			deletes = append(deletes, step(enf.SyncDelete, r, nil))
+		}
+	}
+
+	steps := append(updates, deletes...)
+	return append(steps, creates...)
+}
+
+// sameData reports whether the record has the data of the request.
+func sameData(r *enf.Record, want *enf.CreateRecordRequest) bool {
+	switch *want.Type {
+	case enf.RecordTypeAAAA:
+		a, err := netip.ParseAddr(stringValue(dataIPv6(r)))
+		return err == nil && a.String() == *want.Data.IPv6
+	case enf.RecordTypePTR:
+		if r.Data == nil {
+			return false
+		}
+		return zoneName(stringValue(r.Data.PTRDName)) == zoneName(*want.Data.PTRDName)
+	}
+	return false
+}
+
+func apply(ctx context.Context, client *enf.Client, step *enf.ReconcileStep) error {
+	zoneID := *step.CurrentZone.ID
+	var err error
+	switch step.Op {
+	case enf.SyncCreate:
+		step.ResultRecord, _, err = client.DNS.CreateRecord(ctx, zoneID, step.DesiredRecord)
+	case enf.SyncUpdate:
+		req := &enf.UpdateRecordRequest{Data: step.DesiredRecord.Data, TTL: step.DesiredRecord.TTL}
+		step.ResultRecord, _, err = client.DNS.UpdateRecord(ctx, zoneID, *step.CurrentRecord.ID, req)
+	case enf.SyncDelete:
+		_, err = client.DNS.DeleteRecord(ctx, zoneID, *step.CurrentRecord.ID)
+	}
+	return err
+}
+
+func dataIPv6(r *enf.Record) *string {
+	if r.Data == nil {
+		return nil
+	}
+	return r.Data.IPv6
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
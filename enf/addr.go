@@ -0,0 +1,332 @@
+package enf
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+)
+
+var (
+	ErrMissingDomain   = errors.New("Missing required domain")
+	ErrMissingEndpoint = errors.New("Missing required endpoint")
+)
+
+// Prefix lengths of the addresses in the ENF address hierarchy.
+const (
+	DomainPrefixLen   = 48
+	NetworkPrefixLen  = 64
+	EndpointPrefixLen = 128
+)
+
+// AddrError is returned when an ENF address cannot be parsed. It
+// matches ErrValidation with errors.Is.
+type AddrError struct {
+	// Kind is the kind of address that was expected: "domain",
+	// "network" or "endpoint".
+	Kind string
+
+	// Input is the string that failed to parse.
+	Input string
+
+	// Reason describes the problem.
+	Reason string
+}
+
+func (e *AddrError) Error() string {
+	return fmt.Sprintf("Invalid %v address %q: %v", e.Kind, e.Input, e.Reason)
+}
+
+// Is reports whether the target is ErrValidation.
+func (e *AddrError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// DomainAddr is the /48 IPv6 prefix of an ENF domain, such as
+// fd00:8f80:8000::/48. The zero value is not a valid address.
+type DomainAddr struct {
+	prefix netip.Prefix
+}
+
+// NetworkAddr is the /64 IPv6 prefix of an ENF network, such as
+// fd00:8f80:8000:1::/64. The zero value is not a valid address.
+type NetworkAddr struct {
+	prefix netip.Prefix
+}
+
+// EndpointAddr is the /128 IPv6 address of an ENF endpoint, such as
+// fd00:8f80:8000:1::5. The zero value is not a valid address.
+type EndpointAddr struct {
+	addr netip.Addr
+}
+
+// parsePrefix parses an IPv6 prefix with the given length. The host
+// bits of the prefix must be zero.
+func parsePrefix(kind, s string, bits int) (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, &AddrError{Kind: kind, Input: s, Reason: "not a CIDR prefix"}
+	}
+	return checkPrefix(kind, s, p, bits)
+}
+
+func checkPrefix(kind, s string, p netip.Prefix, bits int) (netip.Prefix, error) {
+	if !p.Addr().Is6() || p.Addr().Is4In6() {
+		return netip.Prefix{}, &AddrError{Kind: kind, Input: s, Reason: "not an IPv6 prefix"}
+	}
+	if p.Addr().Zone() != "" {
+		return netip.Prefix{}, &AddrError{Kind: kind, Input: s, Reason: "zones are not allowed"}
+	}
+	if p.Bits() != bits {
+		return netip.Prefix{}, &AddrError{Kind: kind, Input: s, Reason: fmt.Sprintf("prefix length must be /%d", bits)}
+	}
+	if p.Masked() != p {
+		return netip.Prefix{}, &AddrError{Kind: kind, Input: s, Reason: fmt.Sprintf("host bits beyond /%d must be zero", bits)}
+	}
+	return p, nil
+}
+
+// escapePrefix returns the prefix in the form used in API paths,
+// <address>/<length>, with the address escaped for use in a path.
+func escapePrefix(p netip.Prefix) string {
+	return url.PathEscape(p.Addr().String()) + "/" + strconv.Itoa(p.Bits())
+}
+
+// ParseDomainAddr parses a domain address of the form <prefix>/48.
+func ParseDomainAddr(s string) (DomainAddr, error) {
+	p, err := parsePrefix("domain", s, DomainPrefixLen)
+	if err != nil {
+		return DomainAddr{}, err
+	}
+	return DomainAddr{p}, nil
+}
+
+// MustParseDomainAddr is like ParseDomainAddr, but panics if the
+// address cannot be parsed. It is intended for tests and constants.
+func MustParseDomainAddr(s string) DomainAddr {
+	d, err := ParseDomainAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// DomainAddrFromPrefix returns the domain address for the given /48 prefix.
+func DomainAddrFromPrefix(p netip.Prefix) (DomainAddr, error) {
+	p, err := checkPrefix("domain", p.String(), p, DomainPrefixLen)
+	if err != nil {
+		return DomainAddr{}, err
+	}
+	return DomainAddr{p}, nil
+}
+
+// IsValid reports whether the address is valid (not the zero value).
+func (d DomainAddr) IsValid() bool { return d.prefix.IsValid() }
+
+// Prefix returns the address as a netip.Prefix.
+func (d DomainAddr) Prefix() netip.Prefix { return d.prefix }
+
+// String returns the canonical form of the address, such as
+// fd00:8f80:8000::/48.
+func (d DomainAddr) String() string {
+	if !d.IsValid() {
+		return ""
+	}
+	return d.prefix.String()
+}
+
+// PathEscape returns the address in the form used in API paths.
+func (d DomainAddr) PathEscape() string { return escapePrefix(d.prefix) }
+
+// Contains reports whether the network belongs to the domain.
+func (d DomainAddr) Contains(n NetworkAddr) bool {
+	return d.IsValid() && n.IsValid() && d.prefix.Contains(n.prefix.Addr())
+}
+
+// ContainsEndpoint reports whether the endpoint belongs to the domain.
+func (d DomainAddr) ContainsEndpoint(e EndpointAddr) bool {
+	return d.IsValid() && e.IsValid() && d.prefix.Contains(e.addr)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d DomainAddr) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty input
+// yields the zero value.
+func (d *DomainAddr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = DomainAddr{}
+		return nil
+	}
+	parsed, err := ParseDomainAddr(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// ParseNetworkAddr parses a network address of the form <prefix>/64.
+func ParseNetworkAddr(s string) (NetworkAddr, error) {
+	p, err := parsePrefix("network", s, NetworkPrefixLen)
+	if err != nil {
+		return NetworkAddr{}, err
+	}
+	return NetworkAddr{p}, nil
+}
+
+// MustParseNetworkAddr is like ParseNetworkAddr, but panics if the
+// address cannot be parsed. It is intended for tests and constants.
+func MustParseNetworkAddr(s string) NetworkAddr {
+	n, err := ParseNetworkAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// NetworkAddrFromPrefix returns the network address for the given /64 prefix.
+func NetworkAddrFromPrefix(p netip.Prefix) (NetworkAddr, error) {
+	p, err := checkPrefix("network", p.String(), p, NetworkPrefixLen)
+	if err != nil {
+		return NetworkAddr{}, err
+	}
+	return NetworkAddr{p}, nil
+}
+
+// IsValid reports whether the address is valid (not the zero value).
+func (n NetworkAddr) IsValid() bool { return n.prefix.IsValid() }
+
+// Prefix returns the address as a netip.Prefix.
+func (n NetworkAddr) Prefix() netip.Prefix { return n.prefix }
+
+// String returns the canonical form of the address, such as
+// fd00:8f80:8000:1::/64.
+func (n NetworkAddr) String() string {
+	if !n.IsValid() {
+		return ""
+	}
+	return n.prefix.String()
+}
+
+// PathEscape returns the address in the form used in API paths.
+func (n NetworkAddr) PathEscape() string { return escapePrefix(n.prefix) }
+
+// Domain returns the domain the network belongs to.
+func (n NetworkAddr) Domain() DomainAddr {
+	if !n.IsValid() {
+		return DomainAddr{}
+	}
+	p, _ := n.prefix.Addr().Prefix(DomainPrefixLen)
+	return DomainAddr{p}
+}
+
+// Contains reports whether the endpoint belongs to the network.
+func (n NetworkAddr) Contains(e EndpointAddr) bool {
+	return n.IsValid() && e.IsValid() && n.prefix.Contains(e.addr)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (n NetworkAddr) MarshalText() ([]byte, error) { return []byte(n.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty input
+// yields the zero value.
+func (n *NetworkAddr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*n = NetworkAddr{}
+		return nil
+	}
+	parsed, err := ParseNetworkAddr(string(text))
+	if err != nil {
+		return err
+	}
+	*n = parsed
+	return nil
+}
+
+// ParseEndpointAddr parses an endpoint address. Both the plain IPv6
+// address and the <address>/128 form are accepted.
+func ParseEndpointAddr(s string) (EndpointAddr, error) {
+	var a netip.Addr
+	if p, err := netip.ParsePrefix(s); err == nil {
+		if p.Bits() != EndpointPrefixLen {
+			return EndpointAddr{}, &AddrError{Kind: "endpoint", Input: s, Reason: "prefix length must be /128"}
+		}
+		a = p.Addr()
+	} else if a, err = netip.ParseAddr(s); err != nil {
+		return EndpointAddr{}, &AddrError{Kind: "endpoint", Input: s, Reason: "not an IP address"}
+	}
+	return EndpointAddrFrom(a)
+}
+
+// MustParseEndpointAddr is like ParseEndpointAddr, but panics if the
+// address cannot be parsed. It is intended for tests and constants.
+func MustParseEndpointAddr(s string) EndpointAddr {
+	e, err := ParseEndpointAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// EndpointAddrFrom returns the endpoint address for the given IPv6 address.
+func EndpointAddrFrom(a netip.Addr) (EndpointAddr, error) {
+	if !a.Is6() || a.Is4In6() {
+		return EndpointAddr{}, &AddrError{Kind: "endpoint", Input: a.String(), Reason: "not an IPv6 address"}
+	}
+	if a.Zone() != "" {
+		return EndpointAddr{}, &AddrError{Kind: "endpoint", Input: a.String(), Reason: "zones are not allowed"}
+	}
+	return EndpointAddr{a}, nil
+}
+
+// IsValid reports whether the address is valid (not the zero value).
+func (e EndpointAddr) IsValid() bool { return e.addr.IsValid() }
+
+// Addr returns the address as a netip.Addr.
+func (e EndpointAddr) Addr() netip.Addr { return e.addr }
+
+// String returns the canonical form of the address, such as
+// fd00:8f80:8000:1::5.
+func (e EndpointAddr) String() string {
+	if !e.IsValid() {
+		return ""
+	}
+	return e.addr.String()
+}
+
+// PathEscape returns the address in the form used in API paths.
+func (e EndpointAddr) PathEscape() string { return url.PathEscape(e.addr.String()) }
+
+// Network returns the network the endpoint belongs to.
+func (e EndpointAddr) Network() NetworkAddr {
+	if !e.IsValid() {
+		return NetworkAddr{}
+	}
+	p, _ := e.addr.Prefix(NetworkPrefixLen)
+	return NetworkAddr{p}
+}
+
+// Domain returns the domain the endpoint belongs to.
+func (e EndpointAddr) Domain() DomainAddr {
+	return e.Network().Domain()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (e EndpointAddr) MarshalText() ([]byte, error) { return []byte(e.String()), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler. An empty input
+// yields the zero value.
+func (e *EndpointAddr) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*e = EndpointAddr{}
+		return nil
+	}
+	parsed, err := ParseEndpointAddr(string(text))
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
@@ -0,0 +1,292 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseDomainAddr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"fd00:8f80:8000::/48", "fd00:8f80:8000::/48", true},
+		{"FD00:8F80:8000:0000::/48", "fd00:8f80:8000::/48", true},
+		{"fd00:8f80:8000:1::/48", "", false},
+		{"fd00:8f80:8000::/64", "", false},
+		{"10.0.0.0/48", "", false},
+		{"fd00:8f80:8000::", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseDomainAddr(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseDomainAddr(%q) returned error %v, want ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseDomainAddr(%q) error does not match ErrValidation", tt.in)
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("ParseDomainAddr(%q).String() returned %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseNetworkAddr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"fd00:8f80:8000:1::/64", "fd00:8f80:8000:1::/64", true},
+		{"fd00:8f80:8000:0001:0000::/64", "fd00:8f80:8000:1::/64", true},
+		{"fd00:8f80:8000:1::1/64", "", false},
+		{"fd00:8f80:8000::/48", "", false},
+		{"::ffff:10.0.0.0/64", "", false},
+	}
+
+	for _, tt := range tests {
+		n, err := ParseNetworkAddr(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseNetworkAddr(%q) returned error %v, want ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if got := n.String(); got != tt.want {
+			t.Errorf("ParseNetworkAddr(%q).String() returned %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseEndpointAddr(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"fd00:8f80:8000:1::5", "fd00:8f80:8000:1::5", true},
+		{"fd00:8f80:8000:1:0:0:0:5/128", "fd00:8f80:8000:1::5", true},
+		{"fd00:8f80:8000:1::5/64", "", false},
+		{"10.0.0.1", "", false},
+		{"fe80::1%eth0", "", false},
+	}
+
+	for _, tt := range tests {
+		e, err := ParseEndpointAddr(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseEndpointAddr(%q) returned error %v, want ok=%v", tt.in, err, tt.ok)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("ParseEndpointAddr(%q).String() returned %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAddr_hierarchy(t *testing.T) {
+	e := MustParseEndpointAddr("fd00:8f80:8000:1::5")
+	n := MustParseNetworkAddr("fd00:8f80:8000:1::/64")
+	d := MustParseDomainAddr("fd00:8f80:8000::/48")
+
+	if got := e.Network(); got != n {
+		t.Errorf("Network() returned %v, want %v", got, n)
+	}
+	if got := e.Domain(); got != d {
+		t.Errorf("Domain() returned %v, want %v", got, d)
+	}
+	if got := n.Domain(); got != d {
+		t.Errorf("Domain() returned %v, want %v", got, d)
+	}
+	if !n.Contains(e) {
+		t.Errorf("%v.Contains(%v) returned false, want true", n, e)
+	}
+	if n.Contains(MustParseEndpointAddr("fd00:8f80:8000:2::5")) {
+		t.Errorf("%v.Contains(fd00:8f80:8000:2::5) returned true, want false", n)
+	}
+	if !d.Contains(n) || !d.ContainsEndpoint(e) {
+		t.Errorf("%v does not contain %v and %v", d, n, e)
+	}
+	if d.Contains(MustParseNetworkAddr("fd00:8f80:8001:1::/64")) {
+		t.Errorf("%v.Contains(fd00:8f80:8001:1::/64) returned true, want false", d)
+	}
+	if (NetworkAddr{}).Contains(e) {
+		t.Errorf("Zero NetworkAddr contains %v", e)
+	}
+}
+
+func TestAddr_PathEscape(t *testing.T) {
+	if got, want := MustParseNetworkAddr("fd00:8f80:8000:1::/64").PathEscape(), "fd00:8f80:8000:1::/64"; got != want {
+		t.Errorf("PathEscape returned %q, want %q", got, want)
+	}
+	if got, want := MustParseEndpointAddr("fd00:8f80:8000:1::5").PathEscape(), "fd00:8f80:8000:1::5"; got != want {
+		t.Errorf("PathEscape returned %q, want %q", got, want)
+	}
+}
+
+func TestAddr_JSON(t *testing.T) {
+	type doc struct {
+		Domain   DomainAddr   `json:"domain"`
+		Network  NetworkAddr  `json:"network"`
+		Endpoint EndpointAddr `json:"endpoint"`
+	}
+
+	in := `{"domain":"fd00:8f80:8000::/48","network":"fd00:8f80:8000:1::/64","endpoint":"fd00:8f80:8000:1::5"}`
+	var d doc
+	if err := json.Unmarshal([]byte(in), &d); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(d)
+	if string(out) != in {
+		t.Errorf("Marshal returned %s, want %s", out, in)
+	}
+
+	if err := json.Unmarshal([]byte(`{"network":"fd00::/48"}`), &d); err == nil {
+		t.Errorf("Expected error for invalid network")
+	}
+}
+
+func TestNetworkService_GetNetworkByAddr(t *testing.T) {
+	path := "/api/xcr/v2/nws/fd00:8f80:8000:1::/64"
+
+	responseBodyMock := `{"data": [{"name": "n", "network": "fd00:8f80:8000:1::/64"}]}`
+
+	expected := &Network{
+		Name:    String("n"),
+		Network: String("fd00:8f80:8000:1::/64"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.Network.GetNetworkByAddr(context.Background(), MustParseNetworkAddr("fd00:8f80:8000:1::/64"))
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	getTest(testParams)
+}
+
+func TestFirewallService_ListRulesByAddr_invalid(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+
+	_, _, err := client.Firewall.ListRulesByAddr(context.Background(), NetworkAddr{})
+	if err != ErrMissingNetwork {
+		t.Errorf("Expected ErrMissingNetwork, got %v", err)
+	}
+}
+
+func TestDomainService_ActivateDomainByAddr(t *testing.T) {
+	path := "/api/xcr/v2/domains/fd00:8f80:8000::/48/status"
+
+	responseBodyMock := `{"data": [{"network": "fd00:8f80:8000::/48", "status": "ACTIVE"}]}`
+
+	expected := &Domain{
+		Network: String("fd00:8f80:8000::/48"),
+		Status:  DomainStatusActive.Ptr(),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.Domains.ActivateDomainByAddr(context.Background(), MustParseDomainAddr("fd00:8f80:8000::/48"))
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	putTest(testParams)
+}
+
+func TestUserService_SendNewInviteByAddr(t *testing.T) {
+	path := "/api/xcr/v2/domains/fd00:8f80:8000::/48/invites"
+
+	requestBody := &SendInviteRequest{
+		Email:    String("user@acme.com"),
+		FullName: String("Xaptum User"),
+		UserType: UserTypeDomainUser.Ptr(),
+	}
+
+	responseBodyMock := `{"data": [{"id": 1, "email": "user@acme.com"}]}`
+
+	expected := &Invite{
+		ID:    Int(1),
+		Email: String("user@acme.com"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.User.SendNewInviteByAddr(context.Background(), MustParseDomainAddr("fd00:8f80:8000::/48"), requestBody)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      requestBody,
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	postTest(testParams)
+}
+
+func TestByAddr_invalid(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"Network.GetDefaultEndpointRateLimitsByAddr", func() error {
+			_, _, err := client.Network.GetDefaultEndpointRateLimitsByAddr(ctx, NetworkAddr{})
+			return err
+		}, ErrMissingNetwork},
+		{"Network.SetMaxDefaultEndpointRateLimitsByAddr", func() error {
+			_, _, err := client.Network.SetMaxDefaultEndpointRateLimitsByAddr(ctx, &NetworkRateLimits{}, NetworkAddr{})
+			return err
+		}, ErrMissingNetwork},
+		{"Domains.GetMaxDefaultEndpointRateLimitsByAddr", func() error {
+			_, _, err := client.Domains.GetMaxDefaultEndpointRateLimitsByAddr(ctx, DomainAddr{})
+			return err
+		}, ErrMissingDomain},
+		{"Domains.SetDefaultEndpointRateLimitsByAddr", func() error {
+			_, _, err := client.Domains.SetDefaultEndpointRateLimitsByAddr(ctx, &DomainRateLimits{}, DomainAddr{})
+			return err
+		}, ErrMissingDomain},
+		{"Domains.DeactivateDomainByAddr", func() error {
+			_, _, err := client.Domains.DeactivateDomainByAddr(ctx, DomainAddr{})
+			return err
+		}, ErrMissingDomain},
+		{"User.ListUsersForDomainAddressByAddr", func() error {
+			_, _, err := client.User.ListUsersForDomainAddressByAddr(ctx, DomainAddr{})
+			return err
+		}, ErrMissingDomain},
+		{"User.ListInvitesForDomainAddressByAddr", func() error {
+			_, _, err := client.User.ListInvitesForDomainAddressByAddr(ctx, DomainAddr{})
+			return err
+		}, ErrMissingDomain},
+	}
+
+	for _, tt := range tests {
+		if err := tt.call(); err != tt.want {
+			t.Errorf("%v returned %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -34,26 +34,31 @@ type Credentials struct {
 	DomainNetwork *string `json:"domain_network"`
 }
 
-type authResponse struct {
-	Data []Credentials          `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the username and password are set. The
+// returned error matches ErrMissingUsername or ErrMissingPassword with
+// errors.Is.
+func (r *AuthRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	v.requiredStringErr("username", r.Username, ErrMissingUsername)
+	v.requiredStringErr("token", r.Password, ErrMissingPassword)
+	return v.err()
 }
 
 // Authenticate authenticates the given authorization request.
 func (s *AuthService) Authenticate(ctx context.Context, authReq *AuthRequest) (*Credentials, *http.Response, error) {
-	if *authReq.Username == "" {
-		return nil, nil, ErrMissingUsername
-	}
-
-	if *authReq.Password == "" {
-		return nil, nil, ErrMissingPassword
+	if err := authReq.Validate(); err != nil {
+		return nil, nil, err
 	}
 
 	endpoint := "/api/xcr/v2/xauth"
 
-	body, resp, err := s.client.post(ctx, endpoint, new(authResponse), authReq)
+	creds := new(Credentials)
+	resp, err := s.client.postOne(ctx, endpoint, authReq, creds)
 	if err != nil {
 		return nil, resp, err
 	}
-	return &body.(*authResponse).Data[0], resp, nil
+	return creds, resp, nil
 }
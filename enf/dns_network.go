@@ -0,0 +1,273 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var ErrMissingServiceEndpointID = errors.New("Missing required service endpoint id")
+
+// ServiceEndpoint represents a DNS server within an ENF network. It
+// answers the queries of the network's endpoints for the names of the
+// zones attached to the network.
+type ServiceEndpoint struct {
+	Created     *time.Time `json:"created"`
+	Description *string    `json:"description"`
+	EnfNetwork  *string    `json:"enf_network"`
+	ID          *string    `json:"id"`
+	IPv6        *string    `json:"ipv6"`
+	Modified    *time.Time `json:"modified"`
+}
+
+// CreateServiceEndpointRequest represents a request to create a DNS
+// service endpoint at an address within a network.
+type CreateServiceEndpointRequest struct {
+	Description *string `json:"description,omitempty"`
+	IPv6        *string `json:"ipv6"`
+}
+
+// Validate checks that the address is set and is an IPv6 address.
+func (r *CreateServiceEndpointRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("ipv6", r.IPv6) {
+		v.ipv6("ipv6", r.IPv6)
+	}
+	return v.err()
+}
+
+// ZoneNetwork represents the attachment of a DNS zone to an ENF network,
+// whose endpoints can then resolve the names of the zone.
+type ZoneNetwork struct {
+	Created    *time.Time `json:"created"`
+	EnfNetwork *string    `json:"enf_network"`
+	ZoneID     *string    `json:"zone_id"`
+}
+
+// attachZoneRequest is the body of a request to attach a zone to a
+// network.
+type attachZoneRequest struct {
+	EnfNetwork string `json:"enf_network"`
+}
+
+// ServiceEndpointIterator iterates over a paginated list of DNS service
+// endpoints.
+type ServiceEndpointIterator struct{ pageIterator }
+
+// ServiceEndpoint returns the service endpoint at the current position
+// of the iterator.
+func (it *ServiceEndpointIterator) ServiceEndpoint() *ServiceEndpoint {
+	return it.current().(*ServiceEndpoint)
+}
+
+// ListServiceEndpoints lists the DNS service endpoints of a network,
+// following the pagination of the API.
+func (s *DNSService) ListServiceEndpoints(ctx context.Context, network string) ([]*ServiceEndpoint, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+
+	var endpoints []*ServiceEndpoint
+	it := s.ListServiceEndpointsIter(ctx, network, nil)
+	for it.Next() {
+		endpoints = append(endpoints, it.ServiceEndpoint())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+
+	return endpoints, it.Response(), nil
+}
+
+// ListServiceEndpointsByAddr is like ListServiceEndpoints, but takes a
+// typed network address.
+func (s *DNSService) ListServiceEndpointsByAddr(ctx context.Context, network NetworkAddr) ([]*ServiceEndpoint, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.ListServiceEndpoints(ctx, network.PathEscape())
+}
+
+// ListServiceEndpointsIter returns an iterator over the DNS service
+// endpoints of a network, fetching one page at a time.
+func (s *DNSService) ListServiceEndpointsIter(ctx context.Context, network string, opts *ListOptions) *ServiceEndpointIterator {
+	path := fmt.Sprintf("api/xdns/2019-05-27/networks/%v/endpoints", network)
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var endpoints []*ServiceEndpoint
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &endpoints)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(endpoints))
+		for i, e := range endpoints {
+			items[i] = e
+		}
+		return items, page, resp, nil
+	}
+	return &ServiceEndpointIterator{newPageIterator(ctx, opts, fetch)}
+}
+
+// CreateServiceEndpoint creates a new DNS service endpoint in a network.
+func (s *DNSService) CreateServiceEndpoint(ctx context.Context, network string, req *CreateServiceEndpointRequest) (*ServiceEndpoint, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/networks/%v/endpoints", network)
+	endpoint := new(ServiceEndpoint)
+	resp, err := s.client.postOne(ctx, path, req, endpoint)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return endpoint, resp, nil
+}
+
+// CreateServiceEndpointByAddr is like CreateServiceEndpoint, but takes a
+// typed network address.
+func (s *DNSService) CreateServiceEndpointByAddr(ctx context.Context, network NetworkAddr, req *CreateServiceEndpointRequest) (*ServiceEndpoint, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.CreateServiceEndpoint(ctx, network.PathEscape(), req)
+}
+
+// DeleteServiceEndpoint deletes a DNS service endpoint of a network
+// given its UUID.
+func (s *DNSService) DeleteServiceEndpoint(ctx context.Context, network, endpointUUID string) (*http.Response, error) {
+	if network == "" {
+		return nil, ErrMissingNetwork
+	}
+	if endpointUUID == "" {
+		return nil, ErrMissingServiceEndpointID
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/networks/%v/endpoints/%v", network, endpointUUID)
+	resp, err := s.client.delete(ctx, path)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// DeleteServiceEndpointByAddr is like DeleteServiceEndpoint, but takes a
+// typed network address.
+func (s *DNSService) DeleteServiceEndpointByAddr(ctx context.Context, network NetworkAddr, endpointUUID string) (*http.Response, error) {
+	if !network.IsValid() {
+		return nil, ErrMissingNetwork
+	}
+	return s.DeleteServiceEndpoint(ctx, network.PathEscape(), endpointUUID)
+}
+
+// ZoneNetworkIterator iterates over a paginated list of the networks a
+// zone is attached to.
+type ZoneNetworkIterator struct{ pageIterator }
+
+// ZoneNetwork returns the attachment at the current position of the
+// iterator.
+func (it *ZoneNetworkIterator) ZoneNetwork() *ZoneNetwork { return it.current().(*ZoneNetwork) }
+
+// ListZoneNetworks lists the networks the zone with the given UUID is
+// attached to, following the pagination of the API.
+func (s *DNSService) ListZoneNetworks(ctx context.Context, zoneUUID string) ([]*ZoneNetwork, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+
+	var networks []*ZoneNetwork
+	it := s.ListZoneNetworksIter(ctx, zoneUUID, nil)
+	for it.Next() {
+		networks = append(networks, it.ZoneNetwork())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+
+	return networks, it.Response(), nil
+}
+
+// ListZoneNetworksIter returns an iterator over the networks the zone
+// with the given UUID is attached to, fetching one page at a time.
+func (s *DNSService) ListZoneNetworksIter(ctx context.Context, zoneUUID string, opts *ListOptions) *ZoneNetworkIterator {
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/networks", zoneUUID)
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var networks []*ZoneNetwork
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &networks)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(networks))
+		for i, n := range networks {
+			items[i] = n
+		}
+		return items, page, resp, nil
+	}
+	return &ZoneNetworkIterator{newPageIterator(ctx, opts, fetch)}
+}
+
+// AttachZone attaches the zone with the given UUID to a network, given
+// as an address such as fd00:8f80:8000:1::/64, so that its endpoints
+// can resolve the names of the zone.
+func (s *DNSService) AttachZone(ctx context.Context, zoneUUID, network string) (*ZoneNetwork, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/networks", zoneUUID)
+	attached := new(ZoneNetwork)
+	resp, err := s.client.postOne(ctx, path, &attachZoneRequest{EnfNetwork: network}, attached)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return attached, resp, nil
+}
+
+// AttachZoneByAddr is like AttachZone, but takes a typed network
+// address.
+func (s *DNSService) AttachZoneByAddr(ctx context.Context, zoneUUID string, network NetworkAddr) (*ZoneNetwork, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.AttachZone(ctx, zoneUUID, network.String())
+}
+
+// DetachZone detaches the zone with the given UUID from a network,
+// given in the form used in API paths.
+func (s *DNSService) DetachZone(ctx context.Context, zoneUUID, network string) (*http.Response, error) {
+	if zoneUUID == "" {
+		return nil, ErrMissingZoneID
+	}
+	if network == "" {
+		return nil, ErrMissingNetwork
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/networks/%v", zoneUUID, network)
+	resp, err := s.client.delete(ctx, path)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// DetachZoneByAddr is like DetachZone, but takes a typed network
+// address.
+func (s *DNSService) DetachZoneByAddr(ctx context.Context, zoneUUID string, network NetworkAddr) (*http.Response, error) {
+	if !network.IsValid() {
+		return nil, ErrMissingNetwork
+	}
+	return s.DetachZone(ctx, zoneUUID, network.PathEscape())
+}
@@ -0,0 +1,204 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDNSService_ListServiceEndpoints(t *testing.T) {
+	path := "/api/xdns/2019-05-27/networks/fd00:8f80:8000:1::/64/endpoints"
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"description": "Resolver",
+				"enf_network": "fd00:8f80:8000:1::/64",
+				"id": "e1",
+				"ipv6": "fd00:8f80:8000:1::53"
+			}
+		]
+	}`
+
+	expected := []*ServiceEndpoint{
+		{
+			Description: String("Resolver"),
+			EnfNetwork:  String("fd00:8f80:8000:1::/64"),
+			ID:          String("e1"),
+			IPv6:        String("fd00:8f80:8000:1::53"),
+		},
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.ListServiceEndpointsByAddr(context.Background(), MustParseNetworkAddr("fd00:8f80:8000:1::/64"))
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	getTest(testParams)
+}
+
+func TestDNSService_CreateServiceEndpoint(t *testing.T) {
+	path := "/api/xdns/2019-05-27/networks/N/endpoints"
+
+	requestBody := &CreateServiceEndpointRequest{
+		IPv6: String("fd00:8f80:8000:1::53"),
+	}
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"enf_network": "fd00:8f80:8000:1::/64",
+				"id": "e1",
+				"ipv6": "fd00:8f80:8000:1::53"
+			}
+		]
+	}`
+
+	expected := &ServiceEndpoint{
+		EnfNetwork: String("fd00:8f80:8000:1::/64"),
+		ID:         String("e1"),
+		IPv6:       String("fd00:8f80:8000:1::53"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.CreateServiceEndpoint(context.Background(), "N", requestBody)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      requestBody,
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	postTest(testParams)
+}
+
+func TestDNSService_DeleteServiceEndpoint(t *testing.T) {
+	path := "/api/xdns/2019-05-27/networks/N/endpoints/e1"
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		resp, err := client.DNS.DeleteServiceEndpoint(context.Background(), "N", "e1")
+		return struct{}{}, resp, err
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: "",
+		Expected:         struct{}{},
+		Method:           method,
+		T:                t,
+	}
+
+	deleteTest(testParams)
+}
+
+func TestDNSService_ListZoneNetworks(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/networks"
+
+	responseBodyMock := `{
+		"data": [
+			{"enf_network": "fd00:8f80:8000:1::/64", "zone_id": "1234"},
+			{"enf_network": "fd00:8f80:8000:2::/64", "zone_id": "1234"}
+		]
+	}`
+
+	expected := []*ZoneNetwork{
+		{EnfNetwork: String("fd00:8f80:8000:1::/64"), ZoneID: String("1234")},
+		{EnfNetwork: String("fd00:8f80:8000:2::/64"), ZoneID: String("1234")},
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.ListZoneNetworks(context.Background(), "1234")
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	getTest(testParams)
+}
+
+func TestDNSService_AttachZone(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/1234/networks", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if want := map[string]string{"enf_network": "fd00:8f80:8000:1::/64"}; !reflect.DeepEqual(body, want) {
+			t.Errorf("Request body: %v, want %v", body, want)
+		}
+		fmt.Fprint(w, `{"data": [{"enf_network": "fd00:8f80:8000:1::/64", "zone_id": "1234"}]}`)
+	})
+
+	attached, _, err := client.DNS.AttachZoneByAddr(context.Background(), "1234", MustParseNetworkAddr("fd00:8f80:8000:1::/64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &ZoneNetwork{EnfNetwork: String("fd00:8f80:8000:1::/64"), ZoneID: String("1234")}
+	if !reflect.DeepEqual(attached, expected) {
+		t.Errorf("AttachZone returned %+v, want %+v", attached, expected)
+	}
+}
+
+func TestDNSService_DetachZone(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/networks/fd00:8f80:8000:1::/64"
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		resp, err := client.DNS.DetachZoneByAddr(context.Background(), "1234", MustParseNetworkAddr("fd00:8f80:8000:1::/64"))
+		return struct{}{}, resp, err
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: "",
+		Expected:         struct{}{},
+		Method:           method,
+		T:                t,
+	}
+
+	deleteTest(testParams)
+}
+
+func TestDNSService_Network_missingArgs(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+	ctx := context.Background()
+
+	if _, _, err := client.DNS.ListServiceEndpointsByAddr(ctx, NetworkAddr{}); !errors.Is(err, ErrMissingNetwork) {
+		t.Errorf("ListServiceEndpointsByAddr returned %v, want ErrMissingNetwork", err)
+	}
+	if _, err := client.DNS.DeleteServiceEndpoint(ctx, "N", ""); !errors.Is(err, ErrMissingServiceEndpointID) {
+		t.Errorf("DeleteServiceEndpoint returned %v, want ErrMissingServiceEndpointID", err)
+	}
+	if _, _, err := client.DNS.AttachZone(ctx, "", "N"); !errors.Is(err, ErrMissingZoneID) {
+		t.Errorf("AttachZone returned %v, want ErrMissingZoneID", err)
+	}
+	if _, err := client.DNS.DetachZone(ctx, "1234", ""); !errors.Is(err, ErrMissingNetwork) {
+		t.Errorf("DetachZone returned %v, want ErrMissingNetwork", err)
+	}
+}
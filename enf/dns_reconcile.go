@@ -0,0 +1,410 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrDuplicateZone   = errors.New("Duplicate zone")
+	ErrUnsafeReconcile = errors.New("Reconcile plan exceeds the safety limits")
+)
+
+// DefaultMaxRecordDeletes is the number of records ReconcileZones may
+// delete, unless configured otherwise.
+const DefaultMaxRecordDeletes = 10
+
+// DesiredZone is the desired state of a DNS zone and its records.
+type DesiredZone struct {
+	ZoneDomainName string `json:"zone_domain_name"`
+
+	// Description is the description of the zone. If nil, the
+	// description is left unchanged.
+	Description *string `json:"description"`
+
+	// Records is the full record set of the zone. Names are relative
+	// to the zone, with "@" for the zone itself. Records without a TTL
+	// match existing records with any TTL.
+	Records []CreateRecordRequest `json:"records"`
+}
+
+// ReconcileOptions specifies the optional parameters to ReconcileZones.
+type ReconcileOptions struct {
+	// DryRun computes the plan without applying it.
+	DryRun bool
+
+	// AllowZoneDeletes allows the plan to delete the zones that are not
+	// desired. Otherwise ReconcileZones refuses to apply such a plan.
+	AllowZoneDeletes bool
+
+	// MaxRecordDeletes is the number of records the plan may delete
+	// from the zones it keeps, which may be 0. If nil, it is
+	// DefaultMaxRecordDeletes.
+	MaxRecordDeletes *int
+
+	// AllowRecordDeletes lifts the MaxRecordDeletes limit.
+	AllowRecordDeletes bool
+}
+
+func (o *ReconcileOptions) check(plan *ReconcilePlan) error {
+	if o == nil {
+		o = &ReconcileOptions{}
+	}
+	zones, records := 0, 0
+	for _, step := range plan.Steps {
+		if step.Op != SyncDelete {
+			continue
+		}
+		if step.IsRecord() {
+			records++
+		} else {
+			zones++
+		}
+	}
+
+	max := DefaultMaxRecordDeletes
+	if o.MaxRecordDeletes != nil {
+		max = *o.MaxRecordDeletes
+	}
+	switch {
+	case zones > 0 && !o.AllowZoneDeletes:
+		return fmt.Errorf("%w: the plan deletes %d zones", ErrUnsafeReconcile, zones)
+	case records > max && !o.AllowRecordDeletes:
+		return fmt.Errorf("%w: the plan deletes %d records, more than %d", ErrUnsafeReconcile, records, max)
+	}
+	return nil
+}
+
+// ReconcileStep is a single change in a reconcile plan, to either a
+// zone or a record.
+type ReconcileStep struct {
+	Op SyncOp
+
+	// Zone is the domain name of the zone the step applies to.
+	Zone string
+
+	// CurrentZone is the existing zone, if there is one. It is nil for
+	// zone creates and for the records of created zones.
+	CurrentZone *Zone
+
+	// DesiredZone is the zone that is created or updated. It is nil
+	// for zone deletes and record steps.
+	DesiredZone *DesiredZone
+
+	// CurrentRecord is the existing record that is updated or deleted.
+	CurrentRecord *Record
+
+	// DesiredRecord is the record that is created, or the new state of
+	// an updated record.
+	DesiredRecord *CreateRecordRequest
+
+	// ResultZone and ResultRecord are returned by the API once a
+	// create or update has been applied.
+	ResultZone   *Zone
+	ResultRecord *Record
+}
+
+// IsRecord reports whether the step changes a record rather than a
+// zone.
+func (s *ReconcileStep) IsRecord() bool {
+	return s.CurrentRecord != nil || s.DesiredRecord != nil
+}
+
+func (s *ReconcileStep) String() string {
+	if !s.IsRecord() {
+		return fmt.Sprintf("%v zone %v", s.Op, s.Zone)
+	}
+	r := s.DesiredRecord
+	name, typ := "", ""
+	if r != nil {
+		name, typ = stringValue(r.Name), typeValue(r.Type)
+	} else {
+		name, typ = stringValue(s.CurrentRecord.Name), typeValue(s.CurrentRecord.Type)
+	}
+	return fmt.Sprintf("%v record %v %v in zone %v", s.Op, name, typ, s.Zone)
+}
+
+// ReconcilePlan is the set of changes that brings the DNS zones in line
+// with the desired zones.
+type ReconcilePlan struct {
+	// Steps are the changes, in the order they are applied.
+	Steps []*ReconcileStep
+
+	// Applied is the number of steps that have been applied. If
+	// ReconcileZones fails part way through, Steps[Applied] is the
+	// step that failed.
+	Applied int
+}
+
+// Empty reports whether the plan has no changes.
+func (p *ReconcilePlan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+// ReconcileZones makes the DNS zones and their records match the
+// desired zones. Zones are matched by domain name and records by name,
+// type and data. Records with the same name and type but a different
+// TTL or data are updated in place, and the others are created or
+// deleted. Zones that are not desired are deleted.
+//
+// The changes are applied zone by zone, creating or updating the zone,
+// then updating, deleting and creating its records, so that a CNAME can
+// replace other records of the same name. Zones are deleted last.
+//
+// Before applying the plan, ReconcileZones checks it against the safety
+// limits of opts and refuses to apply it, with an error matching
+// ErrUnsafeReconcile, if it deletes zones or too many records. If
+// opts.DryRun is set, the plan is returned without being applied, with
+// the same error if it is unsafe. If a step fails, the plan records how
+// many steps were applied.
+func (s *DNSService) ReconcileZones(ctx context.Context, desired []DesiredZone, opts *ReconcileOptions) (*ReconcilePlan, *http.Response, error) {
+	seen := make(map[string]bool)
+	for i := range desired {
+		d := &desired[i]
+		if err := (&CreateZoneRequest{ZoneDomainName: &d.ZoneDomainName}).Validate(); err != nil {
+			return nil, nil, fmt.Errorf("Desired zone %d: %w", i, err)
+		}
+		name := zoneKey(d.ZoneDomainName)
+		if seen[name] {
+			return nil, nil, fmt.Errorf("Desired zone %d: %w: %v", i, ErrDuplicateZone, d.ZoneDomainName)
+		}
+		seen[name] = true
+		if err := ValidateRecords(d.ZoneDomainName, d.Records); err != nil {
+			return nil, nil, fmt.Errorf("Desired zone %v: %w", d.ZoneDomainName, err)
+		}
+	}
+
+	zones, resp, err := s.ListZones(ctx)
+	if err != nil {
+		return nil, resp, err
+	}
+	records := make(map[*Zone][]*Record)
+	for _, z := range zones {
+		if !seen[zoneKey(stringValue(z.ZoneDomainName))] {
+			continue
+		}
+		if z.ID == nil {
+			return nil, resp, fmt.Errorf("Cannot list the records of zone %v: %w", stringValue(z.ZoneDomainName), ErrMissingZoneID)
+		}
+		records[z], resp, err = s.ListRecords(ctx, *z.ID, nil)
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+
+	plan := planReconcile(zones, records, desired)
+	if err := opts.check(plan); err != nil {
+		return plan, resp, err
+	}
+	if opts != nil && opts.DryRun {
+		return plan, resp, nil
+	}
+
+	ids := make(map[string]string)
+	for _, z := range zones {
+		ids[zoneKey(stringValue(z.ZoneDomainName))] = stringValue(z.ID)
+	}
+	for _, step := range plan.Steps {
+		resp, err = s.applyReconcileStep(ctx, step, ids)
+		if err != nil {
+			return plan, resp, err
+		}
+		plan.Applied++
+	}
+
+	return plan, resp, nil
+}
+
+// applyReconcileStep applies a step, recording the IDs of created zones
+// in ids.
+func (s *DNSService) applyReconcileStep(ctx context.Context, step *ReconcileStep, ids map[string]string) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	zoneID := ids[zoneKey(step.Zone)]
+
+	switch {
+	case step.IsRecord() && step.Op == SyncCreate:
+		step.ResultRecord, resp, err = s.CreateRecord(ctx, zoneID, step.DesiredRecord)
+	case step.IsRecord() && step.Op == SyncUpdate:
+		req := &UpdateRecordRequest{Data: step.DesiredRecord.Data, TTL: step.DesiredRecord.TTL}
+		step.ResultRecord, resp, err = s.UpdateRecord(ctx, zoneID, stringValue(step.CurrentRecord.ID), req)
+	case step.IsRecord():
+		resp, err = s.DeleteRecord(ctx, zoneID, stringValue(step.CurrentRecord.ID))
+	case step.Op == SyncCreate:
+		req := &CreateZoneRequest{ZoneDomainName: &step.DesiredZone.ZoneDomainName, Description: step.DesiredZone.Description}
+		step.ResultZone, resp, err = s.CreateZone(ctx, req)
+		if err == nil {
+			ids[zoneKey(step.Zone)] = stringValue(step.ResultZone.ID)
+		}
+	case step.Op == SyncUpdate:
+		step.ResultZone, resp, err = s.UpdateZone(ctx, zoneID, &UpdateZoneRequest{Description: step.DesiredZone.Description})
+	default:
+		resp, err = s.DeleteZone(ctx, zoneID)
+	}
+	return resp, err
+}
+
+// planReconcile computes the steps that turn the current zones and
+// records into the desired ones.
+func planReconcile(zones []*Zone, records map[*Zone][]*Record, desired []DesiredZone) *ReconcilePlan {
+	plan := &ReconcilePlan{}
+	byName := make(map[string]*Zone)
+	for _, z := range zones {
+		byName[zoneKey(stringValue(z.ZoneDomainName))] = z
+	}
+
+	kept := make(map[*Zone]bool)
+	for i := range desired {
+		d := &desired[i]
+		name := zoneKey(d.ZoneDomainName)
+		z := byName[name]
+		switch {
+		case z == nil:
+			plan.Steps = append(plan.Steps, &ReconcileStep{Op: SyncCreate, Zone: name, DesiredZone: d})
+		case d.Description != nil && stringValue(z.Description) != *d.Description:
+			plan.Steps = append(plan.Steps, &ReconcileStep{Op: SyncUpdate, Zone: name, CurrentZone: z, DesiredZone: d})
+		}
+		if z != nil {
+			kept[z] = true
+		}
+		plan.Steps = append(plan.Steps, planRecords(name, z, records[z], d.Records)...)
+	}
+
+	var deletes []*ReconcileStep
+	for _, z := range zones {
+		if !kept[z] {
+			name := zoneKey(stringValue(z.ZoneDomainName))
+			deletes = append(deletes, &ReconcileStep{Op: SyncDelete, Zone: name, CurrentZone: z})
+		}
+	}
+	sort.SliceStable(deletes, func(i, j int) bool { return deletes[i].Zone < deletes[j].Zone })
+	plan.Steps = append(plan.Steps, deletes...)
+	return plan
+}
+
+// planRecords computes the record steps of a zone: updates, then
+// deletes, then creates. Records that match exactly are kept before
+// the rest are paired up by name and type, so that a matching record
+// is never changed.
+func planRecords(zone string, z *Zone, current []*Record, desired []CreateRecordRequest) []*ReconcileStep {
+	type candidate struct {
+		record *Record
+		taken  bool
+	}
+	var candidates []*candidate
+	for _, r := range current {
+		if r != nil {
+			candidates = append(candidates, &candidate{record: r})
+		}
+	}
+	take := func(match func(r *Record) bool) *Record {
+		for _, c := range candidates {
+			if !c.taken && match(c.record) {
+				c.taken = true
+				return c.record
+			}
+		}
+		return nil
+	}
+
+	var unmatched []*CreateRecordRequest
+	for i := range desired {
+		d := &desired[i]
+		name, key := recordName(d.Name, zone), recordDataKey(d.Type, d.Data)
+		exact := take(func(r *Record) bool {
+			return recordName(r.Name, zone) == name && recordDataKey(r.Type, r.Data) == key &&
+				(d.TTL == nil || intValue(r.TTL) == *d.TTL)
+		})
+		if exact == nil {
+			unmatched = append(unmatched, d)
+		}
+	}
+
+	var updates, creates []*ReconcileStep
+	for _, d := range unmatched {
+		name, typ := recordName(d.Name, zone), typeValue(d.Type)
+		r := take(func(r *Record) bool {
+			return recordName(r.Name, zone) == name && typeValue(r.Type) == typ
+		})
+		if r != nil {
+			updates = append(updates, &ReconcileStep{Op: SyncUpdate, Zone: zone, CurrentZone: z, CurrentRecord: r, DesiredRecord: d})
+		} else {
+			creates = append(creates, &ReconcileStep{Op: SyncCreate, Zone: zone, CurrentZone: z, DesiredRecord: d})
+		}
+	}
+
+	steps := updates
+	for _, c := range candidates {
+		if !c.taken {
+			steps = append(steps, &ReconcileStep{Op: SyncDelete, Zone: zone, CurrentZone: z, CurrentRecord: c.record})
+		}
+	}
+	return append(steps, creates...)
+}
+
+// zoneKey returns the normalized form of a zone's domain name.
+func zoneKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// recordName returns the normalized form of a record's name, relative
+// to the zone.
+func recordName(name *string, zone string) string {
+	return relativeName(stringValue(name), zone)
+}
+
+// relativeName returns the name of a record, relative to the zone or
+// qualified within it with or without a trailing dot, in lower case and
+// relative to the zone, with "@" for the zone itself. The zone must be
+// normalized as by zoneKey.
+func relativeName(name, zone string) string {
+	n := strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case n == "" || n == "@" || n == zone:
+		return "@"
+	case strings.HasSuffix(n, "."+zone):
+		return strings.TrimSuffix(n, "."+zone)
+	}
+	return n
+}
+
+// recordDataKey returns the normalized form of a record's type and data,
+// so that equivalent records have the same key.
+func recordDataKey(typ *RecordType, d *RecordData) string {
+	if d == nil {
+		return typeValue(typ)
+	}
+	n := *d
+	if n.IPv6 != nil {
+		if a, err := netip.ParseAddr(*n.IPv6); err == nil {
+			n.IPv6 = String(a.String())
+		}
+	}
+	for _, name := range []**string{&n.CNAME, &n.PTRDName, &n.Target, &n.Exchange} {
+		if *name != nil {
+			*name = String(strings.ToLower(**name))
+		}
+	}
+	data, _ := json.Marshal(&n)
+	return typeValue(typ) + " " + string(data)
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func typeValue(t *RecordType) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
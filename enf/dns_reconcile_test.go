@@ -0,0 +1,216 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mockDNS registers handlers that serve two zones, abc.def and
+// old.zone, and records every change made to them.
+func mockDNS(t *testing.T, mux *http.ServeMux) *[]string {
+	var calls []string
+	mux.HandleFunc("/api/xdns/2019-05-27/zones", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			req := new(CreateZoneRequest)
+			json.NewDecoder(r.Body).Decode(req)
+			calls = append(calls, "POST zone "+*req.ZoneDomainName)
+			fmt.Fprintf(w, `{"data": [{"id": "z3", "zone_domain_name": %q}]}`, *req.ZoneDomainName)
+			return
+		}
+		fmt.Fprint(w, `{"data": [
+			{"id": "z1", "zone_domain_name": "abc.def", "description": "Old"},
+			{"id": "z2", "zone_domain_name": "old.zone"}
+		]}`)
+	})
+	record := func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/xdns/2019-05-27/")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body) > 0 {
+			data, _ := json.Marshal(body)
+			path += " " + string(data)
+		}
+		calls = append(calls, r.Method+" "+path)
+		if r.Method != "DELETE" {
+			fmt.Fprint(w, `{"data": [{"id": "new"}]}`)
+		}
+	}
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/z1/records", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			record(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"data": [
+			{"id": "r1", "name": "www", "type": "AAAA", "ttl": 300, "rdata": {"ipv6": "fd00::1"}},
+			{"id": "r2", "name": "api", "type": "CNAME", "ttl": 300, "rdata": {"cname": "WWW.abc.def."}},
+			{"id": "r3", "name": "@", "type": "TXT", "ttl": 300, "rdata": {"txt": ["v=1"]}},
+			{"id": "r4", "name": "gw", "type": "AAAA", "ttl": 300, "rdata": {"ipv6": "fd00::2"}}
+		]}`)
+	})
+	mux.HandleFunc("/api/xdns/2019-05-27/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"data": []}`)
+			return
+		}
+		record(w, r)
+	})
+	return &calls
+}
+
+func testDesiredZones() []DesiredZone {
+	return []DesiredZone{
+		{
+			ZoneDomainName: "ABC.def.",
+			Description:    String("Devices"),
+			Records: []CreateRecordRequest{
+				{Name: String("www.abc.def"), Type: RecordTypeAAAA.Ptr(), Data: &RecordData{IPv6: String("fd00:0:0::1")}},
+				{Name: String("api"), Type: RecordTypeCNAME.Ptr(), TTL: Int(60), Data: &RecordData{CNAME: String("www.abc.def.")}},
+				{Name: String("gw"), Type: RecordTypeAAAA.Ptr(), TTL: Int(300), Data: &RecordData{IPv6: String("fd00::3")}},
+				{Name: String("db"), Type: RecordTypeAAAA.Ptr(), Data: &RecordData{IPv6: String("fd00::4")}},
+			},
+		},
+		{
+			ZoneDomainName: "new.zone",
+			Records: []CreateRecordRequest{
+				{Name: String("@"), Type: RecordTypeTXT.Ptr(), Data: &RecordData{TXT: []string{"hello"}}},
+			},
+		},
+	}
+}
+
+func describeReconcile(plan *ReconcilePlan) []string {
+	steps := make([]string, len(plan.Steps))
+	for i, s := range plan.Steps {
+		steps[i] = s.String()
+	}
+	return steps
+}
+
+func TestDNSService_ReconcileZones(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	calls := mockDNS(t, mux)
+
+	plan, _, err := client.DNS.ReconcileZones(context.Background(), testDesiredZones(), &ReconcileOptions{DryRun: true})
+	if !errors.Is(err, ErrUnsafeReconcile) {
+		t.Errorf("ReconcileZones returned error %v for a plan that deletes a zone", err)
+	}
+	want := []string{
+		"update zone abc.def",
+		"update record api CNAME in zone abc.def",
+		"update record gw AAAA in zone abc.def",
+		"delete record @ TXT in zone abc.def",
+		"create record db AAAA in zone abc.def",
+		"create zone new.zone",
+		"create record @ TXT in zone new.zone",
+		"delete zone old.zone",
+	}
+	if got := describeReconcile(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReconcileZones returned plan\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(*calls) != 0 {
+		t.Errorf("ReconcileZones made changes %v in a dry run", *calls)
+	}
+
+	plan, _, err = client.DNS.ReconcileZones(context.Background(), testDesiredZones(), &ReconcileOptions{AllowZoneDeletes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCalls := []string{
+		`PUT zones/z1 {"description":"Devices"}`,
+		`PUT zones/z1/records/r2 {"rdata":{"cname":"www.abc.def."},"ttl":60}`,
+		`PUT zones/z1/records/r4 {"rdata":{"ipv6":"fd00::3"},"ttl":300}`,
+		`DELETE zones/z1/records/r3`,
+		`POST zones/z1/records {"name":"db","rdata":{"ipv6":"fd00::4"},"type":"AAAA"}`,
+		`POST zone new.zone`,
+		`POST zones/z3/records {"name":"@","rdata":{"txt":["hello"]},"type":"TXT"}`,
+		`DELETE zones/z2`,
+	}
+	if !reflect.DeepEqual(*calls, wantCalls) {
+		t.Errorf("ReconcileZones made changes\n%v\nwant\n%v", strings.Join(*calls, "\n"), strings.Join(wantCalls, "\n"))
+	}
+	if plan.Applied != len(plan.Steps) || *plan.Steps[5].ResultZone.ID != "z3" {
+		t.Errorf("ReconcileZones applied %d of %d steps", plan.Applied, len(plan.Steps))
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", "@"},
+		{"@", "@"},
+		{"abc.def", "@"},
+		{"ABC.def.", "@"},
+		{"www", "www"},
+		{"www.abc.def", "www"},
+		{"WWW.abc.def.", "www"},
+		{"a.b.abc.def", "a.b"},
+		{"www.xabc.def", "www.xabc.def"},
+	}
+	for _, tt := range tests {
+		if got := relativeName(tt.name, "abc.def"); got != tt.want {
+			t.Errorf("relativeName(%q) returned %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDNSService_ReconcileZones_safety(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	calls := mockDNS(t, mux)
+
+	desired := []DesiredZone{{ZoneDomainName: "abc.def"}, {ZoneDomainName: "old.zone"}}
+	opts := &ReconcileOptions{MaxRecordDeletes: Int(3)}
+	if _, _, err := client.DNS.ReconcileZones(context.Background(), desired, opts); !errors.Is(err, ErrUnsafeReconcile) {
+		t.Errorf("ReconcileZones returned error %v for a plan that deletes 4 records", err)
+	}
+
+	// A limit of 0 refuses any record delete, here of gw.
+	keep := []DesiredZone{{ZoneDomainName: "abc.def", Records: []CreateRecordRequest{
+		{Name: String("www"), Type: RecordTypeAAAA.Ptr(), Data: &RecordData{IPv6: String("fd00::1")}},
+		{Name: String("api"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("www.abc.def.")}},
+		{Name: String("@"), Type: RecordTypeTXT.Ptr(), Data: &RecordData{TXT: []string{"v=1"}}},
+	}}, {ZoneDomainName: "old.zone"}}
+	if _, _, err := client.DNS.ReconcileZones(context.Background(), keep, &ReconcileOptions{MaxRecordDeletes: Int(0)}); !errors.Is(err, ErrUnsafeReconcile) {
+		t.Errorf("ReconcileZones returned error %v for a plan that deletes a record with a limit of 0", err)
+	}
+	if _, _, err := client.DNS.ReconcileZones(context.Background(), keep, &ReconcileOptions{MaxRecordDeletes: Int(1), DryRun: true}); err != nil {
+		t.Errorf("ReconcileZones returned error %v for a plan that deletes a record with a limit of 1", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("ReconcileZones made changes %v for an unsafe plan", *calls)
+	}
+
+	opts.AllowRecordDeletes = true
+	plan, _, err := client.DNS.ReconcileZones(context.Background(), desired, opts)
+	if err != nil || plan.Applied != 4 {
+		t.Errorf("ReconcileZones returned %v and applied %d steps with record deletes allowed", err, plan.Applied)
+	}
+}
+
+func TestDNSService_ReconcileZones_invalid(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xdns/2019-05-27/zones", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Invalid desired zones were sent to the server")
+	})
+
+	desired := []DesiredZone{{ZoneDomainName: "abc.def"}, {ZoneDomainName: "ABC.def."}}
+	if _, _, err := client.DNS.ReconcileZones(context.Background(), desired, nil); !errors.Is(err, ErrDuplicateZone) {
+		t.Errorf("ReconcileZones returned error %v for duplicate zones", err)
+	}
+
+	desired = []DesiredZone{{ZoneDomainName: "abc.def", Records: []CreateRecordRequest{{Name: String("www")}}}}
+	if _, _, err := client.DNS.ReconcileZones(context.Background(), desired, nil); !errors.Is(err, ErrValidation) {
+		t.Errorf("ReconcileZones returned error %v for an invalid record", err)
+	}
+}
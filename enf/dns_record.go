@@ -0,0 +1,300 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrMissingZoneID   = errors.New("Missing required zone id")
+	ErrMissingRecordID = errors.New("Missing required record id")
+)
+
+// Record represents a DNS record within a zone of the ENF.
+type Record struct {
+	Created  *time.Time  `json:"created"`
+	Data     *RecordData `json:"rdata"`
+	ID       *string     `json:"id"`
+	Modified *time.Time  `json:"modified"`
+	Name     *string     `json:"name"`
+	TTL      *int        `json:"ttl"`
+	Type     *RecordType `json:"type"`
+	ZoneID   *string     `json:"zone_id"`
+}
+
+// RecordData holds the data of a DNS record. Only the fields of the
+// record's type are set:
+//
+//	AAAA:  IPv6
+//	CNAME: CNAME
+//	TXT:   TXT
+//	SRV:   Priority, Weight, Port and Target
+//	PTR:   PTRDName
+//	MX:    Preference and Exchange
+type RecordData struct {
+	IPv6       *string  `json:"ipv6,omitempty"`
+	CNAME      *string  `json:"cname,omitempty"`
+	TXT        []string `json:"txt,omitempty"`
+	Priority   *int     `json:"priority,omitempty"`
+	Weight     *int     `json:"weight,omitempty"`
+	Port       *int     `json:"port,omitempty"`
+	Target     *string  `json:"target,omitempty"`
+	PTRDName   *string  `json:"ptrdname,omitempty"`
+	Preference *int     `json:"preference,omitempty"`
+	Exchange   *string  `json:"exchange,omitempty"`
+}
+
+// CreateRecordRequest represents a request to create a DNS record
+// within a zone. If TTL is not set, the zone's default is used.
+type CreateRecordRequest struct {
+	Data *RecordData `json:"rdata"`
+	Name *string     `json:"name"`
+	TTL  *int        `json:"ttl,omitempty"`
+	Type *RecordType `json:"type"`
+}
+
+// UpdateRecordRequest represents a request to update the name, TTL or
+// data of a DNS record. Fields that are not set are left unchanged. The
+// type of a record cannot be changed.
+type UpdateRecordRequest struct {
+	Data *RecordData `json:"rdata,omitempty"`
+	Name *string     `json:"name,omitempty"`
+	TTL  *int        `json:"ttl,omitempty"`
+}
+
+// Validate checks that the name is a valid record name and a known type
+// is set, that the data has the fields of that type with valid values,
+// and that the TTL, if set, is between 0 and MaxTTL. A CNAME record
+// must not be named "@". The rules that involve the other records of
+// the zone are checked by ValidateRecords.
+func (r *CreateRecordRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("name", r.Name) {
+		v.domainName("name", r.Name, ownerName)
+	}
+	v.ttl("ttl", r.TTL)
+	if v.required("type", r.Type != nil) {
+		v.enum("type", *r.Type)
+		if v.required("rdata", r.Data != nil) {
+			r.Data.validate(v, *r.Type)
+			r.Data.validateValues(v)
+		}
+		if *r.Type == RecordTypeCNAME {
+			v.cnameAtApex("name", r.Name)
+		}
+	}
+	return v.err()
+}
+
+// Validate checks that at least one field is set, that the name, if
+// set, is a valid record name, that the TTL, if set, is between 0 and
+// MaxTTL, and that the fields of the data that are set have valid
+// values. Whether the data matches the record's type is checked by the
+// server.
+func (r *UpdateRecordRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if r.Data == nil && r.Name == nil && r.TTL == nil {
+		v.add("name", "name, ttl or rdata is required")
+	}
+	if r.Name != nil && v.requiredString("name", r.Name) {
+		v.domainName("name", r.Name, ownerName)
+	}
+	v.ttl("ttl", r.TTL)
+	if r.Data != nil {
+		r.Data.validateValues(v)
+		if r.Data.CNAME != nil {
+			v.cnameAtApex("name", r.Name)
+		}
+	}
+	return v.err()
+}
+
+// validate checks that the data has the fields of the given record
+// type.
+func (d *RecordData) validate(v *validator, typ RecordType) {
+	switch typ {
+	case RecordTypeAAAA:
+		v.requiredString("rdata.ipv6", d.IPv6)
+	case RecordTypeCNAME:
+		v.requiredString("rdata.cname", d.CNAME)
+	case RecordTypeTXT:
+		v.required("rdata.txt", len(d.TXT) > 0)
+	case RecordTypeSRV:
+		v.requiredInt("rdata.priority", d.Priority)
+		v.requiredInt("rdata.weight", d.Weight)
+		if v.requiredInt("rdata.port", d.Port) {
+			v.port("rdata.port", d.Port)
+		}
+		v.requiredString("rdata.target", d.Target)
+	case RecordTypePTR:
+		v.requiredString("rdata.ptrdname", d.PTRDName)
+	case RecordTypeMX:
+		v.requiredInt("rdata.preference", d.Preference)
+		v.requiredString("rdata.exchange", d.Exchange)
+	}
+}
+
+// ListRecordsOptions specifies the optional parameters to the
+// ListRecords methods.
+type ListRecordsOptions struct {
+	ListOptions
+
+	// Name, if set, only lists the records with this name.
+	Name string
+
+	// Type, if set, only lists the records of this type.
+	Type RecordType
+}
+
+// RecordIterator iterates over a paginated list of DNS records.
+type RecordIterator struct{ pageIterator }
+
+// Record returns the record at the current position of the iterator.
+func (it *RecordIterator) Record() *Record { return it.current().(*Record) }
+
+// ListRecords lists the DNS records of the zone with the given UUID,
+// following the pagination of the API.
+func (s *DNSService) ListRecords(ctx context.Context, zoneUUID string, opts *ListRecordsOptions) ([]*Record, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+
+	var records []*Record
+	it := s.ListRecordsIter(ctx, zoneUUID, opts)
+	for it.Next() {
+		records = append(records, it.Record())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+
+	return records, it.Response(), nil
+}
+
+// ListRecordsIter returns an iterator over the DNS records of the zone
+// with the given UUID, fetching one page at a time.
+func (s *DNSService) ListRecordsIter(ctx context.Context, zoneUUID string, opts *ListRecordsOptions) *RecordIterator {
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/records", zoneUUID)
+	var filter ListRecordsOptions
+	if opts != nil {
+		filter = *opts
+	}
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		params := opts.values()
+		if filter.Name != "" {
+			params.Set("name", filter.Name)
+		}
+		if filter.Type != "" {
+			params.Set("type", filter.Type.String())
+		}
+
+		var records []*Record
+		page, resp, err := s.client.getList(ctx, path, params, &records)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(records))
+		for i, r := range records {
+			items[i] = r
+		}
+		return items, page, resp, nil
+	}
+	return &RecordIterator{newPageIterator(ctx, &filter.ListOptions, fetch)}
+}
+
+// GetRecord gets a DNS record given the UUIDs of its zone and itself.
+func (s *DNSService) GetRecord(ctx context.Context, zoneUUID, recordUUID string) (*Record, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+	if recordUUID == "" {
+		return nil, nil, ErrMissingRecordID
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/records/%v", zoneUUID, recordUUID)
+	record := new(Record)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, record)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return record, resp, nil
+}
+
+// CreateRecord creates a new DNS record in the zone with the given UUID.
+// The request is checked by CreateRecordRequest.Validate, but not
+// against the other records of the zone: a CNAME record at a name that
+// has other records, or a record at a name that has a CNAME record, is
+// only rejected by the server. Use ValidateRecords on the full record
+// set, or ReconcileZones, to check it before sending.
+func (s *DNSService) CreateRecord(ctx context.Context, zoneUUID string, req *CreateRecordRequest) (*Record, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/records", zoneUUID)
+	record := new(Record)
+	resp, err := s.client.postOne(ctx, path, req, record)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return record, resp, nil
+}
+
+// UpdateRecord updates a DNS record given the UUIDs of its zone and
+// itself. As with CreateRecord, the request is not checked against the
+// other records of the zone, such as when renaming a record to a name
+// that has a CNAME record.
+func (s *DNSService) UpdateRecord(ctx context.Context, zoneUUID, recordUUID string, req *UpdateRecordRequest) (*Record, *http.Response, error) {
+	if zoneUUID == "" {
+		return nil, nil, ErrMissingZoneID
+	}
+	if recordUUID == "" {
+		return nil, nil, ErrMissingRecordID
+	}
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/records/%v", zoneUUID, recordUUID)
+	record := new(Record)
+	resp, err := s.client.putOne(ctx, path, req, record)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return record, resp, nil
+}
+
+// DeleteRecord deletes a DNS record given the UUIDs of its zone and
+// itself.
+func (s *DNSService) DeleteRecord(ctx context.Context, zoneUUID, recordUUID string) (*http.Response, error) {
+	if zoneUUID == "" {
+		return nil, ErrMissingZoneID
+	}
+	if recordUUID == "" {
+		return nil, ErrMissingRecordID
+	}
+
+	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v/records/%v", zoneUUID, recordUUID)
+	resp, err := s.client.delete(ctx, path)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,293 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDNSService_ListRecords(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/records"
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"id": "r1",
+				"name": "www",
+				"rdata": {"ipv6": "fd00:8f80:8000:1::5"},
+				"ttl": 300,
+				"type": "AAAA",
+				"zone_id": "1234"
+			},
+			{
+				"id": "r2",
+				"name": "_sip._udp",
+				"rdata": {"priority": 10, "weight": 5, "port": 5060, "target": "sip"},
+				"ttl": 3600,
+				"type": "SRV",
+				"zone_id": "1234"
+			}
+		]
+	}`
+
+	expected := []*Record{
+		{
+			Data:   &RecordData{IPv6: String("fd00:8f80:8000:1::5")},
+			ID:     String("r1"),
+			Name:   String("www"),
+			TTL:    Int(300),
+			Type:   RecordTypeAAAA.Ptr(),
+			ZoneID: String("1234"),
+		},
+		{
+			Data:   &RecordData{Priority: Int(10), Weight: Int(5), Port: Int(5060), Target: String("sip")},
+			ID:     String("r2"),
+			Name:   String("_sip._udp"),
+			TTL:    Int(3600),
+			Type:   RecordTypeSRV.Ptr(),
+			ZoneID: String("1234"),
+		},
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.ListRecords(context.Background(), "1234", nil)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	getTest(testParams)
+}
+
+func TestDNSService_ListRecords_filter(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/1234/records", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		curr := 0
+		if p := r.URL.Query().Get("page"); p != "" {
+			fmt.Sscanf(p, "%d", &curr)
+		}
+		if got, want := r.URL.Query().Get("name"), "www"; got != want {
+			t.Errorf("Request name filter: %v, want %v", got, want)
+		}
+		if got, want := r.URL.Query().Get("type"), "TXT"; got != want {
+			t.Errorf("Request type filter: %v, want %v", got, want)
+		}
+		next := -1
+		if curr == 0 {
+			next = 1
+		}
+		fmt.Fprintf(w, `{
+			"data": [{"id": "r%d", "rdata": {"txt": ["v=%d"]}}],
+			"page": {"curr": %d, "next": %d, "prev": %d}
+		}`, curr, curr, curr, next, curr-1)
+	})
+
+	opts := &ListRecordsOptions{Name: "www", Type: RecordTypeTXT}
+	records, _, err := client.DNS.ListRecords(context.Background(), "1234", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Record{
+		{ID: String("r0"), Data: &RecordData{TXT: []string{"v=0"}}},
+		{ID: String("r1"), Data: &RecordData{TXT: []string{"v=1"}}},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("ListRecords returned %+v, want %+v", records, expected)
+	}
+}
+
+func TestDNSService_GetRecord(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/records/r1"
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"created": "2019-10-21T19:48:21.961747Z",
+				"id": "r1",
+				"name": "mail",
+				"rdata": {"preference": 10, "exchange": "mx.abc.def"},
+				"ttl": 3600,
+				"type": "MX",
+				"zone_id": "1234",
+				"modified": null
+			}
+		]
+	}
+	`
+
+	createdTime, _ := time.Parse(time.RFC3339, "2019-10-21T19:48:21.961747Z")
+
+	expected := &Record{
+		Created:  Time(createdTime),
+		Data:     &RecordData{Preference: Int(10), Exchange: String("mx.abc.def")},
+		ID:       String("r1"),
+		Modified: nil,
+		Name:     String("mail"),
+		TTL:      Int(3600),
+		Type:     RecordTypeMX.Ptr(),
+		ZoneID:   String("1234"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.GetRecord(context.Background(), "1234", "r1")
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	getTest(testParams)
+}
+
+func TestDNSService_CreateRecord(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/records"
+
+	requestBody := &CreateRecordRequest{
+		Data: &RecordData{CNAME: String("www.abc.def.")},
+		Name: String("api"),
+		TTL:  Int(600),
+		Type: RecordTypeCNAME.Ptr(),
+	}
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"id": "r3",
+				"name": "api",
+				"rdata": {"cname": "www.abc.def."},
+				"ttl": 600,
+				"type": "CNAME",
+				"zone_id": "1234"
+			}
+		]
+	}
+	`
+
+	expected := &Record{
+		Data:   &RecordData{CNAME: String("www.abc.def.")},
+		ID:     String("r3"),
+		Name:   String("api"),
+		TTL:    Int(600),
+		Type:   RecordTypeCNAME.Ptr(),
+		ZoneID: String("1234"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.CreateRecord(context.Background(), "1234", requestBody)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      requestBody,
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	postTest(testParams)
+}
+
+func TestDNSService_UpdateRecord(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/records/r4"
+
+	requestBody := &UpdateRecordRequest{
+		Data: &RecordData{PTRDName: String("sensor-1.abc.def.")},
+		TTL:  Int(60),
+	}
+
+	responseBodyMock := `{
+		"data": [
+			{
+				"id": "r4",
+				"name": "5.0.0.0",
+				"rdata": {"ptrdname": "sensor-1.abc.def."},
+				"ttl": 60,
+				"type": "PTR",
+				"zone_id": "1234"
+			}
+		]
+	}`
+
+	expected := &Record{
+		Data:   &RecordData{PTRDName: String("sensor-1.abc.def.")},
+		ID:     String("r4"),
+		Name:   String("5.0.0.0"),
+		TTL:    Int(60),
+		Type:   RecordTypePTR.Ptr(),
+		ZoneID: String("1234"),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.DNS.UpdateRecord(context.Background(), "1234", "r4", requestBody)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      requestBody,
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	putTest(testParams)
+}
+
+func TestDNSService_DeleteRecord(t *testing.T) {
+	path := "/api/xdns/2019-05-27/zones/1234/records/r1"
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		resp, err := client.DNS.DeleteRecord(context.Background(), "1234", "r1")
+		return struct{}{}, resp, err
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      struct{}{},
+		ResponseBodyMock: "",
+		Expected:         struct{}{},
+		Method:           method,
+		T:                t,
+	}
+
+	deleteTest(testParams)
+}
+
+func TestDNSService_Record_missingIDs(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+	ctx := context.Background()
+
+	if _, _, err := client.DNS.ListRecords(ctx, "", nil); !errors.Is(err, ErrMissingZoneID) {
+		t.Errorf("ListRecords returned %v, want ErrMissingZoneID", err)
+	}
+	if _, _, err := client.DNS.GetRecord(ctx, "1234", ""); !errors.Is(err, ErrMissingRecordID) {
+		t.Errorf("GetRecord returned %v, want ErrMissingRecordID", err)
+	}
+	if _, _, err := client.DNS.UpdateRecord(ctx, "", "r1", &UpdateRecordRequest{}); !errors.Is(err, ErrMissingZoneID) {
+		t.Errorf("UpdateRecord returned %v, want ErrMissingZoneID", err)
+	}
+	if _, err := client.DNS.DeleteRecord(ctx, "1234", ""); !errors.Is(err, ErrMissingRecordID) {
+		t.Errorf("DeleteRecord returned %v, want ErrMissingRecordID", err)
+	}
+}
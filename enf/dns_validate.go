@@ -0,0 +1,213 @@
+package enf
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+var ErrCNAMEConflict = errors.New("CNAME conflict")
+
+// MaxTTL is the largest TTL of a DNS record, 2^31 - 1 seconds (RFC
+// 2181).
+const MaxTTL = 2147483647
+
+const (
+	maxNameLength  = 253
+	maxLabelLength = 63
+	maxTXTLength   = 255
+)
+
+// nameKind selects the label rules a domain name is checked against.
+type nameKind int
+
+const (
+	// hostName is a host name (RFC 1123): labels of letters, digits
+	// and hyphens that don't start or end with a hyphen. Zone names,
+	// SRV targets and MX exchanges, other than ".", are host names.
+	hostName nameKind = iota
+
+	// targetName is the name a CNAME or PTR record points to, which
+	// may also contain underscores.
+	targetName
+
+	// ownerName is the name of a record, which may also contain
+	// underscores (as in _mqtt._tcp), start with a "*" label, or be
+	// "@" for the zone itself.
+	ownerName
+)
+
+// ValidateZoneName checks that the name is a valid zone name: a host
+// name of at most 253 characters, with labels of at most 63 letters,
+// digits and hyphens that don't start or end with a hyphen. A trailing
+// dot is allowed.
+func ValidateZoneName(name string) error {
+	v := new(validator)
+	if v.requiredString("zone_domain_name", &name) {
+		v.domainName("zone_domain_name", &name, hostName)
+	}
+	return v.err()
+}
+
+// ValidateRecords checks a record set of the zone: each record is
+// checked as by CreateRecordRequest.Validate, names must fit within 253
+// characters once qualified with the zone, and a name that has a CNAME
+// record must have no other records, nor be the zone itself. Record
+// names are relative to the zone, with "@" for the zone itself, or
+// fully qualified within it. Field names of the returned error are
+// prefixed with the index of the record, as in records[2].name.
+func ValidateRecords(zone string, records []CreateRecordRequest) error {
+	v := new(validator)
+	zone = zoneKey(zone)
+
+	names := make([]string, len(records))
+	count := make(map[string]int)
+	for i := range records {
+		r := &records[i]
+		prefix := fmt.Sprintf("records[%d].", i)
+		var verr *ValidationError
+		if err := r.Validate(); errors.As(err, &verr) {
+			for _, fe := range verr.Errors {
+				v.errs = append(v.errs, &FieldError{Field: prefix + fe.Field, Message: fe.Message, Err: fe.Err})
+			}
+			continue
+		}
+
+		name := relativeName(*r.Name, zone)
+		if name != "@" && len(name)+1+len(zone) > maxNameLength {
+			v.add(prefix+"name", "must be at most %d characters within zone %v, got %d", maxNameLength, zone, len(name)+1+len(zone))
+		}
+		names[i] = name
+		count[name]++
+	}
+
+	for i, name := range names {
+		if name == "" || *records[i].Type != RecordTypeCNAME {
+			continue
+		}
+		field := fmt.Sprintf("records[%d].name", i)
+		if name == "@" {
+			// Validate has already rejected a CNAME record named "@".
+			if *records[i].Name != "@" {
+				v.cnameAtApex(field, &name)
+			}
+		} else if count[name] > 1 {
+			v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf("must not have records other than its CNAME record, got %d", count[name]-1), Err: ErrCNAMEConflict})
+		}
+	}
+	return v.err()
+}
+
+// domainName checks that the field, if set and not blank, is a valid
+// domain name of the given kind. A trailing dot is allowed.
+func (v *validator) domainName(field string, value *string, kind nameKind) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return
+	}
+	name := *value
+	if kind == ownerName && name == "@" {
+		return
+	}
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > maxNameLength {
+		v.add(field, "must be at most %d characters, got %d", maxNameLength, len(name))
+		return
+	}
+
+	for i, label := range strings.Split(name, ".") {
+		if msg := checkLabel(label, kind, i == 0); msg != "" {
+			v.add(field, "%v, got %q", msg, *value)
+			return
+		}
+	}
+}
+
+// checkLabel returns the problem with a label of a domain name, or ""
+// if there is none.
+func checkLabel(label string, kind nameKind, first bool) string {
+	switch {
+	case label == "":
+		return "must not have empty labels"
+	case len(label) > maxLabelLength:
+		return fmt.Sprintf("must have labels of at most %d characters", maxLabelLength)
+	case kind == ownerName && first && label == "*":
+		return ""
+	case label[0] == '-' || label[len(label)-1] == '-':
+		return "must not have labels that start or end with a hyphen"
+	}
+	for _, c := range label {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-':
+		case c == '_' && kind != hostName:
+		case kind == hostName:
+			return "must have labels of letters, digits and hyphens"
+		default:
+			return "must have labels of letters, digits, hyphens and underscores"
+		}
+	}
+	return ""
+}
+
+// ttl checks that the field, if set, is a valid TTL.
+func (v *validator) ttl(field string, value *int) {
+	if value != nil && (*value < 0 || *value > MaxTTL) {
+		v.add(field, "must be between 0 and %d, got %d", MaxTTL, *value)
+	}
+}
+
+// uint16 checks that the field, if set, is between 0 and 65535.
+func (v *validator) uint16(field string, value *int) {
+	if value != nil && (*value < 0 || *value > 65535) {
+		v.add(field, "must be between 0 and 65535, got %d", *value)
+	}
+}
+
+// ipv6 checks that the field, if set and not blank, is an IPv6
+// address.
+func (v *validator) ipv6(field string, value *string) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return
+	}
+	addr, err := netip.ParseAddr(*value)
+	if err != nil || !addr.Is6() || addr.Is4In6() || addr.Zone() != "" {
+		v.add(field, "must be an IPv6 address, got %q", *value)
+	}
+}
+
+// cnameAtApex records an error if a CNAME record is named after the
+// zone itself.
+func (v *validator) cnameAtApex(field string, name *string) {
+	if name != nil && *name == "@" {
+		v.errs = append(v.errs, &FieldError{Field: field, Message: "must not be the zone apex for a CNAME record", Err: ErrCNAMEConflict})
+	}
+}
+
+// validateValues checks the values of the fields of the data that are
+// set, whatever the record's type.
+func (d *RecordData) validateValues(v *validator) {
+	v.ipv6("rdata.ipv6", d.IPv6)
+	v.domainName("rdata.cname", d.CNAME, targetName)
+	for _, s := range d.TXT {
+		if len(s) > maxTXTLength {
+			v.add("rdata.txt", "must have strings of at most %d characters, got %d", maxTXTLength, len(s))
+			break
+		}
+	}
+	v.uint16("rdata.priority", d.Priority)
+	v.uint16("rdata.weight", d.Weight)
+	v.domainName("rdata.target", notRoot(d.Target), hostName)
+	v.domainName("rdata.ptrdname", d.PTRDName, targetName)
+	v.uint16("rdata.preference", d.Preference)
+	v.domainName("rdata.exchange", notRoot(d.Exchange), hostName)
+}
+
+// notRoot returns the name unless it is the root ".", which as an SRV
+// target means that the service is not available (RFC 2782) and as an
+// MX exchange that the domain accepts no mail (RFC 7505).
+func notRoot(name *string) *string {
+	if name != nil && *name == "." {
+		return nil
+	}
+	return name
+}
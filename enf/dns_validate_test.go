@@ -0,0 +1,134 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateZoneName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"plant1.example", true},
+		{"Plant1.Example.", true},
+		{"0.0.0.8.0.8.f.8.0.0.d.f.ip6.arpa", true},
+		{"123.example", true},
+		{strings.Repeat("a", 63) + ".example", true},
+		{strings.Repeat("a", 64) + ".example", false},
+		{strings.Repeat("abcdefghi.", 25) + "example", false},
+		{"", false},
+		{".example", false},
+		{"a..example", false},
+		{"-a.example", false},
+		{"a-.example", false},
+		{"_tcp.example", false},
+		{"*.example", false},
+		{"a b.example", false},
+	}
+	for _, tt := range tests {
+		if err := ValidateZoneName(tt.name); (err == nil) != tt.ok {
+			t.Errorf("ValidateZoneName(%q) returned %v", tt.name, err)
+		}
+	}
+}
+
+func TestCreateRecordRequest_Validate_names(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"@", true},
+		{"www", true},
+		{"*", true},
+		{"*.dev", true},
+		{"_mqtt._tcp", true},
+		{"www.plant1.example.", true},
+		{"dev.*", false},
+		{"**", false},
+		{"www-", false},
+		{"w@w", false},
+		{"a..b", false},
+	}
+	for _, tt := range tests {
+		req := &CreateRecordRequest{Name: String(tt.name), Type: RecordTypeTXT.Ptr(), Data: &RecordData{TXT: []string{"x"}}}
+		if err := req.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate returned %v for record name %q", err, tt.name)
+		}
+	}
+
+	data := []*RecordData{
+		{IPv6: String("10.0.0.1")},
+		{IPv6: String("fe80::1%eth0")},
+		{CNAME: String("a..b")},
+		{TXT: []string{strings.Repeat("x", 256)}},
+		{Priority: Int(1), Weight: Int(70000), Port: Int(1), Target: String("_sip.example")},
+		{Preference: Int(-1), Exchange: String("mail")},
+		{Priority: Int(0), Weight: Int(0), Port: Int(0), Target: String("..")},
+	}
+	types := []RecordType{RecordTypeAAAA, RecordTypeAAAA, RecordTypeCNAME, RecordTypeTXT, RecordTypeSRV, RecordTypeMX, RecordTypeSRV}
+	for i, d := range data {
+		req := &CreateRecordRequest{Name: String("x"), Type: types[i].Ptr(), Data: d}
+		if err := req.Validate(); !errors.Is(err, ErrValidation) {
+			t.Errorf("Validate returned %v for %v data %+v", err, types[i], d)
+		}
+	}
+
+	// A root target or exchange means there is no such service.
+	for _, req := range []*CreateRecordRequest{
+		{Name: String("_sip._tcp"), Type: RecordTypeSRV.Ptr(), Data: &RecordData{Priority: Int(0), Weight: Int(0), Port: Int(0), Target: String(".")}},
+		{Name: String("@"), Type: RecordTypeMX.Ptr(), Data: &RecordData{Preference: Int(0), Exchange: String(".")}},
+	} {
+		if err := req.Validate(); err != nil {
+			t.Errorf("Validate returned %v for %v data %+v", err, *req.Type, req.Data)
+		}
+	}
+}
+
+func TestValidateRecords(t *testing.T) {
+	records := []CreateRecordRequest{
+		{Name: String("www"), Type: RecordTypeAAAA.Ptr(), Data: &RecordData{IPv6: String("fd00::1")}},
+		{Name: String("www.plant1.example."), Type: RecordTypeTXT.Ptr(), Data: &RecordData{TXT: []string{"hello"}}},
+		{Name: String("api"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("www")}},
+		{Name: String("Plant1.example"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("www")}},
+		{Name: String("www"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("api")}},
+		{Name: String(strings.TrimSuffix(strings.Repeat(strings.Repeat("a", 60)+".", 4), ".")), Type: RecordTypeTXT.Ptr(), Data: &RecordData{TXT: []string{"x"}}},
+		{Name: String("db"), Type: RecordTypeAAAA.Ptr(), TTL: Int(-1), Data: &RecordData{IPv6: String("fd00::2")}},
+	}
+	err := ValidateRecords("plant1.example.", records)
+	want := []string{"records[5].name", "records[6].ttl", "records[3].name", "records[4].name"}
+	if got := fieldNames(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateRecords returned errors for %v, want %v (%v)", got, want, err)
+	}
+	if !errors.Is(err, ErrCNAMEConflict) {
+		t.Errorf("ValidateRecords error does not match ErrCNAMEConflict")
+	}
+
+	if err := ValidateRecords("plant1.example", records[:3]); err != nil {
+		t.Errorf("ValidateRecords returned %v for a valid record set", err)
+	}
+}
+
+func TestDNSService_CreateRecord_invalid(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("An invalid request was sent to the server")
+	})
+
+	req := &CreateRecordRequest{Name: String("@"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("www")}}
+	if _, _, err := client.DNS.CreateRecord(context.Background(), "z1", req); !errors.Is(err, ErrCNAMEConflict) {
+		t.Errorf("CreateRecord returned error %v for a CNAME at the apex", err)
+	}
+	if _, _, err := client.DNS.UpdateRecord(context.Background(), "z1", "r1", &UpdateRecordRequest{TTL: Int(-5)}); !errors.Is(err, ErrValidation) {
+		t.Errorf("UpdateRecord returned error %v for a negative TTL", err)
+	}
+	if _, _, err := client.DNS.CreateZone(context.Background(), &CreateZoneRequest{ZoneDomainName: String("bad..zone")}); !errors.Is(err, ErrValidation) {
+		t.Errorf("CreateZone returned error %v for an invalid name", err)
+	}
+}
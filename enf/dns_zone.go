@@ -35,53 +35,113 @@ type UpdateZoneRequest struct {
 	Description *string `json:"description"`
 }
 
-type zoneResponse struct {
-	Data []*Zone                `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the zone domain name is set and is a valid zone
+// name, as by ValidateZoneName.
+func (r *CreateZoneRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("zone_domain_name", r.ZoneDomainName) {
+		v.domainName("zone_domain_name", r.ZoneDomainName, hostName)
+	}
+	return v.err()
+}
+
+// Validate checks that the description is set.
+func (r *UpdateZoneRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if r.Description == nil {
+		v.add("description", "is required")
+	}
+	return v.err()
 }
 
-// ListZones lists all the DNS zones for a given ENF domain (::/48 address).
+// ZoneIterator iterates over a paginated list of DNS zones.
+type ZoneIterator struct{ pageIterator }
+
+// Zone returns the zone at the current position of the iterator.
+func (it *ZoneIterator) Zone() *Zone { return it.current().(*Zone) }
+
+// ListZones lists all the DNS zones for a given ENF domain (::/48 address),
+// following the pagination of the API.
 func (s *DNSService) ListZones(ctx context.Context) ([]*Zone, *http.Response, error) {
-	path := "api/xdns/2019-05-27/zones"
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(zoneResponse))
-	if err != nil {
-		return nil, resp, err
+	var zones []*Zone
+	it := s.ListZonesIter(ctx, nil)
+	for it.Next() {
+		zones = append(zones, it.Zone())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
 	}
 
-	return body.(*zoneResponse).Data, resp, nil
+	return zones, it.Response(), nil
+}
+
+// ListZonesIter returns an iterator over the DNS zones, fetching one
+// page at a time.
+func (s *DNSService) ListZonesIter(ctx context.Context, opts *ListOptions) *ZoneIterator {
+	path := "api/xdns/2019-05-27/zones"
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var zones []*Zone
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &zones)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(zones))
+		for i, z := range zones {
+			items[i] = z
+		}
+		return items, page, resp, nil
+	}
+	return &ZoneIterator{newPageIterator(ctx, opts, fetch)}
 }
 
 // GetZone gets a DNS zone given its UUID.
 func (s *DNSService) GetZone(ctx context.Context, zoneUUID string) (*Zone, *http.Response, error) {
 	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v", zoneUUID)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(zoneResponse))
+	zone := new(Zone)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, zone)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*zoneResponse).Data[0], resp, nil
+	return zone, resp, nil
 }
 
 // CreateZone creates a new DNS zone.
 func (s *DNSService) CreateZone(ctx context.Context, req *CreateZoneRequest) (*Zone, *http.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := "api/xdns/2019-05-27/zones"
-	body, resp, err := s.client.post(ctx, path, new(zoneResponse), req)
+	zone := new(Zone)
+	resp, err := s.client.postOne(ctx, path, req, zone)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*zoneResponse).Data[0], resp, nil
+	return zone, resp, nil
 }
 
 // UpdateZone updates a zone's description given its UUID.
 func (s *DNSService) UpdateZone(ctx context.Context, zoneUUID string, req *UpdateZoneRequest) (*Zone, *http.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xdns/2019-05-27/zones/%v", zoneUUID)
-	body, resp, err := s.client.put(ctx, path, new(zoneResponse), req)
+	zone := new(Zone)
+	resp, err := s.client.putOne(ctx, path, req, zone)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*zoneResponse).Data[0], resp, nil
+	return zone, resp, nil
 }
 
 // DeleteZone deletes a zone given its UUID.
@@ -13,9 +13,9 @@ type DomainService service
 
 // Domain represents a domain in the ENF.
 type Domain struct {
-	Name    *string `json:"name"`
-	Network *string `json:"network"`
-	Status  *string `json:"status"`
+	Name    *string       `json:"name"`
+	Network *string       `json:"network"`
+	Status  *DomainStatus `json:"status"`
 }
 
 // DomainRequest represents a request to provision a new domain.
@@ -26,61 +26,133 @@ type DomainRequest struct {
 	AdminEmail *string `json:"admin_email"`
 }
 
-type domainResponse struct {
-	Data []*Domain              `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that all fields of the domain request are set and
+// that the admin email is a valid address.
+func (r *DomainRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	v.requiredString("name", r.Name)
+	v.requiredString("type", r.Type)
+	v.requiredString("admin_name", r.AdminName)
+	if v.requiredString("admin_email", r.AdminEmail) {
+		v.email("admin_email", r.AdminEmail)
+	}
+	return v.err()
 }
 
-// ListDomains lists all available domains on the ENF.
+// DomainIterator iterates over a paginated list of domains.
+type DomainIterator struct{ pageIterator }
+
+// Domain returns the domain at the current position of the iterator.
+func (it *DomainIterator) Domain() *Domain { return it.current().(*Domain) }
+
+// ListDomains lists all available domains on the ENF, following the
+// pagination of the API.
 func (s *DomainService) ListDomains(ctx context.Context) ([]*Domain, *http.Response, error) {
-	path := fmt.Sprintf("api/xcr/v2/domains")
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(domainResponse))
-	if err != nil {
-		return nil, resp, err
+	var domains []*Domain
+	it := s.ListDomainsIter(ctx, nil)
+	for it.Next() {
+		domains = append(domains, it.Domain())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
 	}
 
-	return body.(*domainResponse).Data, resp, nil
+	return domains, it.Response(), nil
+}
+
+// ListDomainsIter returns an iterator over the domains on the ENF,
+// fetching one page at a time.
+func (s *DomainService) ListDomainsIter(ctx context.Context, opts *ListOptions) *DomainIterator {
+	path := "api/xcr/v2/domains"
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var domains []*Domain
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &domains)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(domains))
+		for i, d := range domains {
+			items[i] = d
+		}
+		return items, page, resp, nil
+	}
+	return &DomainIterator{newPageIterator(ctx, opts, fetch)}
 }
 
 // GetDomain gets the information of a specified domain.
 func (s *DomainService) GetDomain(ctx context.Context, domain string) (*Domain, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v", domain)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(domainResponse))
+	d := new(Domain)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, d)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*domainResponse).Data[0], resp, nil
+	return d, resp, nil
+}
+
+// GetDomainByAddr is like GetDomain, but takes a typed domain address.
+func (s *DomainService) GetDomainByAddr(ctx context.Context, domain DomainAddr) (*Domain, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.GetDomain(ctx, domain.PathEscape())
 }
 
 // CreateDomain provisions a domain on the ENF.
 func (s *DomainService) CreateDomain(ctx context.Context, req *DomainRequest) (*Domain, *http.Response, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/domains")
-	body, resp, err := s.client.post(ctx, path, new(domainResponse), req)
+	d := new(Domain)
+	resp, err := s.client.postOne(ctx, path, req, d)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*domainResponse).Data[0], resp, nil
+	return d, resp, nil
 }
 
-// ActivateDomain activates the given domain (sets the status field to ACTIVE)
+// ActivateDomain activates the given domain (sets the status field to DomainStatusActive)
 func (s *DomainService) ActivateDomain(ctx context.Context, domain string) (*Domain, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/status", domain)
-	body, resp, err := s.client.put(ctx, path, new(domainResponse), "ACTIVE")
+	d := new(Domain)
+	resp, err := s.client.putOne(ctx, path, DomainStatusActive, d)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*domainResponse).Data[0], resp, nil
+	return d, resp, nil
 }
 
-// DeactivateDomain deactivates the given domain (sets the status field to READY)
+// ActivateDomainByAddr is like ActivateDomain, but takes a typed domain address.
+func (s *DomainService) ActivateDomainByAddr(ctx context.Context, domain DomainAddr) (*Domain, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.ActivateDomain(ctx, domain.PathEscape())
+}
+
+// DeactivateDomain deactivates the given domain (sets the status field to DomainStatusReady)
 func (s *DomainService) DeactivateDomain(ctx context.Context, domain string) (*Domain, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/status", domain)
-	body, resp, err := s.client.put(ctx, path, new(domainResponse), "READY")
+	d := new(Domain)
+	resp, err := s.client.putOne(ctx, path, DomainStatusReady, d)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*domainResponse).Data[0], resp, nil
+	return d, resp, nil
+}
+
+// DeactivateDomainByAddr is like DeactivateDomain, but takes a typed domain address.
+func (s *DomainService) DeactivateDomainByAddr(ctx context.Context, domain DomainAddr) (*Domain, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.DeactivateDomain(ctx, domain.PathEscape())
 }
@@ -15,49 +15,99 @@ type DomainRateLimits struct {
 	BytesBurstSize   *int `json:"bytes_burst_size"`
 }
 
-// domainRateLimitResponse represents the standard API response for
-// all relevant network or endpoint rate limit endpoints.
-type domainRateLimitResponse struct {
-	Data []*DomainRateLimits    `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the rate limits that are set are not negative.
+func (r *DomainRateLimits) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	v.nonNegative("packets_per_second", r.PacketsPerSecond)
+	v.nonNegative("packets_burst_size", r.PacketsBurstSize)
+	v.nonNegative("bytes_per_second", r.BytesPerSecond)
+	v.nonNegative("bytes_burst_size", r.BytesBurstSize)
+	return v.err()
 }
 
 // GetDefaultEndpointRateLimits gets the default rate limits for an endpoint in the given domain.
 func (s *DomainService) GetDefaultEndpointRateLimits(ctx context.Context, domain string) (*DomainRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/ep_rate_limits/default", domain)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(domainRateLimitResponse))
+	limits := new(DomainRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*domainRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetDefaultEndpointRateLimitsByAddr is like GetDefaultEndpointRateLimits, but takes a typed domain address.
+func (s *DomainService) GetDefaultEndpointRateLimitsByAddr(ctx context.Context, domain DomainAddr) (*DomainRateLimits, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.GetDefaultEndpointRateLimits(ctx, domain.PathEscape())
 }
 
 // GetMaxDefaultEndpointRateLimits gets the max rate limits for an endpoint in the given domain.
 func (s *DomainService) GetMaxDefaultEndpointRateLimits(ctx context.Context, domain string) (*DomainRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/ep_rate_limits/max", domain)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(domainRateLimitResponse))
+	limits := new(DomainRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*domainRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetMaxDefaultEndpointRateLimitsByAddr is like GetMaxDefaultEndpointRateLimits, but takes a typed domain address.
+func (s *DomainService) GetMaxDefaultEndpointRateLimitsByAddr(ctx context.Context, domain DomainAddr) (*DomainRateLimits, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.GetMaxDefaultEndpointRateLimits(ctx, domain.PathEscape())
 }
 
 // SetDefaultEndpointRateLimits sets the default rate limits for an endpoint in the given domain.
 func (s *DomainService) SetDefaultEndpointRateLimits(ctx context.Context, values *DomainRateLimits, domain string) (*DomainRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/ep_rate_limits/default", domain)
-	body, resp, err := s.client.put(ctx, path, new(domainRateLimitResponse), values)
+	limits := new(DomainRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*domainRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetDefaultEndpointRateLimitsByAddr is like SetDefaultEndpointRateLimits, but takes a typed domain address.
+func (s *DomainService) SetDefaultEndpointRateLimitsByAddr(ctx context.Context, values *DomainRateLimits, domain DomainAddr) (*DomainRateLimits, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.SetDefaultEndpointRateLimits(ctx, values, domain.PathEscape())
 }
 
 // SetMaxDefaultEndpointRateLimits sets the max default rate limits for an endpoint in the given domain.
 func (s *DomainService) SetMaxDefaultEndpointRateLimits(ctx context.Context, values *DomainRateLimits, domain string) (*DomainRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/ep_rate_limits/max", domain)
-	body, resp, err := s.client.put(ctx, path, new(domainRateLimitResponse), values)
+	limits := new(DomainRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*domainRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetMaxDefaultEndpointRateLimitsByAddr is like SetMaxDefaultEndpointRateLimits, but takes a typed domain address.
+func (s *DomainService) SetMaxDefaultEndpointRateLimitsByAddr(ctx context.Context, values *DomainRateLimits, domain DomainAddr) (*DomainRateLimits, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.SetMaxDefaultEndpointRateLimits(ctx, values, domain.PathEscape())
 }
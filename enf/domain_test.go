@@ -34,12 +34,12 @@ func TestDomainService_ListDomains(t *testing.T) {
 		{
 			Name:    String("test.domain.1"),
 			Network: String("N/n0"),
-			Status:  String("ACTIVE"),
+			Status:  DomainStatusActive.Ptr(),
 		},
 		{
 			Name:    String("test.domain.2"),
 			Network: String("N/n1"),
-			Status:  String("ACTIVE"),
+			Status:  DomainStatusActive.Ptr(),
 		},
 	}
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -80,7 +80,7 @@ func TestDomainService_GetDomain(t *testing.T) {
 	expected := &Domain{
 		Name:    String("test.domain.1"),
 		Network: String("N/n0"),
-		Status:  String("ACTIVE"),
+		Status:  DomainStatusActive.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -127,7 +127,7 @@ func TestDomainService_CreateDomain(t *testing.T) {
 	expected := &Domain{
 		Name:    String("test.domain.1"),
 		Network: String("N/n0"),
-		Status:  String("READY"),
+		Status:  DomainStatusReady.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -168,7 +168,7 @@ func TestDomainService_ActivateDomain(t *testing.T) {
 	expected := &Domain{
 		Name:    String("test.domain.1"),
 		Network: String("N/n0"),
-		Status:  String("ACTIVE"),
+		Status:  DomainStatusActive.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -209,7 +209,7 @@ func TestDomainService_DeactivateDomain(t *testing.T) {
 	expected := &Domain{
 		Name:    String("test.domain.1"),
 		Network: String("N/n0"),
-		Status:  String("READY"),
+		Status:  DomainStatusReady.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -20,49 +20,99 @@ type EndpointRateLimits struct {
 	Inherit          *bool `json:"inherit"`
 }
 
-// endpointRateLimitResponse represents the standard API response for
-// all relevant endpoint rate limit endpoints.
-type endpointRateLimitResponse struct {
-	Data []*EndpointRateLimits  `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the rate limits that are set are not negative.
+func (r *EndpointRateLimits) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	v.nonNegative("packets_per_second", r.PacketsPerSecond)
+	v.nonNegative("packets_burst_size", r.PacketsBurstSize)
+	v.nonNegative("bytes_per_second", r.BytesPerSecond)
+	v.nonNegative("bytes_burst_size", r.BytesBurstSize)
+	return v.err()
 }
 
 // GetCurrentRateLimits gets the current rate limits for the given endpoint IPv6 address.
 func (s *EndpointService) GetCurrentRateLimits(ctx context.Context, endpointIPv6 string) (*EndpointRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/cxns/%v/ep_rate_limits/current", endpointIPv6)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(endpointRateLimitResponse))
+	limits := new(EndpointRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*endpointRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetCurrentRateLimitsByAddr is like GetCurrentRateLimits, but takes a typed endpoint address.
+func (s *EndpointService) GetCurrentRateLimitsByAddr(ctx context.Context, endpoint EndpointAddr) (*EndpointRateLimits, *http.Response, error) {
+	if !endpoint.IsValid() {
+		return nil, nil, ErrMissingEndpoint
+	}
+	return s.GetCurrentRateLimits(ctx, endpoint.PathEscape())
 }
 
 // GetMaxRateLimits gets the max rate limits for the given endpoint IPv6 address.
 func (s *EndpointService) GetMaxRateLimits(ctx context.Context, endpointIPv6 string) (*EndpointRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/cxns/%v/ep_rate_limits/max", endpointIPv6)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(endpointRateLimitResponse))
+	limits := new(EndpointRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*endpointRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetMaxRateLimitsByAddr is like GetMaxRateLimits, but takes a typed endpoint address.
+func (s *EndpointService) GetMaxRateLimitsByAddr(ctx context.Context, endpoint EndpointAddr) (*EndpointRateLimits, *http.Response, error) {
+	if !endpoint.IsValid() {
+		return nil, nil, ErrMissingEndpoint
+	}
+	return s.GetMaxRateLimits(ctx, endpoint.PathEscape())
 }
 
 // SetCurrentRateLimits sets the current rate limits for the given endpoint IPv6 address and the specified limit.
 func (s *EndpointService) SetCurrentRateLimits(ctx context.Context, values *EndpointRateLimits, endpointIPv6 string) (*EndpointRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/cxns/%v/ep_rate_limits/current", endpointIPv6)
-	body, resp, err := s.client.put(ctx, path, new(endpointRateLimitResponse), values)
+	limits := new(EndpointRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*endpointRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetCurrentRateLimitsByAddr is like SetCurrentRateLimits, but takes a typed endpoint address.
+func (s *EndpointService) SetCurrentRateLimitsByAddr(ctx context.Context, values *EndpointRateLimits, endpoint EndpointAddr) (*EndpointRateLimits, *http.Response, error) {
+	if !endpoint.IsValid() {
+		return nil, nil, ErrMissingEndpoint
+	}
+	return s.SetCurrentRateLimits(ctx, values, endpoint.PathEscape())
 }
 
 // SetMaxRateLimits sets the max rate limits for the given endpoint IPv6 address and the specified limit.
 func (s *EndpointService) SetMaxRateLimits(ctx context.Context, values *EndpointRateLimits, endpointIPv6 string) (*EndpointRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/cxns/%v/ep_rate_limits/max", endpointIPv6)
-	body, resp, err := s.client.put(ctx, path, new(endpointRateLimitResponse), values)
+	limits := new(EndpointRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*endpointRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetMaxRateLimitsByAddr is like SetMaxRateLimits, but takes a typed endpoint address.
+func (s *EndpointService) SetMaxRateLimitsByAddr(ctx context.Context, values *EndpointRateLimits, endpoint EndpointAddr) (*EndpointRateLimits, *http.Response, error) {
+	if !endpoint.IsValid() {
+		return nil, nil, ErrMissingEndpoint
+	}
+	return s.SetMaxRateLimits(ctx, values, endpoint.PathEscape())
 }
@@ -48,6 +48,19 @@ type Client struct {
 	// The API token for authenticating with the API
 	APIToken string
 
+	// Retry configures automatic retries of requests that fail with a
+	// transient error. If nil, each request is attempted only once.
+	Retry *RetryPolicy
+
+	// StrictEnums rejects unknown values of the enumerated types, such
+	// as FirewallAction, in decoded responses with an *EnumError, as
+	// CheckEnums does. It is off by default so that values added to the
+	// API by later releases do not break older clients. Requests are
+	// always checked by their Validate methods, which reject unknown
+	// values whatever this setting; StrictEnums also checks the
+	// enumerated fields those methods leave out.
+	StrictEnums bool
+
 	// Reuse a single struct instead of allocating one for each service on the heap
 	common service
 
@@ -152,22 +165,37 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		return nil, err
 	}
 
-	var buf io.ReadWriter
+	var data []byte
 	if body != nil {
-		buf = new(bytes.Buffer)
+		if c.StrictEnums {
+			if err := CheckEnums(body); err != nil {
+				return nil, err
+			}
+		}
+		buf := new(bytes.Buffer)
 		enc := json.NewEncoder(buf)
 		enc.SetEscapeHTML(false)
 		err := enc.Encode(body)
 		if err != nil {
 			return nil, err
 		}
+		data = buf.Bytes()
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequest(method, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
+	// Make the body replayable so that the request can be retried.
+	if data != nil {
+		req.ContentLength = int64(len(data))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
 	if c.APIToken != "" {
 		req.Header.Set(headerToken, fmt.Sprintf(headerTokenFormat, c.APIToken))
 	}
@@ -183,11 +211,13 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 	return req, nil
 }
 
-// Do executes an HTTP request.
+// Do executes an HTTP request. If the client has a retry policy,
+// requests that fail with a transient error are retried as described
+// by RetryPolicy.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
 	req = req.WithContext(ctx)
 
-	resp, err := c.client.Do(req)
+	resp, err := c.doWithRetry(ctx, req)
 	if err != nil {
 		// If we got an error, and the context has been canceled,.
 		// the context's error is probably more useful.
@@ -213,6 +243,9 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 			if decErr == io.EOF {
 				decErr = nil // ignore EOF errors caused by empty response body
 			}
+			if decErr == nil && c.StrictEnums {
+				decErr = CheckEnums(v)
+			}
 			if decErr != nil {
 				err = decErr
 			}
@@ -222,19 +255,32 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 	return resp, err
 }
 
-// ErrorResponse represents the error response from the API.
+// ErrorResponse represents the error response from the API. Use
+// errors.Is with the sentinel errors such as ErrNotFound to classify
+// it.
 type ErrorResponse struct {
 	Response *http.Response
 	Errorr   struct {
 		Code string `json:"code"`
 		Text string `json:"text"`
 	} `json:"error"`
+
+	// Method and URL of the request that failed.
+	Method string `json:"-"`
+	URL    string `json:"-"`
+
+	// RequestID is the ID the server assigned to the request, if it
+	// returned one in the X-Request-Id or X-Correlation-Id header.
+	RequestID string `json:"-"`
 }
 
 func (r *ErrorResponse) Error() string {
-	return fmt.Sprintf("%v %v: [%d] %v - %v",
-		r.Response.Request.Method, r.Response.Request.URL,
-		r.Response.StatusCode, r.Errorr.Code, r.Errorr.Text)
+	msg := fmt.Sprintf("%v %v: [%d] %v - %v",
+		r.Method, r.URL, r.Response.StatusCode, r.Errorr.Code, r.Errorr.Text)
+	if r.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %v)", r.RequestID)
+	}
+	return msg
 }
 
 // CheckResponse checks the HTTP response for an error.
@@ -243,7 +289,11 @@ func CheckResponse(r *http.Response) error {
 		return nil
 	}
 
-	errorResponse := &ErrorResponse{Response: r}
+	errorResponse := &ErrorResponse{Response: r, RequestID: requestID(r)}
+	if r.Request != nil {
+		errorResponse.Method = r.Request.Method
+		errorResponse.URL = r.Request.URL.String()
+	}
 	data, err := ioutil.ReadAll(r.Body)
 	if err == nil && data != nil {
 		_ = json.Unmarshal(data, errorResponse)
@@ -0,0 +1,457 @@
+package enf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EnumError is returned when a string is not a known value of an
+// enumerated type. It matches ErrValidation with errors.Is.
+type EnumError struct {
+	// Type is the name of the enumerated type.
+	Type string
+
+	// Value is the rejected value.
+	Value string
+
+	// Valid lists the known values of the type.
+	Valid []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("Invalid %v %q: must be one of %v", e.Type, e.Value, strings.Join(e.Valid, ", "))
+}
+
+// Is reports whether the target is ErrValidation.
+func (e *EnumError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// enum is implemented by all enumerated types in this package.
+type enum interface {
+	String() string
+	enumType() string
+	enumValues() []string
+}
+
+func isValidEnum(e enum) bool {
+	for _, v := range e.enumValues() {
+		if e.String() == v {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEnum returns the known value of the enumerated type matching s,
+// ignoring case.
+func parseEnum(typ string, s string, values []string) (string, error) {
+	for _, v := range values {
+		if strings.EqualFold(s, v) {
+			return v, nil
+		}
+	}
+	return "", &EnumError{Type: typ, Value: s, Valid: values}
+}
+
+// CheckEnums returns an *EnumError for the first value of an enumerated
+// type in v, such as a request or a decoded response, that is not known
+// to this package. Nil pointers are skipped. Unknown values in
+// responses are otherwise accepted, so that values added to the API by
+// later releases do not break older clients; see also the StrictEnums
+// field of Client.
+func CheckEnums(v interface{}) error {
+	return checkEnums(reflect.ValueOf(v))
+}
+
+var enumInterface = reflect.TypeOf((*enum)(nil)).Elem()
+
+func checkEnums(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.String && v.Type().Implements(enumInterface) {
+		e := v.Interface().(enum)
+		if !isValidEnum(e) {
+			return &EnumError{Type: e.enumType(), Value: e.String(), Valid: e.enumValues()}
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return checkEnums(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if err := checkEnums(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := checkEnums(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkEnums(iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FirewallAction is the action a firewall rule applies to matching
+// packets.
+type FirewallAction string
+
+// Firewall actions.
+const (
+	ActionAccept FirewallAction = "ACCEPT"
+	ActionDrop   FirewallAction = "DROP"
+)
+
+var firewallActions = []string{string(ActionAccept), string(ActionDrop)}
+
+// ParseFirewallAction parses a firewall action, ignoring case.
+func ParseFirewallAction(s string) (FirewallAction, error) {
+	v, err := parseEnum("firewall action", s, firewallActions)
+	return FirewallAction(v), err
+}
+
+func (a FirewallAction) String() string       { return string(a) }
+func (a FirewallAction) enumType() string     { return "firewall action" }
+func (a FirewallAction) enumValues() []string { return firewallActions }
+
+// IsValid reports whether the action is a known value.
+func (a FirewallAction) IsValid() bool { return isValidEnum(a) }
+
+// Ptr returns a pointer to a copy of the value.
+func (a FirewallAction) Ptr() *FirewallAction { return &a }
+
+// MarshalText implements encoding.TextMarshaler.
+func (a FirewallAction) MarshalText() ([]byte, error) { return []byte(a), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *FirewallAction) UnmarshalText(text []byte) error {
+	*a = FirewallAction(text)
+	return nil
+}
+
+// FirewallDirection is the direction of the traffic a firewall rule
+// applies to, relative to the network.
+type FirewallDirection string
+
+// Firewall directions.
+const (
+	DirectionIngress FirewallDirection = "INGRESS"
+	DirectionEgress  FirewallDirection = "EGRESS"
+)
+
+var firewallDirections = []string{string(DirectionIngress), string(DirectionEgress)}
+
+// ParseFirewallDirection parses a firewall direction, ignoring case.
+func ParseFirewallDirection(s string) (FirewallDirection, error) {
+	v, err := parseEnum("firewall direction", s, firewallDirections)
+	return FirewallDirection(v), err
+}
+
+func (d FirewallDirection) String() string       { return string(d) }
+func (d FirewallDirection) enumType() string     { return "firewall direction" }
+func (d FirewallDirection) enumValues() []string { return firewallDirections }
+
+// IsValid reports whether the direction is a known value.
+func (d FirewallDirection) IsValid() bool { return isValidEnum(d) }
+
+// Ptr returns a pointer to a copy of the value.
+func (d FirewallDirection) Ptr() *FirewallDirection { return &d }
+
+// MarshalText implements encoding.TextMarshaler.
+func (d FirewallDirection) MarshalText() ([]byte, error) { return []byte(d), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *FirewallDirection) UnmarshalText(text []byte) error {
+	*d = FirewallDirection(text)
+	return nil
+}
+
+// IPFamily is the IP version a firewall rule applies to.
+type IPFamily string
+
+// IP families.
+const (
+	IPFamily4 IPFamily = "IP4"
+	IPFamily6 IPFamily = "IP6"
+)
+
+var ipFamilies = []string{string(IPFamily4), string(IPFamily6)}
+
+// ParseIPFamily parses an IP family, ignoring case.
+func ParseIPFamily(s string) (IPFamily, error) {
+	v, err := parseEnum("IP family", s, ipFamilies)
+	return IPFamily(v), err
+}
+
+func (f IPFamily) String() string       { return string(f) }
+func (f IPFamily) enumType() string     { return "IP family" }
+func (f IPFamily) enumValues() []string { return ipFamilies }
+
+// IsValid reports whether the family is a known value.
+func (f IPFamily) IsValid() bool { return isValidEnum(f) }
+
+// Ptr returns a pointer to a copy of the value.
+func (f IPFamily) Ptr() *IPFamily { return &f }
+
+// MarshalText implements encoding.TextMarshaler.
+func (f IPFamily) MarshalText() ([]byte, error) { return []byte(f), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *IPFamily) UnmarshalText(text []byte) error {
+	*f = IPFamily(text)
+	return nil
+}
+
+// FirewallProtocol is the protocol a firewall rule applies to.
+type FirewallProtocol string
+
+// Firewall protocols. ProtocolAll matches every protocol.
+const (
+	ProtocolAll   FirewallProtocol = "ALL"
+	ProtocolTCP   FirewallProtocol = "TCP"
+	ProtocolUDP   FirewallProtocol = "UDP"
+	ProtocolICMP  FirewallProtocol = "ICMP"
+	ProtocolICMP6 FirewallProtocol = "ICMP6"
+)
+
+var firewallProtocols = []string{
+	string(ProtocolAll), string(ProtocolTCP), string(ProtocolUDP),
+	string(ProtocolICMP), string(ProtocolICMP6),
+}
+
+// ParseFirewallProtocol parses a firewall protocol, ignoring case.
+func ParseFirewallProtocol(s string) (FirewallProtocol, error) {
+	v, err := parseEnum("firewall protocol", s, firewallProtocols)
+	return FirewallProtocol(v), err
+}
+
+func (p FirewallProtocol) String() string       { return string(p) }
+func (p FirewallProtocol) enumType() string     { return "firewall protocol" }
+func (p FirewallProtocol) enumValues() []string { return firewallProtocols }
+
+// IsValid reports whether the protocol is a known value.
+func (p FirewallProtocol) IsValid() bool { return isValidEnum(p) }
+
+// Ptr returns a pointer to a copy of the value.
+func (p FirewallProtocol) Ptr() *FirewallProtocol { return &p }
+
+// MarshalText implements encoding.TextMarshaler.
+func (p FirewallProtocol) MarshalText() ([]byte, error) { return []byte(p), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *FirewallProtocol) UnmarshalText(text []byte) error {
+	*p = FirewallProtocol(text)
+	return nil
+}
+
+// NetworkStatus is the status of a network.
+type NetworkStatus string
+
+// Network statuses.
+const (
+	NetworkStatusActive NetworkStatus = "ACTIVE"
+	NetworkStatusReady  NetworkStatus = "READY"
+)
+
+var networkStatuses = []string{string(NetworkStatusActive), string(NetworkStatusReady)}
+
+// ParseNetworkStatus parses a network status, ignoring case.
+func ParseNetworkStatus(s string) (NetworkStatus, error) {
+	v, err := parseEnum("network status", s, networkStatuses)
+	return NetworkStatus(v), err
+}
+
+func (s NetworkStatus) String() string       { return string(s) }
+func (s NetworkStatus) enumType() string     { return "network status" }
+func (s NetworkStatus) enumValues() []string { return networkStatuses }
+
+// IsValid reports whether the status is a known value.
+func (s NetworkStatus) IsValid() bool { return isValidEnum(s) }
+
+// Ptr returns a pointer to a copy of the value.
+func (s NetworkStatus) Ptr() *NetworkStatus { return &s }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s NetworkStatus) MarshalText() ([]byte, error) { return []byte(s), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *NetworkStatus) UnmarshalText(text []byte) error {
+	*s = NetworkStatus(text)
+	return nil
+}
+
+// DomainStatus is the status of a domain.
+type DomainStatus string
+
+// Domain statuses. A provisioned domain is READY until it is
+// activated.
+const (
+	DomainStatusActive DomainStatus = "ACTIVE"
+	DomainStatusReady  DomainStatus = "READY"
+)
+
+var domainStatuses = []string{string(DomainStatusActive), string(DomainStatusReady)}
+
+// ParseDomainStatus parses a domain status, ignoring case.
+func ParseDomainStatus(s string) (DomainStatus, error) {
+	v, err := parseEnum("domain status", s, domainStatuses)
+	return DomainStatus(v), err
+}
+
+func (s DomainStatus) String() string       { return string(s) }
+func (s DomainStatus) enumType() string     { return "domain status" }
+func (s DomainStatus) enumValues() []string { return domainStatuses }
+
+// IsValid reports whether the status is a known value.
+func (s DomainStatus) IsValid() bool { return isValidEnum(s) }
+
+// Ptr returns a pointer to a copy of the value.
+func (s DomainStatus) Ptr() *DomainStatus { return &s }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s DomainStatus) MarshalText() ([]byte, error) { return []byte(s), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *DomainStatus) UnmarshalText(text []byte) error {
+	*s = DomainStatus(text)
+	return nil
+}
+
+// UserStatus is the status of a user.
+type UserStatus string
+
+// User statuses.
+const (
+	UserStatusActive   UserStatus = "ACTIVE"
+	UserStatusInactive UserStatus = "INACTIVE"
+)
+
+var userStatuses = []string{string(UserStatusActive), string(UserStatusInactive)}
+
+// ParseUserStatus parses a user status, ignoring case.
+func ParseUserStatus(s string) (UserStatus, error) {
+	v, err := parseEnum("user status", s, userStatuses)
+	return UserStatus(v), err
+}
+
+func (s UserStatus) String() string       { return string(s) }
+func (s UserStatus) enumType() string     { return "user status" }
+func (s UserStatus) enumValues() []string { return userStatuses }
+
+// IsValid reports whether the status is a known value.
+func (s UserStatus) IsValid() bool { return isValidEnum(s) }
+
+// Ptr returns a pointer to a copy of the value.
+func (s UserStatus) Ptr() *UserStatus { return &s }
+
+// MarshalText implements encoding.TextMarshaler.
+func (s UserStatus) MarshalText() ([]byte, error) { return []byte(s), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *UserStatus) UnmarshalText(text []byte) error {
+	*s = UserStatus(text)
+	return nil
+}
+
+// UserType is the role of a user.
+type UserType string
+
+// User types.
+const (
+	UserTypeXaptumAdmin UserType = "XAPTUM_ADMIN"
+	UserTypeIAMAdmin    UserType = "IAM_ADMIN"
+	UserTypeDomainAdmin UserType = "DOMAIN_ADMIN"
+	UserTypeDomainUser  UserType = "DOMAIN_USER"
+)
+
+var userTypes = []string{
+	string(UserTypeXaptumAdmin), string(UserTypeIAMAdmin),
+	string(UserTypeDomainAdmin), string(UserTypeDomainUser),
+}
+
+// ParseUserType parses a user type, ignoring case.
+func ParseUserType(s string) (UserType, error) {
+	v, err := parseEnum("user type", s, userTypes)
+	return UserType(v), err
+}
+
+func (t UserType) String() string       { return string(t) }
+func (t UserType) enumType() string     { return "user type" }
+func (t UserType) enumValues() []string { return userTypes }
+
+// IsValid reports whether the type is a known value.
+func (t UserType) IsValid() bool { return isValidEnum(t) }
+
+// Ptr returns a pointer to a copy of the value.
+func (t UserType) Ptr() *UserType { return &t }
+
+// MarshalText implements encoding.TextMarshaler.
+func (t UserType) MarshalText() ([]byte, error) { return []byte(t), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *UserType) UnmarshalText(text []byte) error {
+	*t = UserType(text)
+	return nil
+}
+
+// RecordType is the type of a DNS record.
+type RecordType string
+
+// DNS record types.
+const (
+	RecordTypeAAAA  RecordType = "AAAA"
+	RecordTypeCNAME RecordType = "CNAME"
+	RecordTypeTXT   RecordType = "TXT"
+	RecordTypeSRV   RecordType = "SRV"
+	RecordTypePTR   RecordType = "PTR"
+	RecordTypeMX    RecordType = "MX"
+)
+
+var recordTypes = []string{
+	string(RecordTypeAAAA), string(RecordTypeCNAME), string(RecordTypeTXT),
+	string(RecordTypeSRV), string(RecordTypePTR), string(RecordTypeMX),
+}
+
+// ParseRecordType parses a DNS record type, ignoring case.
+func ParseRecordType(s string) (RecordType, error) {
+	v, err := parseEnum("record type", s, recordTypes)
+	return RecordType(v), err
+}
+
+func (t RecordType) String() string       { return string(t) }
+func (t RecordType) enumType() string     { return "record type" }
+func (t RecordType) enumValues() []string { return recordTypes }
+
+// IsValid reports whether the type is a known value.
+func (t RecordType) IsValid() bool { return isValidEnum(t) }
+
+// Ptr returns a pointer to a copy of the value.
+func (t RecordType) Ptr() *RecordType { return &t }
+
+// MarshalText implements encoding.TextMarshaler.
+func (t RecordType) MarshalText() ([]byte, error) { return []byte(t), nil }
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *RecordType) UnmarshalText(text []byte) error {
+	*t = RecordType(text)
+	return nil
+}
@@ -0,0 +1,162 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestParseFirewallAction(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FirewallAction
+		ok   bool
+	}{
+		{"ACCEPT", ActionAccept, true},
+		{"drop", ActionDrop, true},
+		{"ALLOW", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFirewallAction(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseFirewallAction(%q) returned (%q, %v), want %q", tt.in, got, err, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseFirewallAction(%q) error does not match ErrValidation", tt.in)
+		}
+	}
+}
+
+func TestParseEnums(t *testing.T) {
+	tests := []struct {
+		parse func(string) (enum, error)
+		in    string
+		want  string
+	}{
+		{func(s string) (enum, error) { return ParseFirewallDirection(s) }, "egress", "EGRESS"},
+		{func(s string) (enum, error) { return ParseIPFamily(s) }, "ip6", "IP6"},
+		{func(s string) (enum, error) { return ParseFirewallProtocol(s) }, "Icmp6", "ICMP6"},
+		{func(s string) (enum, error) { return ParseNetworkStatus(s) }, "ready", "READY"},
+		{func(s string) (enum, error) { return ParseDomainStatus(s) }, "active", "ACTIVE"},
+		{func(s string) (enum, error) { return ParseUserStatus(s) }, "inactive", "INACTIVE"},
+		{func(s string) (enum, error) { return ParseUserType(s) }, "domain_admin", "DOMAIN_ADMIN"},
+		{func(s string) (enum, error) { return ParseRecordType(s) }, "aaaa", "AAAA"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.parse(tt.in)
+		if err != nil {
+			t.Errorf("Parsing %q returned error %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parsing %q returned %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEnums_JSON(t *testing.T) {
+	rule := new(FirewallRule)
+	in := `{"action":"ACCEPT","direction":"INGRESS","ip_family":"IP6","protocol":"TCP"}`
+	if err := json.Unmarshal([]byte(in), rule); err != nil {
+		t.Fatal(err)
+	}
+	if *rule.Action != ActionAccept || *rule.Direction != DirectionIngress ||
+		*rule.IPFamily != IPFamily6 || *rule.Protocol != ProtocolTCP {
+		t.Errorf("Unmarshal returned %+v", rule)
+	}
+
+	// Unknown values are kept unless strict mode is enabled.
+	if err := json.Unmarshal([]byte(`{"action":"REJECT"}`), rule); err != nil {
+		t.Fatal(err)
+	}
+	if *rule.Action != "REJECT" || rule.Action.IsValid() {
+		t.Errorf("Unmarshal returned action %q, want invalid REJECT", *rule.Action)
+	}
+	if _, err := json.Marshal(rule); err != nil {
+		t.Errorf("Marshal returned error %v in non-strict mode", err)
+	}
+}
+
+func TestCheckEnums(t *testing.T) {
+	user := new(User)
+	if err := json.Unmarshal([]byte(`{"status":"ACTIVE","type":"DOMAIN_USER"}`), user); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckEnums(user); err != nil {
+		t.Errorf("CheckEnums returned error %v for known values", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"status":"active"}`), user); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckEnums([]*User{user})
+	var enumErr *EnumError
+	if !errors.As(err, &enumErr) || enumErr.Value != "active" || enumErr.Type != "user status" {
+		t.Errorf("Expected *EnumError for lower-case status, got %v", err)
+	}
+
+	if err := CheckEnums(&Network{Status: NetworkStatus("GONE").Ptr()}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected CheckEnums to reject unknown network status, got %v", err)
+	}
+	if err := CheckEnums(&Network{}); err != nil {
+		t.Errorf("CheckEnums returned error %v for unset values", err)
+	}
+}
+
+func TestClient_StrictEnums(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"name": "n", "status": "GONE"}]}`)
+	})
+
+	if _, _, err := client.Network.ListNetworks(context.Background(), "N"); err != nil {
+		t.Errorf("ListNetworks returned error %v without strict enums", err)
+	}
+
+	client.StrictEnums = true
+	_, _, err := client.Network.ListNetworks(context.Background(), "N")
+	var enumErr *EnumError
+	if !errors.As(err, &enumErr) || enumErr.Value != "GONE" {
+		t.Errorf("Expected *EnumError for unknown network status, got %v", err)
+	}
+
+	other, _ := NewClient(client.BaseURL.String(), nil)
+	if other.StrictEnums {
+		t.Errorf("StrictEnums of one client applies to another")
+	}
+
+	req, err := client.NewRequest("PUT", "api/xcr/v2/domains/N/status", DomainStatus("GONE"))
+	if !errors.Is(err, ErrValidation) || req != nil {
+		t.Errorf("NewRequest returned (%v, %v) for an unknown domain status", req, err)
+	}
+}
+
+func TestDomainService_ActivateDomain_body(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/domains/N/status", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if got, want := string(body), `"ACTIVE"`+"\n"; got != want {
+			t.Errorf("Request body is %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"data": [{"name": "N", "status": "ACTIVE"}]}`)
+	})
+
+	domain, _, err := client.Domains.ActivateDomain(context.Background(), "N")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *domain.Status != DomainStatusActive {
+		t.Errorf("ActivateDomain returned status %q, want %q", *domain.Status, DomainStatusActive)
+	}
+}
@@ -0,0 +1,193 @@
+package enf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrMalformedResponse is matched by an *EnvelopeError for a response
+// whose body is not a valid data envelope.
+var ErrMalformedResponse = errors.New("Malformed response")
+
+// envelope represents the standard format of the API responses: the
+// requested objects in a `data` array and the pagination information
+// in `page`.
+type envelope struct {
+	Data json.RawMessage `json:"data"`
+	Page *Page           `json:"page"`
+}
+
+// EnvelopeError is returned when a successful API response does not
+// contain the expected data envelope. An envelope with an empty data
+// array matches ErrNotFound; any other problem matches
+// ErrMalformedResponse.
+type EnvelopeError struct {
+	Response *http.Response
+
+	// Empty is true if the data array of the envelope was empty.
+	Empty bool
+
+	// Err is the underlying decoding error, if any.
+	Err error
+}
+
+func (e *EnvelopeError) Error() string {
+	prefix := ""
+	if e.Response != nil && e.Response.Request != nil {
+		prefix = fmt.Sprintf("%v %v: ", e.Response.Request.Method, e.Response.Request.URL)
+	}
+	if e.Empty {
+		return prefix + "response contains no data"
+	}
+	return prefix + fmt.Sprintf("malformed response: %v", e.Err)
+}
+
+// Is reports whether the envelope error matches the given sentinel
+// error.
+func (e *EnvelopeError) Is(target error) bool {
+	if e.Empty {
+		return target == ErrNotFound
+	}
+	return target == ErrMalformedResponse
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *EnvelopeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeEnvelope parses the raw body of the response into an envelope
+// and returns it along with its data items. A null data field has no
+// items.
+func decodeEnvelope(resp *http.Response, raw []byte) (*envelope, []json.RawMessage, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, nil, &EnvelopeError{Response: resp, Err: errors.New("empty body")}
+	}
+
+	env := new(envelope)
+	if err := json.Unmarshal(raw, env); err != nil {
+		return nil, nil, &EnvelopeError{Response: resp, Err: err}
+	}
+	if len(env.Data) == 0 {
+		return nil, nil, &EnvelopeError{Response: resp, Err: errors.New("missing data field")}
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(env.Data, &items); err != nil {
+		return nil, nil, &EnvelopeError{Response: resp, Err: err}
+	}
+	return env, items, nil
+}
+
+// decodeOne decodes the single object in the data array of the
+// response body into v. Unlike an empty array, a null data field is
+// malformed.
+func decodeOne(resp *http.Response, raw []byte, v interface{}) error {
+	env, items, err := decodeEnvelope(resp, raw)
+	if err != nil {
+		return err
+	}
+	if string(env.Data) == "null" {
+		return &EnvelopeError{Response: resp, Err: errors.New("null data field")}
+	}
+	if len(items) == 0 {
+		return &EnvelopeError{Response: resp, Empty: true}
+	}
+	if err := json.Unmarshal(items[0], v); err != nil {
+		return &EnvelopeError{Response: resp, Err: err}
+	}
+	return nil
+}
+
+// decodeList decodes the data array of the response body into v,
+// which must be a pointer to a slice. A null data field is decoded as
+// an empty list, as some endpoints return it when there are no
+// objects.
+func decodeList(resp *http.Response, raw []byte, v interface{}) (*Page, error) {
+	env, _, err := decodeEnvelope(resp, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return nil, &EnvelopeError{Response: resp, Err: err}
+	}
+	return env.Page, nil
+}
+
+// The following helpers make a request and decode the data envelope
+// of the response. Use the xxxOne variants for endpoints that return a
+// single object and getList for endpoints that return a list.
+
+// getOne makes a get request to the given path and stores the single
+// object of the response in v.
+func (c *Client) getOne(ctx context.Context, path string, queryParameters url.Values, v interface{}) (*http.Response, error) {
+	raw := new(bytes.Buffer)
+	_, resp, err := c.get(ctx, path, queryParameters, raw)
+	if err != nil {
+		return resp, err
+	}
+	if err := decodeOne(resp, raw.Bytes(), v); err != nil {
+		return resp, err
+	}
+	return resp, c.checkEnums(resp, v)
+}
+
+// postOne makes a post request to the given path with the given fields
+// and stores the single object of the response in v.
+func (c *Client) postOne(ctx context.Context, path string, fields interface{}, v interface{}) (*http.Response, error) {
+	raw := new(bytes.Buffer)
+	_, resp, err := c.post(ctx, path, raw, fields)
+	if err != nil {
+		return resp, err
+	}
+	if err := decodeOne(resp, raw.Bytes(), v); err != nil {
+		return resp, err
+	}
+	return resp, c.checkEnums(resp, v)
+}
+
+// putOne makes a put request to the given path with the given fields
+// and stores the single object of the response in v.
+func (c *Client) putOne(ctx context.Context, path string, fields interface{}, v interface{}) (*http.Response, error) {
+	raw := new(bytes.Buffer)
+	_, resp, err := c.put(ctx, path, raw, fields)
+	if err != nil {
+		return resp, err
+	}
+	if err := decodeOne(resp, raw.Bytes(), v); err != nil {
+		return resp, err
+	}
+	return resp, c.checkEnums(resp, v)
+}
+
+// getList makes a get request to the given path and stores the list of
+// objects of the response in v, which must be a pointer to a slice.
+func (c *Client) getList(ctx context.Context, path string, queryParameters url.Values, v interface{}) (*Page, *http.Response, error) {
+	raw := new(bytes.Buffer)
+	_, resp, err := c.get(ctx, path, queryParameters, raw)
+	if err != nil {
+		return nil, resp, err
+	}
+	page, err := decodeList(resp, raw.Bytes(), v)
+	if err != nil {
+		return nil, resp, err
+	}
+	return page, resp, c.checkEnums(resp, v)
+}
+
+// checkEnums checks the values of the enumerated types in the decoded
+// response v if the client is strict about them.
+func (c *Client) checkEnums(resp *http.Response, v interface{}) error {
+	if !c.StrictEnums {
+		return nil
+	}
+	if err := CheckEnums(v); err != nil {
+		return &EnvelopeError{Response: resp, Err: err}
+	}
+	return nil
+}
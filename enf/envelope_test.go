@@ -0,0 +1,133 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestEnvelope_emptyData(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		method func(*Client) error
+	}{
+		{"GetZone", "/api/xdns/2019-05-27/zones/1234", func(c *Client) error {
+			_, _, err := c.DNS.GetZone(context.Background(), "1234")
+			return err
+		}},
+		{"GetDomain", "/api/xcr/v2/domains/N", func(c *Client) error {
+			_, _, err := c.Domains.GetDomain(context.Background(), "N")
+			return err
+		}},
+		{"GetNetwork", "/api/xcr/v2/nws/N/n", func(c *Client) error {
+			_, _, err := c.Network.GetNetwork(context.Background(), "N/n")
+			return err
+		}},
+		{"CreateNetwork", "/api/xcr/v2/domains/N/nws", func(c *Client) error {
+			_, _, err := c.Network.CreateNetwork(context.Background(), "N", &NetworkRequest{Name: String("n")})
+			return err
+		}},
+		{"GetCurrentRateLimits", "/api/xcr/v2/cxns/E/ep_rate_limits/current", func(c *Client) error {
+			_, _, err := c.Endpoint.GetCurrentRateLimits(context.Background(), "E")
+			return err
+		}},
+		{"SendNewInvite", "/api/xcr/v2/domains/N/invites", func(c *Client) error {
+			_, _, err := c.User.SendNewInvite(context.Background(), "N", &SendInviteRequest{Email: String("a@b.c"), FullName: String("A"), UserType: UserTypeDomainUser.Ptr()})
+			return err
+		}},
+		{"Authenticate", "/api/xcr/v2/xauth", func(c *Client) error {
+			_, _, err := c.Auth.Authenticate(context.Background(), &AuthRequest{Username: String("u"), Password: String("p")})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc(tt.path, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"data": [], "page": {"curr": -1, "next": -1, "prev": -1}}`)
+			})
+
+			err := tt.method(client)
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+			var envErr *EnvelopeError
+			if !errors.As(err, &envErr) || !envErr.Empty {
+				t.Errorf("Expected empty *EnvelopeError, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestEnvelope_malformed(t *testing.T) {
+	bodies := []string{
+		``,
+		`{}`,
+		`{"data": null}`,
+		`{"data": {"name": "n"}}`,
+		`[{"name": "n"}]`,
+		`{"data": ["n"]}`,
+		`not json`,
+	}
+
+	for _, body := range bodies {
+		client, mux, teardown := setup()
+
+		mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		})
+
+		network, _, err := client.Network.GetNetwork(context.Background(), "N/n")
+		if network != nil {
+			t.Errorf("[%q] GetNetwork returned %+v, want nil", body, network)
+		}
+		if !errors.Is(err, ErrMalformedResponse) {
+			t.Errorf("[%q] Expected ErrMalformedResponse, got %v", body, err)
+		}
+		if errors.Is(err, ErrNotFound) {
+			t.Errorf("[%q] Malformed response matched ErrNotFound", body)
+		}
+
+		teardown()
+	}
+}
+
+func TestEnvelope_listMalformed(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"page": {"curr": -1, "next": -1, "prev": -1}}`)
+	})
+
+	_, _, err := client.Network.ListNetworks(context.Background(), "N")
+	if !errors.Is(err, ErrMalformedResponse) {
+		t.Errorf("Expected ErrMalformedResponse, got %v", err)
+	}
+}
+
+func TestEnvelope_listEmpty(t *testing.T) {
+	for _, data := range []string{`[]`, `null`} {
+		client, mux, teardown := setup()
+
+		mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data": %s, "page": {"curr": -1, "next": -1, "prev": -1}}`, data)
+		})
+
+		networks, _, err := client.Network.ListNetworks(context.Background(), "N")
+		if err != nil {
+			t.Errorf("[%v] ListNetworks returned error %v", data, err)
+		}
+		if len(networks) != 0 {
+			t.Errorf("[%v] ListNetworks returned %+v, want no networks", data, networks)
+		}
+
+		teardown()
+	}
+}
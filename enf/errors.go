@@ -0,0 +1,125 @@
+package enf
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors that an *ErrorResponse matches with errors.Is,
+// depending on its error code and HTTP status. For example:
+//
+//	if errors.Is(err, enf.ErrNotFound) {
+//		// Handle missing resource
+//	}
+var (
+	ErrNotFound     = errors.New("Not found")
+	ErrUnauthorized = errors.New("Unauthorized")
+	ErrForbidden    = errors.New("Forbidden")
+	ErrConflict     = errors.New("Conflict")
+	ErrRateLimited  = errors.New("Rate limited")
+	ErrValidation   = errors.New("Validation failed")
+	ErrServer       = errors.New("Server error")
+)
+
+// Error codes returned by the ENF API in the `error.code` field.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationError    = "validation_error"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidToken       = "invalid_token"
+	CodeForbidden          = "forbidden"
+	CodePermissionDenied   = "permission_denied"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeAlreadyExists      = "already_exists"
+	CodeRateLimited        = "rate_limited"
+	CodeInternalError      = "internal_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// errorCodes maps the known ENF error codes to the sentinel error they
+// represent.
+var errorCodes = map[string]error{
+	CodeBadRequest:         ErrValidation,
+	CodeInvalidRequest:     ErrValidation,
+	CodeValidationError:    ErrValidation,
+	CodeUnauthorized:       ErrUnauthorized,
+	CodeInvalidToken:       ErrUnauthorized,
+	CodeForbidden:          ErrForbidden,
+	CodePermissionDenied:   ErrForbidden,
+	CodeNotFound:           ErrNotFound,
+	CodeConflict:           ErrConflict,
+	CodeAlreadyExists:      ErrConflict,
+	CodeRateLimited:        ErrRateLimited,
+	CodeInternalError:      ErrServer,
+	CodeServiceUnavailable: ErrServer,
+}
+
+// requestIDHeaders are the response headers that may carry the ID the
+// server assigned to a request.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+// kind returns the sentinel error for the error response. A known
+// error code takes precedence over the HTTP status.
+func (r *ErrorResponse) kind() error {
+	if err, ok := errorCodes[strings.ToLower(r.Errorr.Code)]; ok {
+		return err
+	}
+	if r.Response == nil {
+		return nil
+	}
+	return statusKind(r.Response.StatusCode)
+}
+
+// statusKind returns the sentinel error for an HTTP status code.
+func statusKind(code int) error {
+	switch {
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrConflict
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// Is reports whether the error response represents the given sentinel
+// error, so that errors.Is(err, ErrNotFound) and similar work.
+func (r *ErrorResponse) Is(target error) bool {
+	kind := r.kind()
+	return kind != nil && kind == target
+}
+
+// Retryable reports whether the request that produced the error
+// response may succeed if sent again.
+func (r *ErrorResponse) Retryable() bool {
+	if r.Response != nil && shouldRetryStatus(r.Response.StatusCode) {
+		return true
+	}
+	switch strings.ToLower(r.Errorr.Code) {
+	case CodeRateLimited, CodeServiceUnavailable:
+		return true
+	}
+	return false
+}
+
+// requestID returns the request ID header of the response, if any.
+func requestID(r *http.Response) string {
+	for _, h := range requestIDHeaders {
+		if id := r.Header.Get(h); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestErrorResponse_Is(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+		want   error
+	}{
+		{http.StatusNotFound, "", ErrNotFound},
+		{http.StatusBadRequest, "not_found", ErrNotFound},
+		{http.StatusBadRequest, "", ErrValidation},
+		{http.StatusUnprocessableEntity, "", ErrValidation},
+		{http.StatusUnauthorized, "", ErrUnauthorized},
+		{http.StatusUnauthorized, "INVALID_TOKEN", ErrUnauthorized},
+		{http.StatusForbidden, "", ErrForbidden},
+		{http.StatusBadRequest, "permission_denied", ErrForbidden},
+		{http.StatusConflict, "", ErrConflict},
+		{http.StatusBadRequest, "already_exists", ErrConflict},
+		{http.StatusTooManyRequests, "", ErrRateLimited},
+		{http.StatusInternalServerError, "", ErrServer},
+		{http.StatusServiceUnavailable, "", ErrServer},
+		{http.StatusTeapot, "", nil},
+	}
+
+	sentinels := []error{ErrNotFound, ErrUnauthorized, ErrForbidden, ErrConflict, ErrRateLimited, ErrValidation, ErrServer}
+
+	for _, tt := range tests {
+		r := &ErrorResponse{Response: &http.Response{StatusCode: tt.status}}
+		r.Errorr.Code = tt.code
+
+		for _, s := range sentinels {
+			if got, want := errors.Is(r, s), s == tt.want; got != want {
+				t.Errorf("[%d %q] errors.Is(%v) returned %v, want %v", tt.status, tt.code, s, got, want)
+			}
+		}
+	}
+}
+
+func TestErrorResponse_Retryable(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+		want   bool
+	}{
+		{http.StatusTooManyRequests, "", true},
+		{http.StatusBadGateway, "", true},
+		{http.StatusServiceUnavailable, "", true},
+		{http.StatusGatewayTimeout, "", true},
+		{http.StatusInternalServerError, "", false},
+		{http.StatusInternalServerError, "service_unavailable", true},
+		{http.StatusNotFound, "", false},
+	}
+
+	for _, tt := range tests {
+		r := &ErrorResponse{Response: &http.Response{StatusCode: tt.status}}
+		r.Errorr.Code = tt.code
+		if got := r.Retryable(); got != tt.want {
+			t.Errorf("[%d %q] Retryable returned %v, want %v", tt.status, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCheckResponse_requestDetails(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-42")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": {"code": "not_found", "text": "network not found"}}`)
+	})
+
+	_, _, err := client.Network.GetNetwork(context.Background(), "N/n")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Fatalf("Expected *ErrorResponse, got %T", err)
+	}
+	if errResp.Method != "GET" {
+		t.Errorf("Method is %q, want GET", errResp.Method)
+	}
+	if !strings.HasSuffix(errResp.URL, "/api/xcr/v2/nws/N/n") {
+		t.Errorf("URL is %q, want suffix /api/xcr/v2/nws/N/n", errResp.URL)
+	}
+	if errResp.RequestID != "req-42" {
+		t.Errorf("RequestID is %q, want req-42", errResp.RequestID)
+	}
+	if errResp.Errorr.Text != "network not found" {
+		t.Errorf("Error text is %q, want %q", errResp.Errorr.Text, "network not found")
+	}
+	if !strings.Contains(err.Error(), "req-42") {
+		t.Errorf("Error() returned %q, want it to contain the request id", err.Error())
+	}
+}
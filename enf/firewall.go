@@ -10,8 +10,44 @@ import (
 
 var (
 	ErrMissingNetwork = errors.New("Missing required network")
+	ErrMissingRuleID  = errors.New("Missing required rule id")
 )
 
+// RuleNotFoundError is returned when a firewall rule does not exist in
+// a network. It matches ErrNotFound with errors.Is. If the API reported
+// the miss with an error response, that *ErrorResponse is wrapped and
+// can be retrieved with errors.As.
+type RuleNotFoundError struct {
+	Network string
+	ID      string
+
+	// Err is the error response returned by the API, if any.
+	Err error
+}
+
+func (e *RuleNotFoundError) Error() string {
+	return fmt.Sprintf("Rule %v not found in network %v", e.ID, e.Network)
+}
+
+// Is reports whether the target is ErrNotFound.
+func (e *RuleNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// Unwrap returns the underlying error response, if any.
+func (e *RuleNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// ruleNotFound converts a not found error from the API into a
+// *RuleNotFoundError. Other errors are returned unchanged.
+func ruleNotFound(network, id string, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return &RuleNotFoundError{Network: network, ID: id, Err: err}
+	}
+	return err
+}
+
 // FirewallService handles communication with the firewall related
 // methods of the ENF API. These methods are used to manage the
 // firewall rules for each network.
@@ -22,28 +58,121 @@ type FirewallRule struct {
 	ID      *string `json:"id"`
 	Network *string `json:"network"`
 
-	Priority   *int    `json:"priority"`
-	Action     *string `json:"action"`
-	Direction  *string `json:"direction"`
-	IPFamily   *string `json:"ip_family"`
-	Protocol   *string `json:"protocol"`
-	SourceIP   *string `json:"source_ip"`
-	SourcePort *int    `json:"source_port"`
-	DestIP     *string `json:"dest_ip"`
-	DestPort   *int    `json:"dest_port"`
+	Priority   *int               `json:"priority"`
+	Action     *FirewallAction    `json:"action"`
+	Direction  *FirewallDirection `json:"direction"`
+	IPFamily   *IPFamily          `json:"ip_family"`
+	Protocol   *FirewallProtocol  `json:"protocol"`
+	SourceIP   *string            `json:"source_ip"`
+	SourcePort *int               `json:"source_port"`
+	DestIP     *string            `json:"dest_ip"`
+	DestPort   *int               `json:"dest_port"`
+
+	// SourcePortEnd and DestPortEnd, if set, make SourcePort and
+	// DestPort the first port of an inclusive range of ports.
+	SourcePortEnd *int `json:"source_port_end,omitempty"`
+	DestPortEnd   *int `json:"dest_port_end,omitempty"`
+
+	// ICMPType and ICMPCode restrict ICMP and ICMP6 rules to the given
+	// message type and code.
+	ICMPType *int `json:"icmp_type,omitempty"`
+	ICMPCode *int `json:"icmp_code,omitempty"`
 }
 
 // FirewallRuleRequest represents the body of the request for creating a firewall rule.
 type FirewallRuleRequest struct {
-	Priority   *int    `json:"priority"`
-	Action     *string `json:"action"`
-	Direction  *string `json:"direction"`
-	IPFamily   *string `json:"ip_family"`
-	Protocol   *string `json:"protocol"`
-	SourceIP   *string `json:"source_ip"`
-	SourcePort *int    `json:"source_port"`
-	DestIP     *string `json:"dest_ip"`
-	DestPort   *int    `json:"dest_port"`
+	Priority   *int               `json:"priority"`
+	Action     *FirewallAction    `json:"action"`
+	Direction  *FirewallDirection `json:"direction"`
+	IPFamily   *IPFamily          `json:"ip_family"`
+	Protocol   *FirewallProtocol  `json:"protocol"`
+	SourceIP   *string            `json:"source_ip"`
+	SourcePort *int               `json:"source_port"`
+	DestIP     *string            `json:"dest_ip"`
+	DestPort   *int               `json:"dest_port"`
+
+	// SourcePortEnd and DestPortEnd, if set, make SourcePort and
+	// DestPort the first port of an inclusive range of ports.
+	SourcePortEnd *int `json:"source_port_end,omitempty"`
+	DestPortEnd   *int `json:"dest_port_end,omitempty"`
+
+	// ICMPType and ICMPCode restrict ICMP and ICMP6 rules to the given
+	// message type and code.
+	ICMPType *int `json:"icmp_type,omitempty"`
+	ICMPCode *int `json:"icmp_code,omitempty"`
+}
+
+// Validate checks the fields of the firewall rule request: priority,
+// action and direction are required, enumerated fields must have a
+// known value, addresses must be valid IP addresses or CIDR prefixes of
+// the rule's IP family, ports and port ranges must be in range, and the
+// ICMP type and code are only set for ICMP rules.
+func (r *FirewallRuleRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+
+	if v.requiredInt("priority", r.Priority) {
+		v.nonNegative("priority", r.Priority)
+	}
+	if v.required("action", r.Action != nil) {
+		v.enum("action", *r.Action)
+	}
+	if v.required("direction", r.Direction != nil) {
+		v.enum("direction", *r.Direction)
+	}
+	if r.IPFamily != nil {
+		v.enum("ip_family", *r.IPFamily)
+	}
+	if r.Protocol != nil {
+		v.enum("protocol", *r.Protocol)
+	}
+
+	var family IPFamily
+	if r.IPFamily != nil {
+		family = *r.IPFamily
+	}
+	v.ipOrCIDR("source_ip", r.SourceIP, family)
+	v.ipOrCIDR("dest_ip", r.DestIP, family)
+
+	v.port("source_port", r.SourcePort)
+	v.portRange("source_port", r.SourcePort, "source_port_end", r.SourcePortEnd)
+	v.port("dest_port", r.DestPort)
+	v.portRange("dest_port", r.DestPort, "dest_port_end", r.DestPortEnd)
+
+	icmp := r.Protocol != nil && (*r.Protocol == ProtocolICMP || *r.Protocol == ProtocolICMP6)
+	if icmp {
+		ports := []struct {
+			field string
+			value *int
+		}{
+			{"source_port", r.SourcePort}, {"source_port_end", r.SourcePortEnd},
+			{"dest_port", r.DestPort}, {"dest_port_end", r.DestPortEnd},
+		}
+		for _, p := range ports {
+			if p.value != nil && *p.value != 0 {
+				v.add(p.field, "is not valid for protocol %v", *r.Protocol)
+			}
+		}
+	}
+
+	if r.ICMPType != nil {
+		if icmp {
+			v.uint8("icmp_type", r.ICMPType)
+		} else {
+			v.add("icmp_type", "is only valid for protocols %v and %v", ProtocolICMP, ProtocolICMP6)
+		}
+	}
+	if r.ICMPCode != nil {
+		if r.ICMPType == nil {
+			v.add("icmp_code", "requires icmp_type")
+		} else if icmp {
+			v.uint8("icmp_code", r.ICMPCode)
+		}
+	}
+
+	return v.err()
 }
 
 // ListRules gets all the firewall rules for the given network.
@@ -56,25 +185,52 @@ func (s *FirewallService) ListRules(ctx context.Context, network string) ([]*Fir
 	return *(body.(*[]*FirewallRule)), resp, nil
 }
 
-// GetRule gets the information for the firewall rule with the given id within the given network.
+// ListRulesByAddr is like ListRules, but takes a typed network address.
+func (s *FirewallService) ListRulesByAddr(ctx context.Context, network NetworkAddr) ([]*FirewallRule, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.ListRules(ctx, network.PathEscape())
+}
+
+// GetRule gets the information for the firewall rule with the given id
+// within the given network. If the rule does not exist, the error is a
+// *RuleNotFoundError.
 func (s *FirewallService) GetRule(ctx context.Context, network string, id string) (*FirewallRule, *http.Response, error) {
-	rules, resp, err := s.ListRules(ctx, network)
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	if id == "" {
+		return nil, nil, ErrMissingRuleID
+	}
+
+	path := fmt.Sprintf("api/xfw/v1/%v/rule/%v", network, id)
+	body, resp, err := s.client.get(ctx, path, url.Values{}, new(*FirewallRule))
 	if err != nil {
-		return nil, resp, err
+		return nil, resp, ruleNotFound(network, id, err)
 	}
 
-	for _, r := range rules {
-		if *r.ID == id {
-			return r, resp, nil
-		}
+	rule := *(body.(**FirewallRule))
+	if rule == nil {
+		return nil, resp, &RuleNotFoundError{Network: network, ID: id}
 	}
+	return rule, resp, nil
+}
 
-	resp.StatusCode = 404
-	return nil, resp, fmt.Errorf("Rule not found")
+// GetRuleByAddr is like GetRule, but takes a typed network address.
+func (s *FirewallService) GetRuleByAddr(ctx context.Context, network NetworkAddr, id string) (*FirewallRule, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.GetRule(ctx, network.PathEscape(), id)
 }
 
 // CreateRule creates a firewall rule for the given network.
 func (s *FirewallService) CreateRule(ctx context.Context, network string, rule *FirewallRuleRequest) (*FirewallRule, *http.Response, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xfw/v1/%v/rule", network)
 	body, resp, err := s.client.post(ctx, path, new(FirewallRule), rule)
 	if err != nil {
@@ -83,8 +239,57 @@ func (s *FirewallService) CreateRule(ctx context.Context, network string, rule *
 	return body.(*FirewallRule), resp, nil
 }
 
+// CreateRuleByAddr is like CreateRule, but takes a typed network address.
+func (s *FirewallService) CreateRuleByAddr(ctx context.Context, network NetworkAddr, rule *FirewallRuleRequest) (*FirewallRule, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.CreateRule(ctx, network.PathEscape(), rule)
+}
+
+// UpdateRule replaces the firewall rule with the given id within the
+// given network. The rule keeps its id, so unlike deleting and
+// recreating it, the network's policy never goes without the rule. If
+// the rule does not exist, the error is a *RuleNotFoundError.
+func (s *FirewallService) UpdateRule(ctx context.Context, network string, id string, rule *FirewallRuleRequest) (*FirewallRule, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	if id == "" {
+		return nil, nil, ErrMissingRuleID
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	path := fmt.Sprintf("api/xfw/v1/%v/rule/%v", network, id)
+	body, resp, err := s.client.put(ctx, path, new(FirewallRule), rule)
+	if err != nil {
+		return nil, resp, ruleNotFound(network, id, err)
+	}
+	return body.(*FirewallRule), resp, nil
+}
+
+// UpdateRuleByAddr is like UpdateRule, but takes a typed network address.
+func (s *FirewallService) UpdateRuleByAddr(ctx context.Context, network NetworkAddr, id string, rule *FirewallRuleRequest) (*FirewallRule, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.UpdateRule(ctx, network.PathEscape(), id, rule)
+}
+
 // DeleteRule deletes the firewall rule associated with the given network address and ID.
+// If the rule does not exist, the error is a *RuleNotFoundError.
 func (s *FirewallService) DeleteRule(ctx context.Context, network string, id string) (*http.Response, error) {
 	path := fmt.Sprintf("api/xfw/v1/%v/rule/%v", network, id)
-	return s.client.delete(ctx, path)
+	resp, err := s.client.delete(ctx, path)
+	return resp, ruleNotFound(network, id, err)
+}
+
+// DeleteRuleByAddr is like DeleteRule, but takes a typed network address.
+func (s *FirewallService) DeleteRuleByAddr(ctx context.Context, network NetworkAddr, id string) (*http.Response, error) {
+	if !network.IsValid() {
+		return nil, ErrMissingNetwork
+	}
+	return s.DeleteRule(ctx, network.PathEscape(), id)
 }
@@ -0,0 +1,70 @@
+package enf
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTooManyRules = errors.New("Port ranges expand to too many rules")
+)
+
+// ExpandPortRanges replaces the rules with port ranges by rules that
+// each match a single source and destination port, for API versions
+// that don't support ranges. Every combination of source and
+// destination port gets its own rule, with the priority of the original
+// rule. A range that covers every port becomes a rule for any port, so
+// the result is the smallest set of single-port rules that match the
+// same packets. Rules without ranges are returned unchanged.
+//
+// If limit is positive and the result would have more than limit rules,
+// ExpandPortRanges returns ErrTooManyRules.
+func ExpandPortRanges(rules []FirewallRuleRequest, limit int) ([]FirewallRuleRequest, error) {
+	var expanded []FirewallRuleRequest
+	for i := range rules {
+		r := rules[i]
+		if r.SourcePortEnd == nil && r.DestPortEnd == nil {
+			expanded = append(expanded, r)
+			if limit > 0 && len(expanded) > limit {
+				return nil, fmt.Errorf("%w: more than %d rules", ErrTooManyRules, limit)
+			}
+			continue
+		}
+
+		srcPorts := expandPorts(r.SourcePort, r.SourcePortEnd)
+		dstPorts := expandPorts(r.DestPort, r.DestPortEnd)
+		if n := len(expanded) + len(srcPorts)*len(dstPorts); limit > 0 && n > limit {
+			return nil, fmt.Errorf("%w: more than %d rules", ErrTooManyRules, limit)
+		}
+
+		r.SourcePortEnd, r.DestPortEnd = nil, nil
+		for _, src := range srcPorts {
+			for _, dst := range dstPorts {
+				r.SourcePort, r.DestPort = src, dst
+				expanded = append(expanded, r)
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// expandPorts returns the single ports of a port range. A range of
+// every port is returned as a nil port, which matches any port.
+func expandPorts(start, end *int) []*int {
+	if end == nil {
+		return []*int{start}
+	}
+	first := intValue(start)
+	if *end < first {
+		return []*int{start}
+	}
+	if first <= 1 && *end >= 65535 {
+		return []*int{nil}
+	}
+
+	ports := make([]*int, 0, *end-first+1)
+	for p := first; p <= *end; p++ {
+		ports = append(ports, Int(p))
+	}
+	return ports
+}
@@ -0,0 +1,57 @@
+package enf
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExpandPortRanges(t *testing.T) {
+	rules := []FirewallRuleRequest{
+		{Priority: Int(1), Action: ActionDrop.Ptr(), Direction: DirectionIngress.Ptr(), DestPort: Int(22)},
+		{
+			Priority: Int(2), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			SourcePort: Int(1), SourcePortEnd: Int(65535), DestPort: Int(8000), DestPortEnd: Int(8002),
+		},
+	}
+
+	got, err := ExpandPortRanges(rules, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FirewallRuleRequest{rules[0]}
+	for _, port := range []int{8000, 8001, 8002} {
+		want = append(want, FirewallRuleRequest{
+			Priority: Int(2), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(), DestPort: Int(port),
+		})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandPortRanges returned %+v, want %+v", got, want)
+	}
+	if rules[1].DestPortEnd == nil {
+		t.Errorf("ExpandPortRanges modified its input")
+	}
+
+	if _, err := ExpandPortRanges(rules, 3); !errors.Is(err, ErrTooManyRules) {
+		t.Errorf("Expected ErrTooManyRules, got %v", err)
+	}
+}
+
+func TestFirewallRule_rangesJSON(t *testing.T) {
+	data, _ := json.Marshal(&FirewallRuleRequest{Priority: Int(1)})
+	want := `{"priority":1,"action":null,"direction":null,"ip_family":null,"protocol":null,"source_ip":null,"source_port":null,"dest_ip":null,"dest_port":null}`
+	if string(data) != want {
+		t.Errorf("Marshal returned %s, want %s", data, want)
+	}
+
+	rule := new(FirewallRule)
+	in := `{"dest_port":8000,"dest_port_end":8100,"icmp_type":128,"icmp_code":0}`
+	if err := json.Unmarshal([]byte(in), rule); err != nil {
+		t.Fatal(err)
+	}
+	if *rule.DestPortEnd != 8100 || *rule.ICMPType != 128 || *rule.ICMPCode != 0 {
+		t.Errorf("Unmarshal returned %+v", rule)
+	}
+}
@@ -0,0 +1,294 @@
+package enf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// DefaultPrioritySpacing is the gap between the priorities assigned by
+// the rule insertion and renumbering methods, unless configured
+// otherwise.
+const DefaultPrioritySpacing = 10
+
+// PriorityOptions specifies the optional parameters to the rule
+// insertion and renumbering methods.
+type PriorityOptions struct {
+	// Spacing is the gap between assigned priorities. It defaults to
+	// DefaultPrioritySpacing.
+	Spacing int
+}
+
+func (o *PriorityOptions) spacing() int {
+	if o == nil || o.Spacing <= 0 {
+		return DefaultPrioritySpacing
+	}
+	return o.Spacing
+}
+
+// priorityMove is a change of priority of an existing rule.
+type priorityMove struct {
+	rule     *FirewallRule
+	priority int
+}
+
+// InsertRuleBefore creates the rule with a priority that places it just
+// before the rule with the given id in evaluation order. The priority
+// of the given rule is ignored and the rule is not modified.
+//
+// If there is no free priority between the rule with the given id and
+// the one before it, the fewest rules around the insertion point are
+// renumbered to make room. Rules are moved one at a time in an order
+// that keeps their relative order, so the network's policy never
+// changes until the new rule is created.
+func (s *FirewallService) InsertRuleBefore(ctx context.Context, network, id string, rule *FirewallRuleRequest, opts *PriorityOptions) (*FirewallRule, *http.Response, error) {
+	return s.insertRule(ctx, network, rule, opts, func(rules []*FirewallRule) (int, error) {
+		return ruleIndex(network, rules, id)
+	})
+}
+
+// InsertRuleAfter creates the rule with a priority that places it just
+// after the rule with the given id in evaluation order. See
+// InsertRuleBefore.
+func (s *FirewallService) InsertRuleAfter(ctx context.Context, network, id string, rule *FirewallRuleRequest, opts *PriorityOptions) (*FirewallRule, *http.Response, error) {
+	return s.insertRule(ctx, network, rule, opts, func(rules []*FirewallRule) (int, error) {
+		i, err := ruleIndex(network, rules, id)
+		return i + 1, err
+	})
+}
+
+// InsertRuleFirst creates the rule with a priority that places it
+// before every other rule of the network. See InsertRuleBefore.
+func (s *FirewallService) InsertRuleFirst(ctx context.Context, network string, rule *FirewallRuleRequest, opts *PriorityOptions) (*FirewallRule, *http.Response, error) {
+	return s.insertRule(ctx, network, rule, opts, func(rules []*FirewallRule) (int, error) {
+		return 0, nil
+	})
+}
+
+// InsertRuleLast creates the rule with a priority that places it after
+// every other rule of the network. See InsertRuleBefore.
+func (s *FirewallService) InsertRuleLast(ctx context.Context, network string, rule *FirewallRuleRequest, opts *PriorityOptions) (*FirewallRule, *http.Response, error) {
+	return s.insertRule(ctx, network, rule, opts, func(rules []*FirewallRule) (int, error) {
+		return len(rules), nil
+	})
+}
+
+// RenumberRules gives the rules of the network evenly spaced
+// priorities, starting at the spacing, without changing their order.
+// Rules that already have their new priority are not updated, and the
+// others are moved in an order that never changes the network's
+// policy. It returns the rules in evaluation order.
+func (s *FirewallService) RenumberRules(ctx context.Context, network string, opts *PriorityOptions) ([]*FirewallRule, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	current, resp, err := s.ListRules(ctx, network)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	rules := orderRules(current)
+	spacing := opts.spacing()
+	priorities := make([]int, len(rules))
+	for i := range rules {
+		priorities[i] = (i + 1) * spacing
+	}
+
+	updated, resp, err := s.applyMoves(ctx, network, planMoves(rules, priorities))
+	if err != nil {
+		return nil, resp, err
+	}
+	for i, r := range rules {
+		if u := updated[r]; u != nil {
+			rules[i] = u
+		}
+	}
+	return rules, resp, nil
+}
+
+// insertRule creates the rule at the position in evaluation order
+// returned by the given function, renumbering rules if needed.
+func (s *FirewallService) insertRule(ctx context.Context, network string, rule *FirewallRuleRequest, opts *PriorityOptions, position func([]*FirewallRule) (int, error)) (*FirewallRule, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	if rule == nil {
+		return nil, nil, nilRequestError()
+	}
+	req := *rule
+	req.Priority = Int(0)
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	current, resp, err := s.ListRules(ctx, network)
+	if err != nil {
+		return nil, resp, err
+	}
+	rules := orderRules(current)
+	k, err := position(rules)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	priority, moves := planInsert(rules, k, opts.spacing())
+	if _, resp, err := s.applyMoves(ctx, network, moves); err != nil {
+		return nil, resp, err
+	}
+	req.Priority = Int(priority)
+	return s.CreateRule(ctx, network, &req)
+}
+
+// applyMoves updates the priorities of the rules in the given order and
+// returns the updated rules by their original.
+func (s *FirewallService) applyMoves(ctx context.Context, network string, moves []priorityMove) (map[*FirewallRule]*FirewallRule, *http.Response, error) {
+	for _, m := range moves {
+		if m.rule.ID == nil {
+			return nil, nil, fmt.Errorf("Cannot move rule at priority %d: %w", intValue(m.rule.Priority), ErrMissingRuleID)
+		}
+	}
+
+	updated := make(map[*FirewallRule]*FirewallRule)
+	var resp *http.Response
+	for _, m := range moves {
+		req := m.rule.Request()
+		req.Priority = Int(m.priority)
+
+		var r *FirewallRule
+		var err error
+		r, resp, err = s.UpdateRule(ctx, network, *m.rule.ID, req)
+		if err != nil {
+			return nil, resp, err
+		}
+		updated[m.rule] = r
+	}
+	return updated, resp, nil
+}
+
+// orderRules returns the rules in evaluation order, leaving out nil
+// rules. Rules with the same priority keep their order.
+func orderRules(rules []*FirewallRule) []*FirewallRule {
+	ordered := make([]*FirewallRule, 0, len(rules))
+	for _, r := range rules {
+		if r != nil {
+			ordered = append(ordered, r)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return intValue(ordered[i].Priority) < intValue(ordered[j].Priority)
+	})
+	return ordered
+}
+
+func ruleIndex(network string, rules []*FirewallRule, id string) (int, error) {
+	if id == "" {
+		return 0, ErrMissingRuleID
+	}
+	for i, r := range rules {
+		if r.ID != nil && *r.ID == id {
+			return i, nil
+		}
+	}
+	return 0, &RuleNotFoundError{Network: network, ID: id}
+}
+
+// planInsert returns the priority for a new rule at position k of the
+// ordered rules, and the moves that make room for it. It renumbers the
+// smallest window of rules around k whose neighbours leave enough
+// room, which is always possible by extending the window to the end.
+// Priorities stay positive, so a rule can always be placed first.
+func planInsert(rules []*FirewallRule, k, spacing int) (int, []priorityMove) {
+	n := len(rules)
+	best := -1
+	var bestA, bestB int
+	for a := k; a >= 0; a-- {
+		for b := k; b <= n; b++ {
+			if best >= 0 && b-a >= best {
+				break
+			}
+			if fits(rules, a, b) {
+				best, bestA, bestB = b-a, a, b
+				break
+			}
+		}
+	}
+
+	lo := 0
+	if bestA > 0 {
+		lo = intValue(rules[bestA-1].Priority)
+	}
+	priorities := spread(rules, bestA, bestB, lo, spacing)
+	priority := priorities[k-bestA]
+	existing := append(priorities[:k-bestA:k-bestA], priorities[k-bestA+1:]...)
+	return priority, planMoves(rules[bestA:bestB], existing)
+}
+
+// fits reports whether the rules in [a, b) and a new rule fit between
+// the priorities of the rules before a and at b.
+func fits(rules []*FirewallRule, a, b int) bool {
+	if b == len(rules) {
+		return true
+	}
+	lo := 0
+	if a > 0 {
+		lo = intValue(rules[a-1].Priority)
+	}
+	return intValue(rules[b].Priority)-lo-1 >= b-a+1
+}
+
+// spread returns increasing priorities for the rules in [a, b) and a
+// new rule, between lo and the priority of the rule at b. A single new
+// rule goes in the middle of the gap, or a spacing away from its only
+// neighbour.
+func spread(rules []*FirewallRule, a, b, lo, spacing int) []int {
+	count := b - a + 1
+	priorities := make([]int, count)
+
+	if b == len(rules) {
+		for i := range priorities {
+			priorities[i] = lo + (i+1)*spacing
+		}
+		return priorities
+	}
+
+	hi := intValue(rules[b].Priority)
+	if count == 1 {
+		switch {
+		case a == 0 && hi-spacing > lo:
+			priorities[0] = hi - spacing
+		default:
+			priorities[0] = lo + (hi-lo)/2
+		}
+		return priorities
+	}
+
+	step := (hi - lo) / (count + 1)
+	if step > spacing {
+		step = spacing
+	}
+	for i := range priorities {
+		priorities[i] = lo + (i+1)*step
+	}
+	return priorities
+}
+
+// planMoves returns the moves that give the rules the new priorities,
+// leaving out rules that already have them. Rules that move to a lower
+// priority are moved first, from the first to the last, then rules
+// that move to a higher priority, from the last to the first. No rule
+// ever passes or ties with its neighbours, so the evaluation order
+// stays the same after every move.
+func planMoves(rules []*FirewallRule, priorities []int) []priorityMove {
+	var down, up []priorityMove
+	for i, r := range rules {
+		p := priorities[i]
+		switch old := intValue(r.Priority); {
+		case p < old:
+			down = append(down, priorityMove{r, p})
+		case p > old:
+			up = append([]priorityMove{{r, p}}, up...)
+		}
+	}
+	return append(down, up...)
+}
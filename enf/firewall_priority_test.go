@@ -0,0 +1,206 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// priorityRules returns rules with IDs a, b, c, ... and the given
+// priorities.
+func priorityRules(priorities ...int) []*FirewallRule {
+	rules := make([]*FirewallRule, len(priorities))
+	for i, p := range priorities {
+		rules[i] = &FirewallRule{
+			ID:        String(string(rune('a' + i))),
+			Priority:  Int(p),
+			Action:    ActionAccept.Ptr(),
+			Direction: DirectionIngress.Ptr(),
+		}
+	}
+	return rules
+}
+
+func describeMoves(moves []priorityMove) []string {
+	var steps []string
+	for _, m := range moves {
+		steps = append(steps, fmt.Sprintf("%v:%d", *m.rule.ID, m.priority))
+	}
+	return steps
+}
+
+func TestPlanInsert(t *testing.T) {
+	tests := []struct {
+		name       string
+		priorities []int
+		k          int
+		want       int
+		moves      []string
+	}{
+		{"gap", []int{10, 20}, 1, 15, nil},
+		{"last", []int{10, 20}, 2, 30, nil},
+		{"empty", nil, 0, 10, nil},
+		{"first", []int{100}, 0, 90, nil},
+		{"first small gap", []int{6}, 0, 3, nil},
+		{"no gap before", []int{10, 11, 12, 30}, 1, 6, []string{"a:3"}},
+		{"no gap after", []int{10, 11, 12, 30}, 2, 17, []string{"c:23"}},
+		{"no gap first", []int{1, 2}, 0, 10, []string{"b:30", "a:20"}},
+		{"tie", []int{10, 10}, 1, 20, []string{"b:30"}},
+	}
+	for _, tt := range tests {
+		rules := priorityRules(tt.priorities...)
+		got, moves := planInsert(rules, tt.k, 10)
+		if got != tt.want {
+			t.Errorf("[%v] planInsert returned priority %d, want %d", tt.name, got, tt.want)
+		}
+		if steps := describeMoves(moves); !reflect.DeepEqual(steps, tt.moves) {
+			t.Errorf("[%v] planInsert returned moves %v, want %v", tt.name, steps, tt.moves)
+		}
+	}
+}
+
+// TestPlanMoves checks that the rules keep their order after every
+// move.
+func TestPlanMoves(t *testing.T) {
+	rules := priorityRules(5, 6, 7, 40, 41, 90)
+	priorities := []int{10, 20, 30, 40, 50, 60}
+
+	moves := planMoves(rules, priorities)
+	if want := []string{"f:60", "a:10", "b:20", "c:30", "e:50"}; len(moves) != len(want) {
+		t.Fatalf("planMoves returned %v, want the moves of %v in a safe order", describeMoves(moves), want)
+	}
+
+	current := make(map[*FirewallRule]int)
+	for _, r := range rules {
+		current[r] = *r.Priority
+	}
+	for _, m := range moves {
+		current[m.rule] = m.priority
+		for i := 1; i < len(rules); i++ {
+			if current[rules[i-1]] >= current[rules[i]] {
+				t.Fatalf("After moving %v to %d, %v (%d) is not before %v (%d)", *m.rule.ID, m.priority,
+					*rules[i-1].ID, current[rules[i-1]], *rules[i].ID, current[rules[i]])
+			}
+		}
+	}
+	for i, r := range rules {
+		if current[r] != priorities[i] {
+			t.Errorf("Rule %v ended at priority %d, want %d", *r.ID, current[r], priorities[i])
+		}
+	}
+}
+
+func TestFirewallService_InsertRuleBefore(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	var calls []string
+	mux.HandleFunc("/api/xfw/v1/N/rule", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[
+				{"id": "c", "priority": 12, "action": "DROP", "direction": "INGRESS"},
+				{"id": "a", "priority": 10, "action": "ACCEPT", "direction": "INGRESS"},
+				{"id": "b", "priority": 11, "action": "ACCEPT", "direction": "INGRESS"}
+			]`)
+		case "POST":
+			req := new(FirewallRuleRequest)
+			json.NewDecoder(r.Body).Decode(req)
+			calls = append(calls, fmt.Sprintf("POST %d", *req.Priority))
+			fmt.Fprintf(w, `{"id": "d", "priority": %d}`, *req.Priority)
+		default:
+			t.Errorf("Unexpected %v request", r.Method)
+		}
+	})
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		mux.HandleFunc("/api/xfw/v1/N/rule/"+id, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "PUT")
+			req := new(FirewallRuleRequest)
+			json.NewDecoder(r.Body).Decode(req)
+			calls = append(calls, fmt.Sprintf("PUT %v %d", id, *req.Priority))
+			fmt.Fprintf(w, `{"id": %q, "priority": %d}`, id, *req.Priority)
+		})
+	}
+
+	rule := &FirewallRuleRequest{Action: ActionDrop.Ptr(), Direction: DirectionIngress.Ptr()}
+	got, _, err := client.Firewall.InsertRuleBefore(context.Background(), "N", "b", rule, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"PUT a 3", "POST 6"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("InsertRuleBefore made calls %v, want %v", calls, want)
+	}
+	if *got.ID != "d" || rule.Priority != nil {
+		t.Errorf("InsertRuleBefore returned %+v and set the priority of its argument", got)
+	}
+
+	calls = nil
+	if _, _, err := client.Firewall.InsertRuleAfter(context.Background(), "N", "c", rule, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST 22"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("InsertRuleAfter made calls %v, want %v", calls, want)
+	}
+
+	calls = nil
+	if _, _, err := client.Firewall.InsertRuleFirst(context.Background(), "N", rule, &PriorityOptions{Spacing: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST 5"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("InsertRuleFirst made calls %v, want %v", calls, want)
+	}
+
+	calls = nil
+	_, _, err = client.Firewall.InsertRuleBefore(context.Background(), "N", "x", rule, nil)
+	var notFound *RuleNotFoundError
+	if !errors.As(err, &notFound) || notFound.ID != "x" || len(calls) != 0 {
+		t.Errorf("InsertRuleBefore returned error %v for a missing rule, made calls %v", err, calls)
+	}
+
+	_, _, err = client.Firewall.InsertRuleLast(context.Background(), "N", &FirewallRuleRequest{}, nil)
+	if fieldNames(t, err) == nil || len(calls) != 0 {
+		t.Errorf("InsertRuleLast returned error %v for an invalid rule, made calls %v", err, calls)
+	}
+}
+
+func TestFirewallService_RenumberRules(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	var calls []string
+	mux.HandleFunc("/api/xfw/v1/N/rule", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `[
+			{"id": "a", "priority": 1, "action": "ACCEPT", "direction": "INGRESS"},
+			{"id": "b", "priority": 2, "action": "DROP", "direction": "INGRESS"},
+			{"id": "c", "priority": 3, "action": "DROP", "direction": "EGRESS"}
+		]`)
+	})
+	mux.HandleFunc("/api/xfw/v1/N/rule/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PUT")
+		id := strings.TrimPrefix(r.URL.Path, "/api/xfw/v1/N/rule/")
+		req := new(FirewallRuleRequest)
+		json.NewDecoder(r.Body).Decode(req)
+		calls = append(calls, fmt.Sprintf("PUT %v %d", id, *req.Priority))
+		fmt.Fprintf(w, `{"id": %q, "priority": %d}`, id, *req.Priority)
+	})
+
+	rules, _, err := client.Firewall.RenumberRules(context.Background(), "N", &PriorityOptions{Spacing: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"PUT c 300", "PUT b 200", "PUT a 100"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("RenumberRules made calls %v, want %v", calls, want)
+	}
+	for i, r := range rules {
+		if *r.Priority != (i+1)*100 {
+			t.Errorf("RenumberRules returned rule %v with priority %d", *r.ID, *r.Priority)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package enf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+)
+
+// SyncOp is the kind of change in a firewall sync plan or a DNS
+// reconcile plan.
+type SyncOp string
+
+// The changes a sync or reconcile plan is made of.
+const (
+	SyncCreate SyncOp = "create"
+	SyncUpdate SyncOp = "update"
+	SyncDelete SyncOp = "delete"
+)
+
+// SyncOptions specifies the optional parameters to SyncRules.
+type SyncOptions struct {
+	// DryRun computes the plan without applying it.
+	DryRun bool
+}
+
+// SyncStep is a single change in a firewall sync plan.
+type SyncStep struct {
+	Op SyncOp
+
+	// Current is the existing rule that is updated or deleted. It is
+	// nil for creates.
+	Current *FirewallRule
+
+	// Desired is the rule that is created, or the new state of an
+	// updated rule. It is nil for deletes.
+	Desired *FirewallRuleRequest
+
+	// Result is the rule returned by the API once a create or update
+	// has been applied.
+	Result *FirewallRule
+}
+
+// SyncPlan is the set of changes that brings the rules of a network in
+// line with a desired ruleset.
+type SyncPlan struct {
+	Network string
+
+	// Steps are the changes, in the order they are applied.
+	Steps []*SyncStep
+
+	// Unchanged are the existing rules that already match a desired
+	// rule, including its priority.
+	Unchanged []*FirewallRule
+
+	// Applied is the number of steps that have been applied. If
+	// SyncRules fails part way through, Steps[Applied] is the step
+	// that failed.
+	Applied int
+}
+
+// Empty reports whether the plan has no changes.
+func (p *SyncPlan) Empty() bool {
+	return len(p.Steps) == 0
+}
+
+// Request returns a request that recreates the rule, without its
+// server-assigned fields.
+func (r *FirewallRule) Request() *FirewallRuleRequest {
+	return &FirewallRuleRequest{
+		Priority:   r.Priority,
+		Action:     r.Action,
+		Direction:  r.Direction,
+		IPFamily:   r.IPFamily,
+		Protocol:   r.Protocol,
+		SourceIP:   r.SourceIP,
+		SourcePort: r.SourcePort,
+		DestIP:     r.DestIP,
+		DestPort:   r.DestPort,
+
+		SourcePortEnd: r.SourcePortEnd,
+		DestPortEnd:   r.DestPortEnd,
+		ICMPType:      r.ICMPType,
+		ICMPCode:      r.ICMPCode,
+	}
+}
+
+// SyncRules makes the firewall rules of the given network match the
+// desired ruleset. Existing rules are matched to desired rules by the
+// traffic they match and their action, not by ID: matching rules are
+// kept, or updated in place if only their priority differs. Rules
+// without a match are created or deleted.
+//
+// The changes are applied in two phases. First the changes that can
+// only block traffic: new DROP rules, deleted ACCEPT rules, DROP rules
+// that move to an earlier priority and ACCEPT rules that move to a
+// later one. Then the changes that can allow traffic. During the first
+// phase the network allows no more than the old policy, and during the
+// second no more than the new one.
+//
+// If opts.DryRun is set, the plan is returned without being applied.
+// If a step fails, the plan records how many steps were applied.
+func (s *FirewallService) SyncRules(ctx context.Context, network string, desired []FirewallRuleRequest, opts *SyncOptions) (*SyncPlan, *http.Response, error) {
+	if network == "" {
+		return nil, nil, ErrMissingNetwork
+	}
+	for i := range desired {
+		if err := desired[i].Validate(); err != nil {
+			return nil, nil, fmt.Errorf("Desired rule %d: %w", i, err)
+		}
+	}
+
+	current, resp, err := s.ListRules(ctx, network)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	plan := planSync(network, current, desired)
+	if opts != nil && opts.DryRun {
+		return plan, resp, nil
+	}
+
+	for _, step := range plan.Steps {
+		switch step.Op {
+		case SyncCreate:
+			step.Result, resp, err = s.CreateRule(ctx, network, step.Desired)
+		case SyncUpdate:
+			step.Result, resp, err = s.UpdateRule(ctx, network, *step.Current.ID, step.Desired)
+		case SyncDelete:
+			resp, err = s.DeleteRule(ctx, network, *step.Current.ID)
+		}
+		if err != nil {
+			return plan, resp, err
+		}
+		plan.Applied++
+	}
+
+	return plan, resp, nil
+}
+
+// planSync computes the steps that turn the current rules into the
+// desired ones, in a safe order.
+func planSync(network string, current []*FirewallRule, desired []FirewallRuleRequest) *SyncPlan {
+	plan := &SyncPlan{Network: network}
+
+	// Group the current rules by key, ordered by priority so that
+	// updates pick the closest candidates first.
+	sorted := append([]*FirewallRule(nil), current...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return intValue(sorted[i].Priority) < intValue(sorted[j].Priority)
+	})
+	byKey := make(map[string][]*FirewallRule)
+	for _, r := range sorted {
+		k := r.Request().key()
+		byKey[k] = append(byKey[k], r)
+	}
+
+	take := func(k string, i int) *FirewallRule {
+		rules := byKey[k]
+		r := rules[i]
+		byKey[k] = append(rules[:i:i], rules[i+1:]...)
+		return r
+	}
+
+	// Keep the rules that match exactly before pairing the rest, so
+	// that a rule with the right priority is never moved.
+	var unmatched []*FirewallRuleRequest
+	for i := range desired {
+		d := &desired[i]
+		k := d.key()
+		found := false
+		for j, r := range byKey[k] {
+			if intValue(r.Priority) == intValue(d.Priority) {
+				plan.Unchanged = append(plan.Unchanged, take(k, j))
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, d)
+		}
+	}
+
+	var tighten, loosen []*SyncStep
+	for _, d := range unmatched {
+		k := d.key()
+		if len(byKey[k]) > 0 {
+			r := take(k, 0)
+			step := &SyncStep{Op: SyncUpdate, Current: r, Desired: d}
+			earlier := intValue(d.Priority) < intValue(r.Priority)
+			if isDrop(d.Action) == earlier {
+				tighten = append(tighten, step)
+			} else {
+				loosen = append(loosen, step)
+			}
+			continue
+		}
+
+		step := &SyncStep{Op: SyncCreate, Desired: d}
+		if isDrop(d.Action) {
+			tighten = append(tighten, step)
+		} else {
+			loosen = append(loosen, step)
+		}
+	}
+
+	for _, r := range sorted {
+		if rules := byKey[r.Request().key()]; !containsRule(rules, r) {
+			continue
+		}
+		step := &SyncStep{Op: SyncDelete, Current: r}
+		if isDrop(r.Action) {
+			loosen = append(loosen, step)
+		} else {
+			tighten = append(tighten, step)
+		}
+	}
+
+	plan.Steps = append(tighten, loosen...)
+	return plan
+}
+
+func containsRule(rules []*FirewallRule, r *FirewallRule) bool {
+	for _, c := range rules {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+// isDrop reports whether the action blocks traffic. A missing or
+// unknown action is treated as blocking, which is the cautious choice
+// when ordering changes.
+func isDrop(a *FirewallAction) bool {
+	return a == nil || *a != ActionAccept
+}
+
+func intValue(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// key returns the fields of the rule that determine what it matches and
+// what it does, normalized so that equivalent rules have the same key.
+// The priority is not part of the key.
+func (r *FirewallRuleRequest) key() string {
+	var action FirewallAction
+	if r.Action != nil {
+		action = *r.Action
+	}
+	var direction FirewallDirection
+	if r.Direction != nil {
+		direction = *r.Direction
+	}
+	protocol := ProtocolAll
+	if r.Protocol != nil && *r.Protocol != "" {
+		protocol = *r.Protocol
+	}
+
+	family, src, dst := normalizeAddrs(r.IPFamily, r.SourceIP, r.DestIP)
+	return fmt.Sprintf("%v|%v|%v|%v|%v|%v|%v|%v|%v",
+		action, direction, family, protocol, src, portKey(r.SourcePort, r.SourcePortEnd),
+		dst, portKey(r.DestPort, r.DestPortEnd), icmpKey(r.ICMPType, r.ICMPCode))
+}
+
+// portKey returns the normalized form of a port or port range.
+func portKey(start, end *int) string {
+	s := intValue(start)
+	if end == nil || *end == s {
+		return strconv.Itoa(s)
+	}
+	return fmt.Sprintf("%d-%d", s, *end)
+}
+
+// icmpKey returns the normalized form of an ICMP type and code.
+func icmpKey(typ, code *int) string {
+	switch {
+	case typ == nil:
+		return "*"
+	case code == nil:
+		return strconv.Itoa(*typ)
+	}
+	return fmt.Sprintf("%d/%d", *typ, *code)
+}
+
+// normalizeAddrs returns the canonical form of a rule's addresses,
+// with "*" for any address, and its IP family, inferred from the
+// addresses if it is not set.
+func normalizeAddrs(family *IPFamily, srcIP, dstIP *string) (IPFamily, string, string) {
+	var f IPFamily
+	if family != nil {
+		f = *family
+	}
+	src, srcFamily := normalizeAddr(srcIP)
+	dst, dstFamily := normalizeAddr(dstIP)
+	if f == "" {
+		f = srcFamily
+	}
+	if f == "" {
+		f = dstFamily
+	}
+	return f, src, dst
+}
+
+func normalizeAddr(s *string) (string, IPFamily) {
+	if s == nil || *s == "" || *s == "*" {
+		return "*", ""
+	}
+
+	p, err := netip.ParsePrefix(*s)
+	if err != nil {
+		a, err := netip.ParseAddr(*s)
+		if err != nil {
+			return *s, ""
+		}
+		p = netip.PrefixFrom(a, a.BitLen())
+	}
+
+	family := IPFamily6
+	if p.Addr().Is4() {
+		family = IPFamily4
+	}
+	p = p.Masked()
+	switch {
+	case p.Bits() == 0:
+		return "*", family
+	case p.IsSingleIP():
+		return p.Addr().String(), family
+	}
+	return p.String(), family
+}
@@ -0,0 +1,151 @@
+package enf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func syncRule(id string, priority int, action FirewallAction, dst string, port int) *FirewallRule {
+	return &FirewallRule{
+		ID:        String(id),
+		Priority:  Int(priority),
+		Action:    action.Ptr(),
+		Direction: DirectionIngress.Ptr(),
+		IPFamily:  IPFamily6.Ptr(),
+		Protocol:  ProtocolTCP.Ptr(),
+		SourceIP:  String("*"),
+		DestIP:    String(dst),
+		DestPort:  Int(port),
+	}
+}
+
+// describeSteps returns a short description of each step in a plan.
+func describeSteps(plan *SyncPlan) []string {
+	var steps []string
+	for _, s := range plan.Steps {
+		switch s.Op {
+		case SyncCreate:
+			steps = append(steps, fmt.Sprintf("create %v@%d", *s.Desired.Action, *s.Desired.Priority))
+		case SyncUpdate:
+			steps = append(steps, fmt.Sprintf("update %v %d->%d", *s.Current.ID, *s.Current.Priority, *s.Desired.Priority))
+		case SyncDelete:
+			steps = append(steps, fmt.Sprintf("delete %v", *s.Current.ID))
+		}
+	}
+	return steps
+}
+
+func TestPlanSync(t *testing.T) {
+	current := []*FirewallRule{
+		syncRule("keep", 10, ActionAccept, "fd00:8f80:8000:1::/64", 443),
+		syncRule("old-accept", 20, ActionAccept, "fd00:8f80:8000:1::5", 22),
+		syncRule("old-drop", 30, ActionDrop, "fd00:8f80:8000:2::/64", 0),
+		syncRule("drop-later", 40, ActionDrop, "fd00:8f80:8000:1::6", 0),
+		syncRule("accept-earlier", 50, ActionAccept, "fd00:8f80:8000:1::7", 80),
+	}
+
+	desired := []FirewallRuleRequest{
+		// Same rule, written differently.
+		*syncRule("", 10, ActionAccept, "fd00:8f80:8000:1:0::/64", 443).Request(),
+		*syncRule("", 5, ActionAccept, "fd00:8f80:8000:1::7/128", 80).Request(),
+		*syncRule("", 60, ActionDrop, "fd00:8f80:8000:1::6", 0).Request(),
+		*syncRule("", 15, ActionAccept, "fd00:8f80:8000:1::8", 8080).Request(),
+		*syncRule("", 100, ActionDrop, "::/0", 0).Request(),
+	}
+
+	plan := planSync("N", current, desired)
+
+	want := []string{
+		"create DROP@100",
+		"delete old-accept",
+		"update accept-earlier 50->5",
+		"update drop-later 40->60",
+		"create ACCEPT@15",
+		"delete old-drop",
+	}
+	if got := describeSteps(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("planSync returned steps\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if len(plan.Unchanged) != 1 || *plan.Unchanged[0].ID != "keep" {
+		t.Errorf("planSync returned unchanged rules %v, want [keep]", plan.Unchanged)
+	}
+}
+
+func TestPlanSync_duplicates(t *testing.T) {
+	current := []*FirewallRule{
+		syncRule("a", 10, ActionDrop, "*", 0),
+		syncRule("b", 20, ActionDrop, "*", 0),
+	}
+	desired := []FirewallRuleRequest{*current[1].Request()}
+
+	plan := planSync("N", current, desired)
+	if got, want := describeSteps(plan), []string{"delete a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("planSync returned steps %v, want %v", got, want)
+	}
+}
+
+func TestFirewallService_SyncRules(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	var calls []string
+	mux.HandleFunc("/api/xfw/v1/N/rule", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `[
+				{"id": "1", "priority": 10, "action": "ACCEPT", "direction": "INGRESS", "protocol": "TCP", "dest_port": 22},
+				{"id": "2", "priority": 20, "action": "DROP", "direction": "INGRESS"}
+			]`)
+		case "POST":
+			req := new(FirewallRuleRequest)
+			json.NewDecoder(r.Body).Decode(req)
+			calls = append(calls, fmt.Sprintf("POST %v", *req.Action))
+			fmt.Fprint(w, `{"id": "3"}`)
+		default:
+			t.Errorf("Unexpected %v request", r.Method)
+		}
+	})
+	mux.HandleFunc("/api/xfw/v1/N/rule/1", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+		calls = append(calls, "DELETE 1")
+	})
+
+	desired := []FirewallRuleRequest{
+		{Priority: Int(20), Action: ActionDrop.Ptr(), Direction: DirectionIngress.Ptr()},
+		{Priority: Int(5), Action: ActionDrop.Ptr(), Direction: DirectionIngress.Ptr(), Protocol: ProtocolTCP.Ptr(), DestPort: Int(22)},
+	}
+
+	plan, _, err := client.Firewall.SyncRules(context.Background(), "N", desired, &SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 0 || plan.Applied != 0 || len(plan.Steps) != 2 {
+		t.Fatalf("Dry run made calls %v, plan %+v", calls, plan)
+	}
+
+	plan, _, err = client.Firewall.SyncRules(context.Background(), "N", desired, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"POST DROP", "DELETE 1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("SyncRules made calls %v, want %v", calls, want)
+	}
+	if plan.Applied != 2 || *plan.Steps[0].Result.ID != "3" {
+		t.Errorf("SyncRules returned plan %+v", plan)
+	}
+}
+
+func TestFirewallService_SyncRules_invalid(t *testing.T) {
+	client, _, teardown := setup()
+	defer teardown()
+
+	_, _, err := client.Firewall.SyncRules(context.Background(), "N", []FirewallRuleRequest{{}}, nil)
+	if fieldNames(t, err) == nil {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
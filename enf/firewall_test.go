@@ -2,6 +2,8 @@ package enf
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 )
@@ -40,16 +42,11 @@ func TestFirewallService_ListRules(t *testing.T) {
 }
 
 func TestFirewallService_GetRule(t *testing.T) {
-	path := "/api/xfw/v1/N/rule"
+	path := "/api/xfw/v1/N/rule/00000000-0000-4000-2000-000000000001"
 
-	responseBodyMock := `[
-		{
-			"id":"00000000-0000-4000-2000-000000000000"
-		},
-		{
-			"id":"00000000-0000-4000-2000-000000000001"
-		}
-	]`
+	responseBodyMock := `{
+		"id":"00000000-0000-4000-2000-000000000001"
+	}`
 
 	expected := &FirewallRule{
 		ID: String("00000000-0000-4000-2000-000000000001"),
@@ -76,8 +73,8 @@ func TestFirewallService_CreateRule(t *testing.T) {
 
 	requestBody := &FirewallRuleRequest{
 		Priority:  Int(1),
-		Action:    String("ACCEPT"),
-		Direction: String("INGRESSS"),
+		Action:    ActionAccept.Ptr(),
+		Direction: DirectionIngress.Ptr(),
 	}
 
 	responseBodyMock := `{
@@ -105,6 +102,80 @@ func TestFirewallService_CreateRule(t *testing.T) {
 	postTest(testParams)
 }
 
+func TestFirewallService_GetRule_notFound(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xfw/v1/N/rule/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": {"code": "not_found", "text": "No such rule"}}`)
+	})
+	mux.HandleFunc("/api/xfw/v1/N/rule/empty", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `null`)
+	})
+
+	_, resp, err := client.Firewall.GetRule(context.Background(), "N", "missing")
+	var notFound *RuleNotFoundError
+	if !errors.As(err, &notFound) || notFound.ID != "missing" || notFound.Network != "N" {
+		t.Fatalf("Expected *RuleNotFoundError, got %v", err)
+	}
+	var errResp *ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Errorr.Text != "No such rule" {
+		t.Errorf("Expected wrapped *ErrorResponse, got %v", err)
+	}
+
+	_, resp, err = client.Firewall.GetRule(context.Background(), "N", "empty")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Response status was changed to %d", resp.StatusCode)
+	}
+
+	if _, _, err := client.Firewall.GetRule(context.Background(), "N", ""); err != ErrMissingRuleID {
+		t.Errorf("Expected ErrMissingRuleID, got %v", err)
+	}
+}
+
+func TestFirewallService_UpdateRule(t *testing.T) {
+	path := "/api/xfw/v1/N/rule/00000000-0000-4000-2000-000000000001"
+
+	requestBody := &FirewallRuleRequest{
+		Priority:  Int(5),
+		Action:    ActionDrop.Ptr(),
+		Direction: DirectionEgress.Ptr(),
+	}
+
+	responseBodyMock := `{
+		"id":"00000000-0000-4000-2000-000000000001",
+		"priority":5,
+		"action":"DROP",
+		"direction":"EGRESS"
+	}`
+
+	expected := &FirewallRule{
+		ID:        String("00000000-0000-4000-2000-000000000001"),
+		Priority:  Int(5),
+		Action:    ActionDrop.Ptr(),
+		Direction: DirectionEgress.Ptr(),
+	}
+
+	method := func(client *Client) (interface{}, *http.Response, error) {
+		return client.Firewall.UpdateRule(context.Background(), "N", "00000000-0000-4000-2000-000000000001", requestBody)
+	}
+
+	testParams := &TestParams{
+		Path:             path,
+		RequestBody:      requestBody,
+		ResponseBodyMock: responseBodyMock,
+		Expected:         expected,
+		Method:           method,
+		T:                t,
+	}
+
+	putTest(testParams)
+}
+
 func TestFirewallService_DeleteRule(t *testing.T) {
 	path := "/api/xfw/v1/N/rule/00000000-0000-4000-2000-000000000000"
 
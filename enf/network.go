@@ -18,57 +18,138 @@ type NetworkRequest struct {
 	Description *string `json:"description"`
 }
 
+// Validate checks that at least one field is set and that the name, if
+// set, is not blank.
+func (r *NetworkRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if r.Name == nil && r.Description == nil {
+		v.add("name", "name or description is required")
+	}
+	if r.Name != nil {
+		v.requiredString("name", r.Name)
+	}
+	return v.err()
+}
+
 // Network represents a network in the ENF.
 type Network struct {
-	Name        *string `json:"name"`
-	Network     *string `json:"network"`
-	Description *string `json:"description"`
-	Status      *string `json:"status"`
+	Name        *string        `json:"name"`
+	Network     *string        `json:"network"`
+	Description *string        `json:"description"`
+	Status      *NetworkStatus `json:"status"`
 }
 
-// networkResponse represents the typical API response for all
-// the endpoints in the xcr namespace.
-type networkResponse struct {
-	Data []*Network             `json:"data"`
-	Page map[string]interface{} `json:"page"`
-}
+// NetworkIterator iterates over a paginated list of networks.
+type NetworkIterator struct{ pageIterator }
 
-// ListNetworks gets a list of all the networks under a given domain.
+// Network returns the network at the current position of the iterator.
+func (it *NetworkIterator) Network() *Network { return it.current().(*Network) }
+
+// ListNetworks gets a list of all the networks under a given domain,
+// following the pagination of the API.
 func (s *NetworkService) ListNetworks(ctx context.Context, domain string) ([]*Network, *http.Response, error) {
+	var networks []*Network
+	it := s.ListNetworksIter(ctx, domain, nil)
+	for it.Next() {
+		networks = append(networks, it.Network())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+	return networks, it.Response(), nil
+}
+
+// ListNetworksByAddr is like ListNetworks, but takes a typed domain address.
+func (s *NetworkService) ListNetworksByAddr(ctx context.Context, domain DomainAddr) ([]*Network, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.ListNetworks(ctx, domain.PathEscape())
+}
+
+// ListNetworksIter returns an iterator over the networks under a
+// given domain, fetching one page at a time.
+func (s *NetworkService) ListNetworksIter(ctx context.Context, domain string, opts *ListOptions) *NetworkIterator {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/nws", domain)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(networkResponse))
-	if err != nil {
-		return nil, resp, err
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var networks []*Network
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &networks)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(networks))
+		for i, n := range networks {
+			items[i] = n
+		}
+		return items, page, resp, nil
 	}
-	return body.(*networkResponse).Data, resp, nil
+	return &NetworkIterator{newPageIterator(ctx, opts, fetch)}
 }
 
 // GetNetwork gets the network object for a given network address of the form <prefix>/<prefix_length>.
 func (s *NetworkService) GetNetwork(ctx context.Context, network string) (*Network, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/nws/%s", network)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(networkResponse))
+	n := new(Network)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, n)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkResponse).Data[0], resp, nil
+	return n, resp, nil
+}
+
+// GetNetworkByAddr is like GetNetwork, but takes a typed network address.
+func (s *NetworkService) GetNetworkByAddr(ctx context.Context, network NetworkAddr) (*Network, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.GetNetwork(ctx, network.PathEscape())
 }
 
 // CreateNetwork creates a network with the given fields under the given domain.
 func (s *NetworkService) CreateNetwork(ctx context.Context, domain string, fields *NetworkRequest) (*Network, *http.Response, error) {
+	if err := fields.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/nws", domain)
-	body, resp, err := s.client.post(ctx, path, new(networkResponse), fields)
+	n := new(Network)
+	resp, err := s.client.postOne(ctx, path, fields, n)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkResponse).Data[0], resp, nil
+	return n, resp, nil
+}
+
+// CreateNetworkByAddr is like CreateNetwork, but takes a typed domain address.
+func (s *NetworkService) CreateNetworkByAddr(ctx context.Context, domain DomainAddr, fields *NetworkRequest) (*Network, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.CreateNetwork(ctx, domain.PathEscape(), fields)
 }
 
 // UpdateNetwork updates the name and/or description of an existing network.
 func (s *NetworkService) UpdateNetwork(ctx context.Context, network string, fields *NetworkRequest) (*Network, *http.Response, error) {
+	if err := fields.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/nws/%s", network)
-	body, resp, err := s.client.put(ctx, path, new(networkResponse), fields)
+	n := new(Network)
+	resp, err := s.client.putOne(ctx, path, fields, n)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkResponse).Data[0], resp, nil
+	return n, resp, nil
+}
+
+// UpdateNetworkByAddr is like UpdateNetwork, but takes a typed network address.
+func (s *NetworkService) UpdateNetworkByAddr(ctx context.Context, network NetworkAddr, fields *NetworkRequest) (*Network, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.UpdateNetwork(ctx, network.PathEscape(), fields)
 }
@@ -16,49 +16,99 @@ type NetworkRateLimits struct {
 	Inherit          *bool `json:"inherit"`
 }
 
-// standardRateLimitResponse represents the standard API response for
-// all relevant network rate limit endpoints.
-type networkRateLimitResponse struct {
-	Data []*NetworkRateLimits   `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the rate limits that are set are not negative.
+func (r *NetworkRateLimits) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	v.nonNegative("packets_per_second", r.PacketsPerSecond)
+	v.nonNegative("packets_burst_size", r.PacketsBurstSize)
+	v.nonNegative("bytes_per_second", r.BytesPerSecond)
+	v.nonNegative("bytes_burst_size", r.BytesBurstSize)
+	return v.err()
 }
 
 // GetDefaultEndpointRateLimits gets the default rate limits for endpoints in the given network.
 func (s *NetworkService) GetDefaultEndpointRateLimits(ctx context.Context, network string) (*NetworkRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/nws/%v/ep_rate_limits/default", network)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(networkRateLimitResponse))
+	limits := new(NetworkRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetDefaultEndpointRateLimitsByAddr is like GetDefaultEndpointRateLimits, but takes a typed network address.
+func (s *NetworkService) GetDefaultEndpointRateLimitsByAddr(ctx context.Context, network NetworkAddr) (*NetworkRateLimits, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.GetDefaultEndpointRateLimits(ctx, network.PathEscape())
 }
 
 // GetMaxDefaultEndpointRateLimits gets the max default rate limits for endpoints in the given network.
 func (s *NetworkService) GetMaxDefaultEndpointRateLimits(ctx context.Context, network string) (*NetworkRateLimits, *http.Response, error) {
 	path := fmt.Sprintf("api/xcr/v2/nws/%v/ep_rate_limits/max", network)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(networkRateLimitResponse))
+	limits := new(NetworkRateLimits)
+	resp, err := s.client.getOne(ctx, path, url.Values{}, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// GetMaxDefaultEndpointRateLimitsByAddr is like GetMaxDefaultEndpointRateLimits, but takes a typed network address.
+func (s *NetworkService) GetMaxDefaultEndpointRateLimitsByAddr(ctx context.Context, network NetworkAddr) (*NetworkRateLimits, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.GetMaxDefaultEndpointRateLimits(ctx, network.PathEscape())
 }
 
 // SetDefaultEndpointRateLimits sets the default rate limits for endpoints in the given network.
 func (s *NetworkService) SetDefaultEndpointRateLimits(ctx context.Context, values *NetworkRateLimits, network string) (*NetworkRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/nws/%v/ep_rate_limits/default", network)
-	body, resp, err := s.client.put(ctx, path, new(networkRateLimitResponse), values)
+	limits := new(NetworkRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetDefaultEndpointRateLimitsByAddr is like SetDefaultEndpointRateLimits, but takes a typed network address.
+func (s *NetworkService) SetDefaultEndpointRateLimitsByAddr(ctx context.Context, values *NetworkRateLimits, network NetworkAddr) (*NetworkRateLimits, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.SetDefaultEndpointRateLimits(ctx, values, network.PathEscape())
 }
 
 // SetMaxDefaultEndpointRateLimits sets the max default rate limits for endpoints in the given network.
 func (s *NetworkService) SetMaxDefaultEndpointRateLimits(ctx context.Context, values *NetworkRateLimits, network string) (*NetworkRateLimits, *http.Response, error) {
+	if err := values.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/nws/%v/ep_rate_limits/max", network)
-	body, resp, err := s.client.put(ctx, path, new(networkRateLimitResponse), values)
+	limits := new(NetworkRateLimits)
+	resp, err := s.client.putOne(ctx, path, values, limits)
 	if err != nil {
 		return nil, resp, err
 	}
-	return body.(*networkRateLimitResponse).Data[0], resp, nil
+	return limits, resp, nil
+}
+
+// SetMaxDefaultEndpointRateLimitsByAddr is like SetMaxDefaultEndpointRateLimits, but takes a typed network address.
+func (s *NetworkService) SetMaxDefaultEndpointRateLimitsByAddr(ctx context.Context, values *NetworkRateLimits, network NetworkAddr) (*NetworkRateLimits, *http.Response, error) {
+	if !network.IsValid() {
+		return nil, nil, ErrMissingNetwork
+	}
+	return s.SetMaxDefaultEndpointRateLimits(ctx, values, network.PathEscape())
 }
@@ -38,13 +38,13 @@ func TestNetworkService_ListNetworks(t *testing.T) {
 			Name:        String("TestNetwork 1"),
 			Network:     String("fd00:8f80:8000:0000::/64"),
 			Description: String("This is a network."),
-			Status:      String("ACTIVE"),
+			Status:      NetworkStatusActive.Ptr(),
 		},
 		{
 			Name:        String("TestNetwork 2"),
 			Network:     String("fd00:8f80:8000:1::/64"),
 			Description: String("This is another network."),
-			Status:      String("ACTIVE"),
+			Status:      NetworkStatusActive.Ptr(),
 		},
 	}
 
@@ -88,7 +88,7 @@ func TestNetworkService_GetNetwork(t *testing.T) {
 		Name:        String("TestNetwork 1"),
 		Network:     String("N/n"),
 		Description: String("This is a network."),
-		Status:      String("ACTIVE"),
+		Status:      NetworkStatusActive.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -136,7 +136,7 @@ func TestNetworkService_CreateNetwork(t *testing.T) {
 		Name:        String("TestNetwork 1"),
 		Network:     String("N/n"),
 		Description: String("This is a network."),
-		Status:      String("ACTIVE"),
+		Status:      NetworkStatusActive.Ptr(),
 	}
 
 	method := func(client *Client) (interface{}, *http.Response, error) {
@@ -188,7 +188,7 @@ func TestNetworkService_UpdateNetwork(t *testing.T) {
 		Name:        String("TestNetwork 334"),
 		Network:     String("N/n"),
 		Description: String("Trying to update the network.."),
-		Status:      String("ACTIVE"),
+		Status:      NetworkStatusActive.Ptr(),
 	}
 
 	testParams := &TestParams{
@@ -0,0 +1,145 @@
+package enf
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListOptions specifies the optional parameters to the List methods
+// that support pagination.
+type ListOptions struct {
+	// Page is the page of results to retrieve, starting at 0.
+	Page int
+
+	// PerPage is the number of results to include per page. If zero,
+	// the API default is used.
+	PerPage int
+}
+
+// values returns the query parameters for the list options.
+func (o *ListOptions) values() url.Values {
+	v := url.Values{}
+	if o == nil {
+		return v
+	}
+	if o.Page > 0 {
+		v.Set("page", strconv.Itoa(o.Page))
+	}
+	if o.PerPage > 0 {
+		v.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+	return v
+}
+
+// Page represents the pagination information returned in the `page`
+// field of API responses. A value of -1 means that there is no such
+// page.
+type Page struct {
+	Curr *int `json:"curr"`
+	Next *int `json:"next"`
+	Prev *int `json:"prev"`
+}
+
+// HasNext reports whether there is another page of results after
+// this one.
+func (p *Page) HasNext() bool {
+	if p == nil || p.Next == nil || *p.Next < 0 {
+		return false
+	}
+	// Guard against a server that reports the current or an earlier
+	// page as the next one.
+	return p.Curr == nil || *p.Next > *p.Curr
+}
+
+// pageFetcher fetches a single page of results using the given
+// options.
+type pageFetcher func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error)
+
+// pageIterator walks all pages of a paginated list endpoint. It is
+// embedded in the typed iterators returned by the ListXxxIter
+// methods.
+type pageIterator struct {
+	ctx   context.Context
+	opts  ListOptions
+	fetch pageFetcher
+
+	items   []interface{}
+	cur     interface{}
+	page    *Page
+	resp    *http.Response
+	err     error
+	done    bool
+	fetched bool
+}
+
+func newPageIterator(ctx context.Context, opts *ListOptions, fetch pageFetcher) pageIterator {
+	it := pageIterator{ctx: ctx, fetch: fetch}
+	if opts != nil {
+		it.opts = *opts
+	}
+	return it
+}
+
+// Next advances the iterator to the next item, fetching the next page
+// from the API when the current one is exhausted. It returns false
+// when there are no more items or an error occurred. The context is
+// checked before each page is requested.
+func (it *pageIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			it.cur = nil
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			continue
+		}
+
+		items, page, resp, err := it.fetch(it.ctx, &it.opts)
+		it.resp = resp
+		if err != nil {
+			it.err = err
+			continue
+		}
+		// A server that ignores the page parameter returns the first
+		// page again instead of the requested one: stop rather than
+		// repeat its items forever.
+		mismatch := page != nil && page.Curr != nil && *page.Curr != it.opts.Page
+		if mismatch && it.fetched {
+			items = nil
+		}
+		it.items, it.page, it.fetched = items, page, true
+
+		if len(items) == 0 || mismatch || !page.HasNext() || *page.Next <= it.opts.Page {
+			it.done = true
+		} else {
+			it.opts.Page = *page.Next
+		}
+	}
+
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Err returns the first error encountered while iterating, if any.
+func (it *pageIterator) Err() error {
+	return it.err
+}
+
+// Page returns the pagination information of the most recently
+// fetched page.
+func (it *pageIterator) Page() *Page {
+	return it.page
+}
+
+// Response returns the HTTP response of the most recently fetched
+// page.
+func (it *pageIterator) Response() *http.Response {
+	return it.resp
+}
+
+func (it *pageIterator) current() interface{} {
+	return it.cur
+}
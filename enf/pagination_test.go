@@ -0,0 +1,174 @@
+package enf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// mockPages registers a handler on the given path that serves one
+// network per page, selected by the page query parameter.
+func mockPages(t *testing.T, mux *http.ServeMux, path string, pages int) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		curr := 0
+		if p := r.URL.Query().Get("page"); p != "" {
+			fmt.Sscanf(p, "%d", &curr)
+		}
+		next := curr + 1
+		if next >= pages {
+			next = -1
+		}
+		fmt.Fprintf(w, `{
+			"data": [{"name": "N%d"}],
+			"page": {"curr": %d, "next": %d, "prev": %d}
+		}`, curr, curr, next, curr-1)
+	})
+}
+
+func TestPage_HasNext(t *testing.T) {
+	tests := []struct {
+		page *Page
+		want bool
+	}{
+		{nil, false},
+		{&Page{}, false},
+		{&Page{Curr: Int(-1), Next: Int(-1), Prev: Int(-1)}, false},
+		{&Page{Curr: Int(0), Next: Int(1), Prev: Int(-1)}, true},
+		{&Page{Curr: Int(1), Next: Int(1), Prev: Int(0)}, false},
+		{&Page{Curr: Int(2), Next: Int(1), Prev: Int(1)}, false},
+		{&Page{Next: Int(2)}, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.page.HasNext(); got != tt.want {
+			t.Errorf("%+v.HasNext() returned %v, want %v", tt.page, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkService_ListNetworks_pages(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mockPages(t, mux, "/api/xcr/v2/domains/N/nws", 3)
+
+	networks, _, err := client.Network.ListNetworks(context.Background(), "N")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Network{
+		{Name: String("N0")},
+		{Name: String("N1")},
+		{Name: String("N2")},
+	}
+	if !reflect.DeepEqual(networks, expected) {
+		t.Errorf("ListNetworks returned %+v, want %+v", networks, expected)
+	}
+}
+
+func TestNetworkService_ListNetworks_pageIgnored(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	requests := 0
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data": [{"name": "N0"}], "page": {"curr": 0, "next": 1, "prev": -1}}`)
+	})
+
+	networks, _, err := client.Network.ListNetworks(context.Background(), "N")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(networks, []*Network{{Name: String("N0")}}) {
+		t.Errorf("ListNetworks returned %+v, want only N0", networks)
+	}
+	if requests != 2 {
+		t.Errorf("ListNetworks made %d requests, want 2", requests)
+	}
+
+	// Without the current page, the next page must still move forward.
+	requests = 0
+	mux.HandleFunc("/api/xcr/v2/domains/M/nws", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data": [{"name": "M0"}], "page": {"next": 1}}`)
+	})
+
+	if _, _, err := client.Network.ListNetworks(context.Background(), "M"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("ListNetworks made %d requests, want 2", requests)
+	}
+}
+
+func TestNetworkService_ListNetworksIter_options(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.RawQuery, "page=2&per_page=50"; got != want {
+			t.Errorf("Request query: %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"data": [{"name": "N2"}], "page": {"curr": 2, "next": -1, "prev": 1}}`)
+	})
+
+	it := client.Network.ListNetworksIter(context.Background(), "N", &ListOptions{Page: 2, PerPage: 50})
+	var names []string
+	for it.Next() {
+		names = append(names, *it.Network().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"N2"}) {
+		t.Errorf("Iterator returned %v, want [N2]", names)
+	}
+	if got := it.Page(); got == nil || *got.Curr != 2 {
+		t.Errorf("Iterator page is %+v, want curr 2", got)
+	}
+}
+
+func TestNetworkService_ListNetworksIter_cancel(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mockPages(t, mux, "/api/xcr/v2/domains/N/nws", 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.Network.ListNetworksIter(ctx, "N", nil)
+	if !it.Next() {
+		t.Fatalf("Next returned false, want true: %v", it.Err())
+	}
+	cancel()
+
+	if it.Next() {
+		t.Errorf("Next returned true after cancel, want false")
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("Err returned %v, want %v", it.Err(), context.Canceled)
+	}
+}
+
+func TestUserService_ListUsersForDomainAddress_pages(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mockPages(t, mux, "/api/xcr/v2/domains/N/users", 2)
+
+	it := client.User.ListUsersForDomainAddressIter(context.Background(), "N", nil)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Iterator returned %d users, want 2", count)
+	}
+}
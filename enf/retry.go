@@ -0,0 +1,253 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxAttempts    = 4
+	defaultInitialBackoff = 250 * time.Millisecond
+	defaultMaxBackoff     = 10 * time.Second
+	defaultMultiplier     = 2.0
+	defaultJitter         = 0.5
+)
+
+// RetryPolicy configures how the Client retries requests that fail
+// with a transient error.
+//
+// A request is retried when the API responds with 429, 502, 503 or
+// 504, or when the connection fails with a transient network error.
+// Only idempotent methods (GET, HEAD, OPTIONS, PUT and DELETE) are
+// retried unless RetryPOST is set.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first one. A value of 0 uses the default of 4; a value of 1
+	// disables retries.
+	MaxAttempts int
+
+	// MaxElapsed bounds the total time spent on a request, including
+	// all attempts and waits. No further attempt is started if
+	// waiting for it would exceed the budget. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// InitialBackoff is the wait before the first retry. Defaults to
+	// 250ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between two attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the backoff grows after each
+	// attempt. Defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction of each backoff that is randomized, in
+	// the range [0, 1]. Defaults to 0.5. Use a negative value to
+	// disable jitter.
+	Jitter float64
+
+	// RetryPOST allows POST requests to be retried. Only enable this
+	// if duplicate creations are acceptable or otherwise handled.
+	RetryPOST bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with the default settings.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Multiplier:     defaultMultiplier,
+		Jitter:         defaultJitter,
+	}
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil {
+		return 1
+	}
+	if p.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return p.MaxAttempts
+}
+
+// allowsMethod reports whether requests with the given method may be retried.
+func (p *RetryPolicy) allowsMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	case "POST":
+		return p.RetryPOST
+	default:
+		return false
+	}
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// backoff returns the wait before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = defaultMultiplier
+	}
+	jitter := p.Jitter
+	if jitter == 0 {
+		jitter = defaultJitter
+	}
+
+	d := float64(initial) * math.Pow(mult, float64(retry-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+	if jitter > 0 {
+		if jitter > 1 {
+			jitter = 1
+		}
+		jitterMu.Lock()
+		r := jitterRand.Float64()
+		jitterMu.Unlock()
+		d -= d * jitter * r
+	}
+	return time.Duration(d)
+}
+
+// shouldRetryStatus reports whether a response with the given status
+// code is worth retrying.
+func shouldRetryStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether the error returned by the HTTP
+// client is a transient network error worth retrying.
+func isTransientError(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header of the response, which is
+// either a number of seconds or an HTTP date. It returns false if the
+// header is absent or malformed.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// doWithRetry sends the request, retrying it according to the client's
+// retry policy. The body of the returned response has not been read.
+func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	policy := c.Retry
+	attempts := policy.maxAttempts()
+	if attempts > 1 && !policy.allowsMethod(req.Method) {
+		attempts = 1
+	}
+	if attempts > 1 && req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be replayed.
+		attempts = 1
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.client.Do(req)
+		if attempt >= attempts {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || !isTransientError(err) {
+				return resp, err
+			}
+			wait = policy.backoff(attempt)
+		case shouldRetryStatus(resp.StatusCode):
+			wait = policy.backoff(attempt)
+			if d, ok := retryAfter(resp, time.Now()); ok {
+				wait = d
+			}
+		default:
+			return resp, nil
+		}
+
+		if policy.MaxElapsed > 0 && time.Since(start)+wait > policy.MaxElapsed {
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain and close the body so the connection can be reused.
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package enf
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy returns a retry policy with short backoffs for testing.
+func testRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Jitter:         -1,
+	}
+}
+
+func TestClient_Do_retriesTransientStatus(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	client.Retry = testRetryPolicy()
+
+	calls := 0
+	mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"name": "n"}]}`)
+	})
+
+	network, _, err := client.Network.GetNetwork(context.Background(), "N/n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *network.Name != "n" {
+		t.Errorf("GetNetwork returned %+v, want name n", network)
+	}
+	if calls != 3 {
+		t.Errorf("Server received %d requests, want 3", calls)
+	}
+}
+
+func TestClient_Do_retryExhausted(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	client.Retry = testRetryPolicy()
+
+	calls := 0
+	mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, resp, err := client.Network.GetNetwork(context.Background(), "N/n")
+	if err == nil {
+		t.Fatal("Expected error to be returned")
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Response status is %d, want %d", resp.StatusCode, http.StatusBadGateway)
+	}
+	if calls != 3 {
+		t.Errorf("Server received %d requests, want 3", calls)
+	}
+}
+
+func TestClient_Do_noRetryForPOST(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	client.Retry = testRetryPolicy()
+
+	calls := 0
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, _, err := client.Network.CreateNetwork(context.Background(), "N", &NetworkRequest{Name: String("n")})
+	if err == nil {
+		t.Fatal("Expected error to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("Server received %d requests, want 1", calls)
+	}
+}
+
+func TestClient_Do_retryPOSTReplaysBody(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	client.Retry = testRetryPolicy()
+	client.Retry.RetryPOST = true
+
+	var bodies []string
+	mux.HandleFunc("/api/xcr/v2/domains/N/nws", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"name": "n"}]}`)
+	})
+
+	_, _, err := client.Network.CreateNetwork(context.Background(), "N", &NetworkRequest{Name: String("n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("Server received %d requests, want 2", len(bodies))
+	}
+	if bodies[0] == "" || bodies[0] != bodies[1] {
+		t.Errorf("Request bodies differ between attempts: %q and %q", bodies[0], bodies[1])
+	}
+}
+
+func TestClient_Do_retryAfterExceedsBudget(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+	client.Retry = testRetryPolicy()
+	client.Retry.MaxElapsed = time.Second
+
+	calls := 0
+	mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	_, _, err := client.Network.GetNetwork(context.Background(), "N/n")
+	if err == nil {
+		t.Fatal("Expected error to be returned")
+	}
+	if calls != 1 {
+		t.Errorf("Server received %d requests, want 1", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"Wed, 01 Jan 2020 00:00:10 GMT", 10 * time.Second, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) returned (%v, %v), want (%v, %v)", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Jitter: -1}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) returned %v, want %v", i+1, got, w)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(1); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("backoff(1) with jitter returned %v, want within [500ms, 1s]", got)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+var (
+	ErrMissingCredentialSource = errors.New("Missing credential source")
+	ErrMissingToken            = errors.New("Auth response did not contain a token")
+)
+
+// CredentialSource supplies the username and password used to obtain
+// an API token. Implementations must be safe for concurrent use.
+type CredentialSource interface {
+	Credentials(ctx context.Context) (username, password string, err error)
+}
+
+// StaticCredentials is a CredentialSource that always returns the same
+// username and password.
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+// Credentials returns the static username and password.
+func (c StaticCredentials) Credentials(ctx context.Context) (string, string, error) {
+	return c.Username, c.Password, nil
+}
+
+// CredentialSourceFunc is an adapter to allow the use of an ordinary
+// function as a CredentialSource.
+type CredentialSourceFunc func(ctx context.Context) (username, password string, err error)
+
+// Credentials calls f(ctx).
+func (f CredentialSourceFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// TokenAuthTransport is an http.RoundTripper that authenticates
+// requests with an ENF API token. The token is obtained lazily from
+// the /api/xcr/v2/xauth endpoint on the first request. If a request is
+// rejected with 401 Unauthorized, the transport logs in again and
+// retries the request once.
+//
+// A TokenAuthTransport is safe for concurrent use by multiple
+// goroutines. Only one login is performed at a time; requests that
+// need a token wait for it.
+type TokenAuthTransport struct {
+	// Base is the underlying RoundTripper used to make requests and to
+	// log in. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	domain string
+	source CredentialSource
+
+	mu    sync.Mutex
+	token string
+	creds *Credentials
+}
+
+// NewTokenAuthTransport returns a TokenAuthTransport that logs in to
+// the given ENF domain with the credentials from source.
+func NewTokenAuthTransport(domain string, source CredentialSource) *TokenAuthTransport {
+	return &TokenAuthTransport{domain: domain, source: source}
+}
+
+// TokenAuthClient returns an http.Client that authenticates its
+// requests to the given ENF domain using the username and password.
+// Pass it to NewClient to use API methods which require
+// authentication.
+func TokenAuthClient(domain, username, password string) *http.Client {
+	return TokenAuthClientFromSource(domain, StaticCredentials{Username: username, Password: password})
+}
+
+// TokenAuthClientFromSource returns an http.Client that authenticates
+// its requests to the given ENF domain using credentials from source.
+func TokenAuthClientFromSource(domain string, source CredentialSource) *http.Client {
+	return &http.Client{Transport: NewTokenAuthTransport(domain, source)}
+}
+
+// Credentials returns the credentials from the most recent successful
+// login, or nil if the transport has not logged in yet.
+func (t *TokenAuthTransport) Credentials() *Credentials {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.creds
+}
+
+// RoundTrip authorizes and sends the request, logging in first if the
+// transport does not have a token yet.
+func (t *TokenAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	token, err := t.currentToken(ctx)
+	if err != nil {
+		closeBody(req)
+		return nil, err
+	}
+
+	resp, err := t.base().RoundTrip(authorize(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token was rejected. Retry once with a fresh token if the
+	// request body can be replayed.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	token, err = t.refreshToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := authorize(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return t.base().RoundTrip(retry)
+}
+
+func (t *TokenAuthTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// currentToken returns the current token, logging in if there is none.
+func (t *TokenAuthTransport) currentToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" {
+		return t.token, nil
+	}
+	return t.login(ctx)
+}
+
+// refreshToken logs in again unless another goroutine already
+// replaced the rejected token in the meantime.
+func (t *TokenAuthTransport) refreshToken(ctx context.Context, rejected string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.token != rejected {
+		return t.token, nil
+	}
+	return t.login(ctx)
+}
+
+// login obtains a new token from the auth API. It must be called with
+// t.mu held.
+func (t *TokenAuthTransport) login(ctx context.Context) (string, error) {
+	t.token = ""
+
+	if t.source == nil {
+		return "", ErrMissingCredentialSource
+	}
+	username, password, err := t.source.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := NewClient(t.domain, &http.Client{Transport: t.base()})
+	if err != nil {
+		return "", err
+	}
+	creds, _, err := client.Auth.Authenticate(ctx, &AuthRequest{Username: &username, Password: &password})
+	if err != nil {
+		return "", err
+	}
+	if creds.Token == nil || *creds.Token == "" {
+		return "", ErrMissingToken
+	}
+
+	t.token, t.creds = *creds.Token, creds
+	return t.token, nil
+}
+
+// authorize returns a copy of the request carrying the given token.
+// RoundTrippers must not modify the original request.
+func authorize(req *http.Request, token string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set(headerToken, fmt.Sprintf(headerTokenFormat, token))
+	return r
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,143 @@
+package enf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// tokenServer is a mock ENF API that issues a new token on each login
+// and only accepts the most recently issued one.
+type tokenServer struct {
+	mu     sync.Mutex
+	logins int
+	valid  string
+}
+
+func (s *tokenServer) setup(t *testing.T) (*httptest.Server, *http.ServeMux) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/xcr/v2/xauth", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		s.mu.Lock()
+		s.logins++
+		s.valid = fmt.Sprintf("token%d", s.logins)
+		token := s.valid
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"data": [{"username": "user", "token": "%s"}], "page": {}}`, token)
+	})
+	mux.HandleFunc("/api/xcr/v2/nws/N/n", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		want := fmt.Sprintf(headerTokenFormat, s.valid)
+		s.mu.Unlock()
+		if r.Header.Get(headerToken) != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": {"code": "unauthorized", "text": "invalid token"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"name": "n"}]}`)
+	})
+	return httptest.NewServer(mux), mux
+}
+
+func (s *tokenServer) expire() {
+	s.mu.Lock()
+	s.valid = "expired"
+	s.mu.Unlock()
+}
+
+func TestTokenAuthClient_lazyLogin(t *testing.T) {
+	ts := &tokenServer{}
+	server, _ := ts.setup(t)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, TokenAuthClient(server.URL, "user", "pass"))
+	if ts.logins != 0 {
+		t.Fatalf("Logged in %d times before the first request, want 0", ts.logins)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Network.GetNetwork(context.Background(), "N/n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ts.logins != 1 {
+		t.Errorf("Logged in %d times, want 1", ts.logins)
+	}
+}
+
+func TestTokenAuthClient_reauthenticates(t *testing.T) {
+	ts := &tokenServer{}
+	server, _ := ts.setup(t)
+	defer server.Close()
+
+	transport := NewTokenAuthTransport(server.URL, StaticCredentials{Username: "user", Password: "pass"})
+	client, _ := NewClient(server.URL, &http.Client{Transport: transport})
+
+	if _, _, err := client.Network.GetNetwork(context.Background(), "N/n"); err != nil {
+		t.Fatal(err)
+	}
+	ts.expire()
+	if _, _, err := client.Network.GetNetwork(context.Background(), "N/n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ts.logins != 2 {
+		t.Errorf("Logged in %d times, want 2", ts.logins)
+	}
+	if creds := transport.Credentials(); creds == nil || *creds.Token != "token2" {
+		t.Errorf("Credentials returned %+v, want token2", creds)
+	}
+}
+
+func TestTokenAuthClient_credentialSourceError(t *testing.T) {
+	ts := &tokenServer{}
+	server, _ := ts.setup(t)
+	defer server.Close()
+
+	wantErr := fmt.Errorf("vault unavailable")
+	source := CredentialSourceFunc(func(ctx context.Context) (string, string, error) {
+		return "", "", wantErr
+	})
+	client, _ := NewClient(server.URL, TokenAuthClientFromSource(server.URL, source))
+
+	_, _, err := client.Network.GetNetwork(context.Background(), "N/n")
+	if err == nil {
+		t.Fatal("Expected error to be returned")
+	}
+	if ts.logins != 0 {
+		t.Errorf("Logged in %d times, want 0", ts.logins)
+	}
+}
+
+func TestTokenAuthClient_concurrent(t *testing.T) {
+	ts := &tokenServer{}
+	server, _ := ts.setup(t)
+	defer server.Close()
+
+	var calls int32
+	source := CredentialSourceFunc(func(ctx context.Context) (string, string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "user", "pass", nil
+	})
+	client, _ := NewClient(server.URL, TokenAuthClientFromSource(server.URL, source))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := client.Network.GetNetwork(context.Background(), "N/n"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Logged in %d times, want 1", n)
+	}
+}
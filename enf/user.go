@@ -15,21 +15,21 @@ type UserService service
 
 // User represents an ENF user.
 type User struct {
-	UserID      *int       `json:"user_id"`
-	Username    *string    `json:"username"`
-	Description *string    `json:"description"`
-	FullName    *string    `json:"full_name"`
-	LastLogin   *time.Time `json:"last_login"`
-	DomainID    *int       `json:"domain_id"`
-	Type        *string    `json:"type"`
-	ResetCode   *string    `json:"reset_code"`
-	ResetTime   *time.Time `json:"reset_time"`
-	Status      *string    `json:"status"`
+	UserID      *int        `json:"user_id"`
+	Username    *string     `json:"username"`
+	Description *string     `json:"description"`
+	FullName    *string     `json:"full_name"`
+	LastLogin   *time.Time  `json:"last_login"`
+	DomainID    *int        `json:"domain_id"`
+	Type        *UserType   `json:"type"`
+	ResetCode   *string     `json:"reset_code"`
+	ResetTime   *time.Time  `json:"reset_time"`
+	Status      *UserStatus `json:"status"`
 }
 
 // UpdateUserStatusRequest represents the body of the request to update a user's status.
 type UpdateUserStatusRequest struct {
-	Status *string `json:"status"`
+	Status *UserStatus `json:"status"`
 }
 
 // ResetPasswordRequest represents the body of the request to reset a user's password.
@@ -39,37 +39,109 @@ type ResetPasswordRequest struct {
 	Password *string `json:"pwd"`
 }
 
-type userResponse struct {
-	Data []*User                `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that the status is set to ACTIVE or INACTIVE.
+func (r *UpdateUserStatusRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.required("status", r.Status != nil) {
+		v.enum("status", *r.Status)
+	}
+	return v.err()
+}
+
+// Validate checks that all fields of the reset password request are set
+// and that the email is a valid address.
+func (r *ResetPasswordRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("email", r.Email) {
+		v.email("email", r.Email)
+	}
+	v.requiredString("code", r.Code)
+	v.requiredString("pwd", r.Password)
+	return v.err()
 }
 
 type emptyResponse []interface{}
 
-// ListUsersForDomainAddress gets the list of users for a given domain address.
+// UserIterator iterates over a paginated list of users.
+type UserIterator struct{ pageIterator }
+
+// User returns the user at the current position of the iterator.
+func (it *UserIterator) User() *User { return it.current().(*User) }
+
+// ListUsersForDomainAddress gets the list of users for a given domain address,
+// following the pagination of the API.
 func (s *UserService) ListUsersForDomainAddress(ctx context.Context, address string) ([]*User, *http.Response, error) {
-	path := fmt.Sprintf("api/xcr/v2/domains/%v/users", address)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(userResponse))
-	if err != nil {
-		return nil, resp, err
+	return collectUsers(s.ListUsersForDomainAddressIter(ctx, address, nil))
+}
+
+// ListUsersForDomainAddressByAddr is like ListUsersForDomainAddress, but takes a typed domain address.
+func (s *UserService) ListUsersForDomainAddressByAddr(ctx context.Context, domain DomainAddr) ([]*User, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
 	}
+	return s.ListUsersForDomainAddress(ctx, domain.PathEscape())
+}
 
-	return body.(*userResponse).Data, resp, nil
+// ListUsersForDomainAddressIter returns an iterator over the users for a
+// given domain address, fetching one page at a time.
+func (s *UserService) ListUsersForDomainAddressIter(ctx context.Context, address string, opts *ListOptions) *UserIterator {
+	path := fmt.Sprintf("api/xcr/v2/domains/%v/users", address)
+	return s.listUsersIter(ctx, path, opts)
 }
 
-// ListUsersForDomainID gets a list of users for a given unique domain identifier.
+// ListUsersForDomainID gets a list of users for a given unique domain identifier,
+// following the pagination of the API.
 func (s *UserService) ListUsersForDomainID(ctx context.Context, id string) ([]*User, *http.Response, error) {
+	return collectUsers(s.ListUsersForDomainIDIter(ctx, id, nil))
+}
+
+// ListUsersForDomainIDIter returns an iterator over the users for a given
+// unique domain identifier, fetching one page at a time.
+func (s *UserService) ListUsersForDomainIDIter(ctx context.Context, id string, opts *ListOptions) *UserIterator {
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/users", id)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(userResponse))
-	if err != nil {
-		return nil, resp, err
+	return s.listUsersIter(ctx, path, opts)
+}
+
+func (s *UserService) listUsersIter(ctx context.Context, path string, opts *ListOptions) *UserIterator {
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var users []*User
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &users)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(users))
+		for i, u := range users {
+			items[i] = u
+		}
+		return items, page, resp, nil
 	}
+	return &UserIterator{newPageIterator(ctx, opts, fetch)}
+}
 
-	return body.(*userResponse).Data, resp, nil
+func collectUsers(it *UserIterator) ([]*User, *http.Response, error) {
+	var users []*User
+	for it.Next() {
+		users = append(users, it.User())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
+	}
+
+	return users, it.Response(), nil
 }
 
-// UpdateUserStatus updates the status of a user to "ACTIVE" or "INACTIVE".
+// UpdateUserStatus updates the status of a user to UserStatusActive or UserStatusInactive.
 func (s *UserService) UpdateUserStatus(ctx context.Context, userID int, updateUserStatusRequest *UpdateUserStatusRequest) (*http.Response, error) {
+	if err := updateUserStatusRequest.Validate(); err != nil {
+		return nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/users/%v/status", userID)
 	_, resp, err := s.client.put(ctx, path, new(emptyResponse), updateUserStatusRequest)
 	if err != nil {
@@ -95,6 +167,10 @@ func (s *UserService) EmailResetPasswordCode(ctx context.Context, email string)
 
 // ResetPassword resets a user's password.
 func (s *UserService) ResetPassword(ctx context.Context, resetPasswordRequest *ResetPasswordRequest) (*http.Response, error) {
+	if err := resetPasswordRequest.Validate(); err != nil {
+		return nil, err
+	}
+
 	path := "api/xcr/v2/users/reset"
 	_, resp, err := s.client.post(ctx, path, new(emptyResponse), resetPasswordRequest)
 	if err != nil {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"time"
 )
 
@@ -25,9 +24,9 @@ type Invite struct {
 
 // SendInviteRequest represents the body of the request to send an invite.
 type SendInviteRequest struct {
-	Email    *string `json:"email"`
-	FullName *string `json:"full_name"`
-	UserType *string `json:"user_type"`
+	Email    *string   `json:"email"`
+	FullName *string   `json:"full_name"`
+	UserType *UserType `json:"user_type"`
 }
 
 // AcceptInviteRequest represents the body of the request to accept an invite.
@@ -38,35 +37,120 @@ type AcceptInviteRequest struct {
 	Password *string `json:"password"`
 }
 
-type inviteResponse struct {
-	Data []*Invite              `json:"data"`
-	Page map[string]interface{} `json:"page"`
+// Validate checks that all fields of the invite request are set, that
+// the email is a valid address and that the user type is a domain
+// admin or user.
+func (r *SendInviteRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("email", r.Email) {
+		v.email("email", r.Email)
+	}
+	v.requiredString("full_name", r.FullName)
+	if v.required("user_type", r.UserType != nil) {
+		if *r.UserType != UserTypeDomainAdmin && *r.UserType != UserTypeDomainUser {
+			v.add("user_type", "must be one of %v, %v, got %q", UserTypeDomainAdmin, UserTypeDomainUser, *r.UserType)
+		}
+	}
+	return v.err()
+}
+
+// Validate checks that all fields of the accept invite request are set
+// and that the email is a valid address.
+func (r *AcceptInviteRequest) Validate() error {
+	if r == nil {
+		return nilRequestError()
+	}
+	v := new(validator)
+	if v.requiredString("email", r.Email) {
+		v.email("email", r.Email)
+	}
+	v.requiredString("code", r.Code)
+	v.requiredString("name", r.Name)
+	v.requiredString("password", r.Password)
+	return v.err()
 }
 
-// ListInvitesForDomainAddress gets a list of active invites for a given domain address.
+// InviteIterator iterates over a paginated list of invites.
+type InviteIterator struct{ pageIterator }
+
+// Invite returns the invite at the current position of the iterator.
+func (it *InviteIterator) Invite() *Invite { return it.current().(*Invite) }
+
+// ListInvitesForDomainAddress gets a list of active invites for a given domain address,
+// following the pagination of the API.
 func (s *UserService) ListInvitesForDomainAddress(ctx context.Context, address string) ([]*Invite, *http.Response, error) {
-	path := fmt.Sprintf("api/xcr/v2/domains/%v/invites", address)
-	body, resp, err := s.client.get(ctx, path, url.Values{}, new(inviteResponse))
-	if err != nil {
-		return nil, resp, err
+	var invites []*Invite
+	it := s.ListInvitesForDomainAddressIter(ctx, address, nil)
+	for it.Next() {
+		invites = append(invites, it.Invite())
+	}
+	if err := it.Err(); err != nil {
+		return nil, it.Response(), err
 	}
 
-	return body.(*inviteResponse).Data, resp, nil
+	return invites, it.Response(), nil
+}
+
+// ListInvitesForDomainAddressByAddr is like ListInvitesForDomainAddress, but takes a typed domain address.
+func (s *UserService) ListInvitesForDomainAddressByAddr(ctx context.Context, domain DomainAddr) ([]*Invite, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.ListInvitesForDomainAddress(ctx, domain.PathEscape())
+}
+
+// ListInvitesForDomainAddressIter returns an iterator over the active invites
+// for a given domain address, fetching one page at a time.
+func (s *UserService) ListInvitesForDomainAddressIter(ctx context.Context, address string, opts *ListOptions) *InviteIterator {
+	path := fmt.Sprintf("api/xcr/v2/domains/%v/invites", address)
+	fetch := func(ctx context.Context, opts *ListOptions) ([]interface{}, *Page, *http.Response, error) {
+		var invites []*Invite
+		page, resp, err := s.client.getList(ctx, path, opts.values(), &invites)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+		items := make([]interface{}, len(invites))
+		for i, inv := range invites {
+			items[i] = inv
+		}
+		return items, page, resp, nil
+	}
+	return &InviteIterator{newPageIterator(ctx, opts, fetch)}
 }
 
 // SendNewInvite sends a new invite for a user to join the domain with the given address.
 func (s *UserService) SendNewInvite(ctx context.Context, address string, inviteRequest *SendInviteRequest) (*Invite, *http.Response, error) {
+	if err := inviteRequest.Validate(); err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("api/xcr/v2/domains/%v/invites", address)
-	body, resp, err := s.client.post(ctx, path, new(inviteResponse), inviteRequest)
+	invite := new(Invite)
+	resp, err := s.client.postOne(ctx, path, inviteRequest, invite)
 	if err != nil {
 		return nil, resp, err
 	}
 
-	return body.(*inviteResponse).Data[0], resp, nil
+	return invite, resp, nil
+}
+
+// SendNewInviteByAddr is like SendNewInvite, but takes a typed domain address.
+func (s *UserService) SendNewInviteByAddr(ctx context.Context, domain DomainAddr, inviteRequest *SendInviteRequest) (*Invite, *http.Response, error) {
+	if !domain.IsValid() {
+		return nil, nil, ErrMissingDomain
+	}
+	return s.SendNewInvite(ctx, domain.PathEscape(), inviteRequest)
 }
 
 // AcceptInvite accepts an invite.
 func (s *UserService) AcceptInvite(ctx context.Context, acceptInviteRequest *AcceptInviteRequest) (*http.Response, error) {
+	if err := acceptInviteRequest.Validate(); err != nil {
+		return nil, err
+	}
+
 	path := "api/xcr/v2/users/invites"
 	_, resp, err := s.client.post(ctx, path, new(emptyResponse), acceptInviteRequest)
 	if err != nil {
@@ -51,7 +51,7 @@ func TestUserService_SendNewInvite(t *testing.T) {
 	requestBody := &SendInviteRequest{
 		Email:    String("user@acme.com"),
 		FullName: String("Xaptum User"),
-		UserType: String("DOMAIN_USER"),
+		UserType: UserTypeDomainUser.Ptr(),
 	}
 
 	responseBodyMock := `{
@@ -25,7 +25,7 @@ func TestUserService_ListUsersForDomainAddress(t *testing.T) {
 			UserID:   Int(1),
 			Username: String("user@acme"),
 			FullName: String("Xaptum User"),
-			Status:   String("ACTIVE"),
+			Status:   UserStatusActive.Ptr(),
 		},
 	}
 
@@ -64,7 +64,7 @@ func TestUserService_ListUsersForDomainID(t *testing.T) {
 			UserID:   Int(1),
 			Username: String("user@acme"),
 			FullName: String("Xaptum User"),
-			Status:   String("ACTIVE"),
+			Status:   UserStatusActive.Ptr(),
 		},
 	}
 
@@ -88,7 +88,7 @@ func TestUserService_UpdateUserStatus(t *testing.T) {
 	path := "/api/xcr/v2/users/61/status"
 
 	requestBody := &UpdateUserStatusRequest{
-		Status: String("INACTIVE"),
+		Status: UserStatusInactive.Ptr(),
 	}
 
 	responseBodyMock := `[]`
@@ -0,0 +1,188 @@
+package enf
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+)
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	// Field is the JSON name of the field.
+	Field string
+
+	// Message describes the problem.
+	Message string
+
+	// Err is a sentinel error for the problem, if there is one (such
+	// as ErrMissingUsername).
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Field, e.Message)
+}
+
+// ValidationError is returned by the Validate methods of the request
+// types, and by the service methods before sending an invalid request.
+// It lists every problem found in the request and matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "Invalid request: " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether the validation error matches the target: either
+// ErrValidation or the sentinel error of one of its field errors.
+func (e *ValidationError) Is(target error) bool {
+	if target == ErrValidation {
+		return true
+	}
+	for _, fe := range e.Errors {
+		if fe.Err != nil && fe.Err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// validator collects the field errors of a request.
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the collected errors as a *ValidationError, or nil if
+// there are none.
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+// requiredString checks that the field is set and not blank.
+func (v *validator) requiredString(field string, value *string) bool {
+	return v.requiredStringErr(field, value, nil)
+}
+
+// requiredStringErr is like requiredString, but records the given
+// sentinel error on the field error.
+func (v *validator) requiredStringErr(field string, value *string, sentinel error) bool {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		v.errs = append(v.errs, &FieldError{Field: field, Message: "is required", Err: sentinel})
+		return false
+	}
+	return true
+}
+
+// requiredInt checks that the field is set.
+func (v *validator) requiredInt(field string, value *int) bool {
+	if value == nil {
+		v.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+// required checks that the field is set.
+func (v *validator) required(field string, set bool) bool {
+	if !set {
+		v.add(field, "is required")
+	}
+	return set
+}
+
+// enum checks that the field is a known value of its enumerated type.
+func (v *validator) enum(field string, value enum) {
+	if !isValidEnum(value) {
+		v.add(field, "must be one of %v, got %q", strings.Join(value.enumValues(), ", "), value.String())
+	}
+}
+
+// nonNegative checks that the field, if set, is not negative.
+func (v *validator) nonNegative(field string, value *int) {
+	if value != nil && *value < 0 {
+		v.add(field, "must not be negative, got %d", *value)
+	}
+}
+
+// port checks that the field, if set, is a valid port number.
+func (v *validator) port(field string, value *int) {
+	if value != nil && (*value < 0 || *value > 65535) {
+		v.add(field, "must be a port between 0 and 65535, got %d", *value)
+	}
+}
+
+// portRange checks that the end of a port range, if set, follows a
+// start port and is not before it.
+func (v *validator) portRange(startField string, start *int, endField string, end *int) {
+	if end == nil {
+		return
+	}
+	v.port(endField, end)
+	if start == nil || *start == 0 {
+		v.add(endField, "requires %v", startField)
+	} else if *end < *start {
+		v.add(endField, "must not be less than %v %d, got %d", startField, *start, *end)
+	}
+}
+
+// uint8 checks that the field, if set, is between 0 and 255.
+func (v *validator) uint8(field string, value *int) {
+	if value != nil && (*value < 0 || *value > 255) {
+		v.add(field, "must be between 0 and 255, got %d", *value)
+	}
+}
+
+// email checks that the field, if set, is a valid email address.
+func (v *validator) email(field string, value *string) {
+	if value == nil || *value == "" {
+		return
+	}
+	if _, err := mail.ParseAddress(*value); err != nil {
+		v.add(field, "must be a valid email address, got %q", *value)
+	}
+}
+
+// ipOrCIDR checks that the field, if set, is an IP address or CIDR
+// prefix of the given family (or of either family if empty). The
+// wildcard "*" matches any address.
+func (v *validator) ipOrCIDR(field string, value *string, family IPFamily) {
+	if value == nil || *value == "*" {
+		return
+	}
+
+	ip := net.ParseIP(*value)
+	if ip == nil {
+		var err error
+		if ip, _, err = net.ParseCIDR(*value); err != nil {
+			v.add(field, "must be an IP address or CIDR prefix, got %q", *value)
+			return
+		}
+	}
+
+	isIPv4 := ip.To4() != nil
+	switch {
+	case family == IPFamily6 && isIPv4:
+		v.add(field, "must be an IPv6 address for ip_family IP6, got %q", *value)
+	case family == IPFamily4 && !isIPv4:
+		v.add(field, "must be an IPv4 address for ip_family IP4, got %q", *value)
+	}
+}
+
+// nilRequestError is returned when Validate is called on a nil request.
+func nilRequestError() error {
+	return &ValidationError{Errors: []*FieldError{{Field: "request", Message: "is required"}}}
+}
@@ -0,0 +1,173 @@
+package enf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// fieldNames returns the names of the fields in a validation error.
+func fieldNames(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %T: %v", err, err)
+	}
+	names := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		names[i] = fe.Field
+	}
+	return names
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		req  interface{ Validate() error }
+		want []string
+	}{
+		{"auth ok", &AuthRequest{Username: String("u"), Password: String("p")}, nil},
+		{"auth nil fields", &AuthRequest{}, []string{"username", "token"}},
+		{"auth nil request", (*AuthRequest)(nil), []string{"request"}},
+
+		{"rule ok", &FirewallRuleRequest{
+			Priority: Int(10), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			IPFamily: IPFamily6.Ptr(), Protocol: ProtocolTCP.Ptr(),
+			SourceIP: String("fd00:8f80:8000:1::/64"), DestIP: String("fd00:8f80:8000:2::9"),
+			SourcePort: Int(0), DestPort: Int(443),
+		}, nil},
+		{"rule missing", &FirewallRuleRequest{}, []string{"priority", "action", "direction"}},
+		{"rule bad values", &FirewallRuleRequest{
+			Priority: Int(-1), Action: FirewallAction("ALLOW").Ptr(), Direction: FirewallDirection("IN").Ptr(),
+			IPFamily: IPFamily("IPV6").Ptr(), Protocol: FirewallProtocol("SCTP").Ptr(),
+			SourceIP: String("fd00::/129"), DestIP: String("not-an-ip"),
+			SourcePort: Int(70000), DestPort: Int(-2),
+		}, []string{"priority", "action", "direction", "ip_family", "protocol", "source_ip", "dest_ip", "source_port", "dest_port"}},
+		{"rule family mismatch", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionDrop.Ptr(), Direction: DirectionEgress.Ptr(),
+			IPFamily: IPFamily6.Ptr(), SourceIP: String("10.0.0.0/8"), DestIP: String("*"),
+		}, []string{"source_ip"}},
+		{"rule icmp ports", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionDrop.Ptr(), Direction: DirectionEgress.Ptr(),
+			Protocol: ProtocolICMP6.Ptr(), DestPort: Int(22),
+		}, []string{"dest_port"}},
+		{"rule port ranges", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			Protocol: ProtocolTCP.Ptr(), SourcePort: Int(1024), SourcePortEnd: Int(65535),
+			DestPort: Int(8000), DestPortEnd: Int(8100),
+		}, nil},
+		{"rule bad port ranges", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			SourcePortEnd: Int(80), DestPort: Int(8100), DestPortEnd: Int(8000),
+		}, []string{"source_port_end", "dest_port_end"}},
+		{"rule icmp type", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			Protocol: ProtocolICMP6.Ptr(), ICMPType: Int(128), ICMPCode: Int(0),
+		}, nil},
+		{"rule bad icmp", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			Protocol: ProtocolICMP.Ptr(), ICMPType: Int(256), DestPortEnd: Int(10),
+		}, []string{"dest_port_end", "dest_port_end", "icmp_type"}},
+		{"rule icmp without protocol", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			Protocol: ProtocolTCP.Ptr(), ICMPType: Int(8), ICMPCode: Int(0),
+		}, []string{"icmp_type"}},
+		{"rule icmp code without type", &FirewallRuleRequest{
+			Priority: Int(1), Action: ActionAccept.Ptr(), Direction: DirectionIngress.Ptr(),
+			Protocol: ProtocolICMP.Ptr(), ICMPCode: Int(0),
+		}, []string{"icmp_code"}},
+
+		{"network ok", &NetworkRequest{Description: String("d")}, nil},
+		{"network empty", &NetworkRequest{}, []string{"name"}},
+		{"network blank name", &NetworkRequest{Name: String(" ")}, []string{"name"}},
+
+		{"domain ok", &DomainRequest{Name: String("d"), Type: String("CUSTOMER_SOURCE"), AdminName: String("a"), AdminEmail: String("a@b.c")}, nil},
+		{"domain bad", &DomainRequest{AdminEmail: String("nope")}, []string{"name", "type", "admin_name", "admin_email"}},
+
+		{"invite ok", &SendInviteRequest{Email: String("a@b.c"), FullName: String("A"), UserType: UserTypeDomainAdmin.Ptr()}, nil},
+		{"invite bad", &SendInviteRequest{Email: String("a"), UserType: UserType("ROOT").Ptr()}, []string{"email", "full_name", "user_type"}},
+		{"accept invite", &AcceptInviteRequest{Email: String("a@b.c")}, []string{"code", "name", "password"}},
+
+		{"zone ok", &CreateZoneRequest{ZoneDomainName: String("abc.def")}, nil},
+		{"zone missing", &CreateZoneRequest{}, []string{"zone_domain_name"}},
+		{"zone bad name", &CreateZoneRequest{ZoneDomainName: String("abc_1.def")}, []string{"zone_domain_name"}},
+		{"update zone", &UpdateZoneRequest{}, []string{"description"}},
+
+		{"record ok", &CreateRecordRequest{Name: String("www"), Type: RecordTypeAAAA.Ptr(), TTL: Int(300), Data: &RecordData{IPv6: String("fd00::1")}}, nil},
+		{"record missing", &CreateRecordRequest{}, []string{"name", "type"}},
+		{"record bad type", &CreateRecordRequest{Name: String("a"), Type: RecordType("A").Ptr(), TTL: Int(-1), Data: &RecordData{}}, []string{"ttl", "type"}},
+		{"record missing data", &CreateRecordRequest{Name: String("a"), Type: RecordTypeCNAME.Ptr()}, []string{"rdata"}},
+		{"record srv", &CreateRecordRequest{Name: String("_sip._udp"), Type: RecordTypeSRV.Ptr(), Data: &RecordData{Priority: Int(10), Port: Int(70000)}}, []string{"rdata.weight", "rdata.port", "rdata.target"}},
+		{"record mx", &CreateRecordRequest{Name: String("@"), Type: RecordTypeMX.Ptr(), Data: &RecordData{Exchange: String("mail")}}, []string{"rdata.preference"}},
+		{"update record", &UpdateRecordRequest{TTL: Int(60)}, nil},
+		{"update record empty", &UpdateRecordRequest{}, []string{"name"}},
+		{"update record bad", &UpdateRecordRequest{Name: String("-www"), TTL: Int(MaxTTL + 1), Data: &RecordData{IPv6: String("::ffff:10.0.0.1")}}, []string{"name", "ttl", "rdata.ipv6"}},
+		{"record cname at apex", &CreateRecordRequest{Name: String("@"), Type: RecordTypeCNAME.Ptr(), Data: &RecordData{CNAME: String("www")}}, []string{"name"}},
+		{"service endpoint ok", &CreateServiceEndpointRequest{IPv6: String("fd00:8f80:8000:1::53")}, nil},
+		{"service endpoint ipv4", &CreateServiceEndpointRequest{IPv6: String("10.0.0.53")}, []string{"ipv6"}},
+		{"service endpoint ipv4-mapped", &CreateServiceEndpointRequest{IPv6: String("::ffff:10.0.0.53")}, []string{"ipv6"}},
+		{"service endpoint missing", &CreateServiceEndpointRequest{}, []string{"ipv6"}},
+
+		{"user status", &UpdateUserStatusRequest{Status: UserStatus("DISABLED").Ptr()}, []string{"status"}},
+		{"reset password", &ResetPasswordRequest{Email: String("a@b.c")}, []string{"code", "pwd"}},
+
+		{"endpoint limits", &EndpointRateLimits{PacketsPerSecond: Int(-1), BytesBurstSize: Int(-5), BytesPerSecond: Int(0)}, []string{"packets_per_second", "bytes_burst_size"}},
+		{"domain limits", &DomainRateLimits{PacketsBurstSize: Int(-1)}, []string{"packets_burst_size"}},
+		{"network limits", &NetworkRateLimits{BytesPerSecond: Int(100)}, nil},
+	}
+
+	for _, tt := range tests {
+		err := tt.req.Validate()
+		if got := fieldNames(t, err); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("[%v] Validate returned errors for %v, want %v (%v)", tt.name, got, tt.want, err)
+		}
+		if err != nil && !errors.Is(err, ErrValidation) {
+			t.Errorf("[%v] Validate error does not match ErrValidation", tt.name)
+		}
+	}
+}
+
+func TestAuthService_Authenticate_validation(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xcr/v2/xauth", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Invalid request was sent to the server")
+	})
+
+	_, _, err := client.Auth.Authenticate(context.Background(), &AuthRequest{Password: String("p")})
+	if !errors.Is(err, ErrMissingUsername) {
+		t.Errorf("Expected ErrMissingUsername, got %v", err)
+	}
+	if errors.Is(err, ErrMissingPassword) {
+		t.Errorf("Error unexpectedly matches ErrMissingPassword: %v", err)
+	}
+
+	_, _, err = client.Auth.Authenticate(context.Background(), &AuthRequest{Username: String(""), Password: String("")})
+	if !errors.Is(err, ErrMissingUsername) || !errors.Is(err, ErrMissingPassword) {
+		t.Errorf("Expected ErrMissingUsername and ErrMissingPassword, got %v", err)
+	}
+}
+
+func TestFirewallService_CreateRule_validation(t *testing.T) {
+	client, mux, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/xfw/v1/N/rule", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Invalid request was sent to the server")
+	})
+
+	_, resp, err := client.Firewall.CreateRule(context.Background(), "N", &FirewallRuleRequest{Action: FirewallAction("ALLOW").Ptr()})
+	if resp != nil {
+		t.Errorf("CreateRule returned response %v, want nil", resp)
+	}
+	want := []string{"priority", "action", "direction"}
+	if got := fieldNames(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateRule returned errors for %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,302 @@
+// Package fwlint analyzes the firewall rules of an ENF network for
+// rules that never match, duplicate each other or are likely mistakes.
+//
+// Rules are considered in the evaluation order used by package fwsim:
+// ascending priority, first match wins.
+package fwlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+
+	"github.com/xaptum/go-enf/enf"
+	"github.com/xaptum/go-enf/fwsim"
+)
+
+// Severity is the severity of a finding.
+type Severity string
+
+// Finding severities, from least to most severe.
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// rank orders the severities.
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	}
+	return 0
+}
+
+// AtLeast reports whether the severity is at least as severe as min.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+// Check identifies the kind of problem a finding reports.
+type Check string
+
+// The checks run by Lint.
+const (
+	// CheckInvalid reports rules that cannot be evaluated, such as
+	// rules with an address that cannot be parsed.
+	CheckInvalid Check = "invalid"
+
+	// CheckFamilyMismatch reports rules with an address that is not
+	// of the rule's IP family.
+	CheckFamilyMismatch Check = "family_mismatch"
+
+	// CheckDuplicate reports rules identical to an earlier rule,
+	// apart from their priority.
+	CheckDuplicate Check = "duplicate"
+
+	// CheckSemanticDuplicate reports rules that match the same
+	// packets with the same action as an earlier rule, but are
+	// written differently.
+	CheckSemanticDuplicate Check = "semantic_duplicate"
+
+	// CheckShadowed reports rules that never match because an earlier
+	// rule matches every packet they would.
+	CheckShadowed Check = "shadowed"
+
+	// CheckPriorityCollision reports rules that share a priority, so
+	// their relative order depends on the server.
+	CheckPriorityCollision Check = "priority_collision"
+
+	// CheckOverlyBroad reports ACCEPT rules for any source and any
+	// destination.
+	CheckOverlyBroad Check = "overly_broad"
+)
+
+// Finding is a problem found in a ruleset.
+type Finding struct {
+	Check    Check    `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+
+	// RuleID and Priority identify the rule the finding is about.
+	RuleID   string `json:"rule_id,omitempty"`
+	Priority int    `json:"priority"`
+
+	// RelatedID identifies the other rule involved, such as the rule
+	// that shadows this one.
+	RelatedID string `json:"related_id,omitempty"`
+
+	Rule    *enf.FirewallRule `json:"-"`
+	Related *enf.FirewallRule `json:"-"`
+}
+
+// Report is the result of linting a ruleset.
+type Report struct {
+	Findings []*Finding `json:"findings"`
+}
+
+// Filter returns the findings that are at least as severe as min.
+func (r *Report) Filter(min Severity) []*Finding {
+	var findings []*Finding
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(min) {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// HasErrors reports whether the report has findings of severity error.
+func (r *Report) HasErrors() bool {
+	return len(r.Filter(SeverityError)) > 0
+}
+
+// JSON returns the report encoded as JSON.
+func (r *Report) JSON() ([]byte, error) {
+	if r.Findings == nil {
+		return json.Marshal(&Report{Findings: []*Finding{}})
+	}
+	return json.Marshal(r)
+}
+
+// Lint analyzes the rules, such as those returned by
+// enf.FirewallService.ListRules, and reports the problems it finds in
+// evaluation order.
+//
+// A rule is only reported as shadowed if a single earlier rule covers
+// it; a rule covered by several earlier rules together is not
+// detected.
+func Lint(rules []*enf.FirewallRule) *Report {
+	l := &linter{report: new(Report)}
+
+	ordered := fwsim.Order(rules)
+	compiled := make([]*fwsim.Rule, len(ordered))
+	for i, r := range ordered {
+		l.checkPriority(ordered[:i], r)
+
+		c, err := fwsim.Compile(r)
+		if err != nil {
+			l.add(CheckInvalid, SeverityError, r, nil, "%v", err)
+			continue
+		}
+		compiled[i] = c
+		l.checkFamily(c)
+		l.checkBroad(c)
+
+		for j := 0; j < i; j++ {
+			if l.checkEarlier(ordered[j], compiled[j], r, c) {
+				break
+			}
+		}
+	}
+
+	return l.report
+}
+
+type linter struct {
+	report *Report
+}
+
+func (l *linter) add(check Check, sev Severity, r, related *enf.FirewallRule, format string, args ...interface{}) {
+	f := &Finding{
+		Check:    check,
+		Severity: sev,
+		Message:  fmt.Sprintf(format, args...),
+		RuleID:   ruleID(r),
+		Rule:     r,
+		Related:  related,
+	}
+	if r.Priority != nil {
+		f.Priority = *r.Priority
+	}
+	if related != nil {
+		f.RelatedID = ruleID(related)
+	}
+	l.report.Findings = append(l.report.Findings, f)
+}
+
+// checkFamily reports addresses that don't belong to the rule's IP
+// family, and source and destination addresses of different families.
+func (l *linter) checkFamily(c *fwsim.Rule) {
+	addrs := []struct {
+		field  string
+		prefix netip.Prefix
+	}{
+		{"source_ip", c.SourceIP},
+		{"dest_ip", c.DestIP},
+	}
+
+	family := c.Family
+	for _, a := range addrs {
+		if !a.prefix.IsValid() {
+			continue
+		}
+		f := prefixFamily(a.prefix)
+		if family == "" {
+			family = f
+			continue
+		}
+		if f != family {
+			l.add(CheckFamilyMismatch, SeverityError, c.Source, nil,
+				"%v %v is not an %v address; the rule never matches", a.field, a.prefix, family)
+			return
+		}
+	}
+}
+
+// checkBroad reports ACCEPT rules for any source and destination.
+func (l *linter) checkBroad(c *fwsim.Rule) {
+	if c.Action != enf.ActionAccept || c.SourceIP.Bits() > 0 || c.DestIP.Bits() > 0 {
+		return
+	}
+
+	sev := SeverityWarning
+	what := "any source to any destination"
+	if c.Protocol == "" && c.SourcePorts.Any() && c.DestPorts.Any() {
+		sev = SeverityError
+		what += " on any protocol and port"
+	}
+	l.add(CheckOverlyBroad, sev, c.Source, nil, "Rule accepts %v", what)
+}
+
+// checkEarlier compares a rule with an earlier one, and reports whether
+// it found a problem.
+func (l *linter) checkEarlier(earlier *enf.FirewallRule, ec *fwsim.Rule, r *enf.FirewallRule, c *fwsim.Rule) bool {
+	if ec == nil || !ec.Covers(c) {
+		return false
+	}
+
+	switch {
+	case sameRule(earlier, r):
+		l.add(CheckDuplicate, SeverityWarning, r, earlier,
+			"Rule is identical to rule %v", ruleID(earlier))
+	case c.Covers(ec) && c.Action == ec.Action:
+		l.add(CheckSemanticDuplicate, SeverityWarning, r, earlier,
+			"Rule matches the same packets as rule %v", ruleID(earlier))
+	case c.Action == ec.Action:
+		l.add(CheckShadowed, SeverityWarning, r, earlier,
+			"Rule never matches: rule %v already %vs every packet it matches", ruleID(earlier), verb(ec.Action))
+	default:
+		l.add(CheckShadowed, SeverityError, r, earlier,
+			"Rule never matches: rule %v %vs every packet it would %v", ruleID(earlier), verb(ec.Action), verb(c.Action))
+	}
+	return true
+}
+
+// sameRule reports whether the rules are identical apart from their
+// priority, which only decides which of them is dead.
+func sameRule(a, b *enf.FirewallRule) bool {
+	ar, br := a.Request(), b.Request()
+	ar.Priority, br.Priority = nil, nil
+	return reflect.DeepEqual(ar, br)
+}
+
+// checkPriority reports a rule that shares its priority with an
+// earlier rule. Every rule of a group is reported against the first.
+func (l *linter) checkPriority(earlier []*enf.FirewallRule, r *enf.FirewallRule) {
+	if r.Priority == nil {
+		return
+	}
+	var first *enf.FirewallRule
+	for k := len(earlier) - 1; k >= 0; k-- {
+		p := earlier[k].Priority
+		if p == nil || *p != *r.Priority {
+			break
+		}
+		first = earlier[k]
+	}
+	if first != nil {
+		l.add(CheckPriorityCollision, SeverityWarning, r, first,
+			"Rule has the same priority %d as rule %v; their order is undefined", *r.Priority, ruleID(first))
+	}
+}
+
+func prefixFamily(p netip.Prefix) enf.IPFamily {
+	if p.Addr().Is4() {
+		return enf.IPFamily4
+	}
+	return enf.IPFamily6
+}
+
+func verb(a enf.FirewallAction) string {
+	switch a {
+	case enf.ActionAccept:
+		return "accept"
+	case enf.ActionDrop:
+		return "drop"
+	}
+	return string(a)
+}
+
+func ruleID(r *enf.FirewallRule) string {
+	if r.ID == nil {
+		return ""
+	}
+	return *r.ID
+}
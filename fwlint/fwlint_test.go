@@ -0,0 +1,124 @@
+package fwlint
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+func rule(id string, priority int, action enf.FirewallAction) *enf.FirewallRule {
+	return &enf.FirewallRule{
+		ID:        enf.String(id),
+		Priority:  enf.Int(priority),
+		Action:    action.Ptr(),
+		Direction: enf.DirectionIngress.Ptr(),
+		IPFamily:  enf.IPFamily6.Ptr(),
+		Protocol:  enf.ProtocolTCP.Ptr(),
+	}
+}
+
+func withDest(r *enf.FirewallRule, ip string, port int) *enf.FirewallRule {
+	r.DestIP = enf.String(ip)
+	r.DestPort = enf.Int(port)
+	return r
+}
+
+type summary struct {
+	check    Check
+	severity Severity
+	rule     string
+	related  string
+}
+
+func summarize(report *Report) []summary {
+	var got []summary
+	for _, f := range report.Findings {
+		got = append(got, summary{f.Check, f.Severity, f.RuleID, f.RelatedID})
+	}
+	return got
+}
+
+func TestLint(t *testing.T) {
+	rules := []*enf.FirewallRule{
+		withDest(rule("drop-net", 10, enf.ActionDrop), "fd00:8f80:0:2::/64", 0),
+		withDest(rule("accept-host", 20, enf.ActionAccept), "fd00:8f80:0:2::9", 443),
+		withDest(rule("drop-host", 30, enf.ActionDrop), "fd00:8f80:0:2::9", 22),
+		withDest(rule("dup", 40, enf.ActionAccept), "fd00:8f80:0:3::9", 443),
+		withDest(rule("dup-again", 40, enf.ActionAccept), "fd00:8f80:0:3::9", 443),
+		withDest(rule("same", 50, enf.ActionAccept), "fd00:8f80:0:3:0::9/128", 443),
+		withDest(rule("v4", 60, enf.ActionAccept), "10.0.0.1", 80),
+		rule("any", 70, enf.ActionAccept),
+		{ID: enf.String("bad"), Priority: enf.Int(80), Action: enf.ActionDrop.Ptr(), SourceIP: enf.String("nope")},
+	}
+
+	want := []summary{
+		{CheckShadowed, SeverityError, "accept-host", "drop-net"},
+		{CheckShadowed, SeverityWarning, "drop-host", "drop-net"},
+		{CheckPriorityCollision, SeverityWarning, "dup-again", "dup"},
+		{CheckDuplicate, SeverityWarning, "dup-again", "dup"},
+		{CheckSemanticDuplicate, SeverityWarning, "same", "dup"},
+		{CheckFamilyMismatch, SeverityError, "v4", ""},
+		{CheckOverlyBroad, SeverityWarning, "any", ""},
+		{CheckInvalid, SeverityError, "bad", ""},
+	}
+
+	report := Lint(rules)
+	if got := summarize(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint returned\n%+v\nwant\n%+v", got, want)
+	}
+	if !report.HasErrors() {
+		t.Errorf("HasErrors returned false")
+	}
+	if got := len(report.Filter(SeverityError)); got != 3 {
+		t.Errorf("Filter returned %d errors, want 3", got)
+	}
+}
+
+func TestLint_duplicatePriority(t *testing.T) {
+	rules := []*enf.FirewallRule{
+		withDest(rule("first", 10, enf.ActionAccept), "fd00:8f80:0:3::9", 443),
+		withDest(rule("again", 20, enf.ActionAccept), "fd00:8f80:0:3::9", 443),
+	}
+
+	want := []summary{{CheckDuplicate, SeverityWarning, "again", "first"}}
+	if got := summarize(Lint(rules)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint returned %+v, want %+v", got, want)
+	}
+}
+
+func TestLint_overlyBroad(t *testing.T) {
+	r := &enf.FirewallRule{
+		ID: enf.String("open"), Priority: enf.Int(1), Action: enf.ActionAccept.Ptr(),
+		Direction: enf.DirectionIngress.Ptr(), SourceIP: enf.String("*"), DestIP: enf.String("::/0"),
+	}
+
+	findings := Lint([]*enf.FirewallRule{r}).Findings
+	if len(findings) != 1 || findings[0].Check != CheckOverlyBroad || findings[0].Severity != SeverityError {
+		t.Errorf("Lint returned %+v, want one overly broad error", findings)
+	}
+}
+
+func TestReport_JSON(t *testing.T) {
+	data, err := Lint(nil).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"findings":[]}` {
+		t.Errorf("JSON returned %s for an empty report", data)
+	}
+
+	data, err = Lint([]*enf.FirewallRule{rule("a", 1, enf.ActionDrop), rule("b", 2, enf.ActionDrop)}).JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	f := decoded.Findings[0]
+	if f.Check != CheckDuplicate || f.RuleID != "b" || f.RelatedID != "a" || f.Priority != 2 {
+		t.Errorf("JSON returned %s", data)
+	}
+}
@@ -0,0 +1,217 @@
+// Package fwsim evaluates packets against the firewall rules of an ENF
+// network offline, without sending anything to the network.
+//
+// Rules are evaluated in ascending priority order and the first rule
+// that matches decides the action. Rules with the same priority are
+// evaluated in the order they are given. A packet that matches no rule
+// gets the default action.
+package fwsim
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// DefaultAction is the action for packets that match no rule, unless
+// the simulator is configured otherwise.
+const DefaultAction = enf.ActionDrop
+
+var (
+	ErrMissingAddress   = errors.New("Missing required packet address")
+	ErrMissingDirection = errors.New("Missing required packet direction")
+	ErrMissingProtocol  = errors.New("Missing required packet protocol")
+)
+
+// Packet describes a packet to evaluate.
+type Packet struct {
+	// Family is the IP family of the packet. If empty, it is
+	// inferred from the addresses.
+	Family enf.IPFamily
+
+	// Protocol is the protocol of the packet. It must be a specific
+	// protocol, not enf.ProtocolAll.
+	Protocol enf.FirewallProtocol
+
+	// Direction is the direction of the packet relative to the
+	// network.
+	Direction enf.FirewallDirection
+
+	Source     netip.Addr
+	SourcePort int
+	Dest       netip.Addr
+	DestPort   int
+
+	// ICMPType and ICMPCode are the message type and code of ICMP
+	// and ICMP6 packets.
+	ICMPType int
+	ICMPCode int
+}
+
+// validate checks that the packet is complete and consistent.
+func (p *Packet) validate() error {
+	if !p.Source.IsValid() || !p.Dest.IsValid() {
+		return ErrMissingAddress
+	}
+	if p.Direction == "" {
+		return ErrMissingDirection
+	}
+	if p.Protocol == "" || p.Protocol == enf.ProtocolAll {
+		return ErrMissingProtocol
+	}
+	if p.Source.Is4() != p.Dest.Is4() {
+		return fmt.Errorf("Packet addresses %v and %v are of different families", p.Source, p.Dest)
+	}
+	if p.Family != "" && p.Family != addrFamily(p.Source) {
+		return fmt.Errorf("Packet family %v does not match address %v", p.Family, p.Source)
+	}
+	return nil
+}
+
+// family returns the IP family of the packet.
+func (p *Packet) family() enf.IPFamily {
+	if p.Family != "" {
+		return p.Family
+	}
+	return addrFamily(p.Source)
+}
+
+// hasPorts reports whether the packet's protocol has ports.
+func (p *Packet) hasPorts() bool {
+	return p.Protocol == enf.ProtocolTCP || p.Protocol == enf.ProtocolUDP
+}
+
+// isICMP reports whether the packet is an ICMP or ICMP6 message.
+func (p *Packet) isICMP() bool {
+	return p.Protocol == enf.ProtocolICMP || p.Protocol == enf.ProtocolICMP6
+}
+
+func addrFamily(a netip.Addr) enf.IPFamily {
+	if a.Is4() {
+		return enf.IPFamily4
+	}
+	return enf.IPFamily6
+}
+
+// Options specifies the optional parameters to New.
+type Options struct {
+	// DefaultAction is the action for packets that match no rule. If
+	// empty, the package DefaultAction is used.
+	DefaultAction enf.FirewallAction
+}
+
+// Step is the evaluation of one rule against a packet.
+type Step struct {
+	Rule *enf.FirewallRule
+
+	// Matched reports whether the rule matched the packet.
+	Matched bool
+
+	// Reason explains why the rule did not match, or that it could
+	// not be compiled.
+	Reason string
+}
+
+// Result is the outcome of evaluating a packet.
+type Result struct {
+	// Action is the action applied to the packet.
+	Action enf.FirewallAction
+
+	// Rule is the rule that matched the packet, or nil if the
+	// default action applies.
+	Rule *enf.FirewallRule
+
+	// Trace lists every rule that was considered, in evaluation
+	// order, up to and including the rule that matched.
+	Trace []Step
+}
+
+// Default reports whether no rule matched and the default action
+// applies.
+func (r *Result) Default() bool {
+	return r.Rule == nil
+}
+
+// Simulator evaluates packets against a fixed set of rules.
+type Simulator struct {
+	rules         []*enf.FirewallRule
+	compiled      []*Rule
+	errs          []error
+	defaultAction enf.FirewallAction
+}
+
+// New returns a simulator for the given rules, such as those returned
+// by enf.FirewallService.ListRules. Rules that cannot be compiled never
+// match; they show up in the trace with the reason.
+func New(rules []*enf.FirewallRule, opts *Options) *Simulator {
+	s := &Simulator{defaultAction: DefaultAction}
+	if opts != nil && opts.DefaultAction != "" {
+		s.defaultAction = opts.DefaultAction
+	}
+
+	s.rules = Order(rules)
+	s.compiled = make([]*Rule, len(s.rules))
+	s.errs = make([]error, len(s.rules))
+	for i, r := range s.rules {
+		s.compiled[i], s.errs[i] = Compile(r)
+	}
+	return s
+}
+
+// Evaluate evaluates the packet against the rules and returns the
+// resulting action, the rule that matched and the trace.
+func (s *Simulator) Evaluate(p Packet) (*Result, error) {
+	p.Source, p.Dest = p.Source.Unmap(), p.Dest.Unmap()
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	res := new(Result)
+	for i, r := range s.rules {
+		if s.errs[i] != nil {
+			res.Trace = append(res.Trace, Step{Rule: r, Reason: s.errs[i].Error()})
+			continue
+		}
+		ok, reason := s.compiled[i].Match(&p)
+		res.Trace = append(res.Trace, Step{Rule: r, Matched: ok, Reason: reason})
+		if ok {
+			res.Rule = r
+			res.Action = s.compiled[i].Action
+			return res, nil
+		}
+	}
+
+	res.Action = s.defaultAction
+	return res, nil
+}
+
+// Evaluate evaluates the packet against the rules with the default
+// options. See Simulator.Evaluate.
+func Evaluate(rules []*enf.FirewallRule, p Packet) (*Result, error) {
+	return New(rules, nil).Evaluate(p)
+}
+
+// Order returns the rules in evaluation order, leaving out nil rules.
+// The given slice is not modified.
+func Order(rules []*enf.FirewallRule) []*enf.FirewallRule {
+	ordered := make([]*enf.FirewallRule, 0, len(rules))
+	for _, r := range rules {
+		if r != nil {
+			ordered = append(ordered, r)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return priority(ordered[i]) < priority(ordered[j])
+	})
+	return ordered
+}
+
+func priority(r *enf.FirewallRule) int {
+	if r.Priority == nil {
+		return 0
+	}
+	return *r.Priority
+}
@@ -0,0 +1,220 @@
+package fwsim
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+func testRules() []*enf.FirewallRule {
+	return []*enf.FirewallRule{
+		{
+			ID: enf.String("drop-all"), Priority: enf.Int(100),
+			Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+		},
+		{
+			ID: enf.String("https"), Priority: enf.Int(10),
+			Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			IPFamily: enf.IPFamily6.Ptr(), Protocol: enf.ProtocolTCP.Ptr(),
+			SourceIP: enf.String("fd00:8f80:0:1::/64"), DestIP: enf.String("fd00:8f80:0:2::9"),
+			DestPort: enf.Int(443),
+		},
+		{
+			ID: enf.String("ssh"), Priority: enf.Int(20),
+			Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			Protocol: enf.ProtocolTCP.Ptr(), DestPort: enf.Int(22),
+		},
+		{
+			ID: enf.String("icmp"), Priority: enf.Int(30),
+			Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			Protocol: enf.ProtocolICMP6.Ptr(),
+		},
+		{
+			ID: enf.String("broken"), Priority: enf.Int(5),
+			Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			SourceIP: enf.String("not-an-ip"),
+		},
+	}
+}
+
+func packet(protocol enf.FirewallProtocol, src string, srcPort int, dst string, dstPort int) Packet {
+	return Packet{
+		Protocol:   protocol,
+		Direction:  enf.DirectionIngress,
+		Source:     netip.MustParseAddr(src),
+		SourcePort: srcPort,
+		Dest:       netip.MustParseAddr(dst),
+		DestPort:   dstPort,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet Packet
+		rule   string
+		action enf.FirewallAction
+		trace  int
+	}{
+		{"https allowed", packet(enf.ProtocolTCP, "fd00:8f80:0:1::5", 40000, "fd00:8f80:0:2::9", 443), "https", enf.ActionAccept, 2},
+		{"https from elsewhere", packet(enf.ProtocolTCP, "fd00:8f80:0:3::5", 40000, "fd00:8f80:0:2::9", 443), "drop-all", enf.ActionDrop, 5},
+		{"ssh", packet(enf.ProtocolTCP, "fd00:8f80:0:1::5", 40000, "fd00:8f80:0:2::9", 22), "ssh", enf.ActionDrop, 3},
+		{"icmp", packet(enf.ProtocolICMP6, "fd00:8f80:0:1::5", 0, "fd00:8f80:0:2::9", 0), "icmp", enf.ActionAccept, 4},
+		{"ipv4 https", packet(enf.ProtocolTCP, "10.0.0.1", 40000, "10.0.0.2", 443), "drop-all", enf.ActionDrop, 5},
+	}
+
+	sim := New(testRules(), nil)
+	for _, tt := range tests {
+		res, err := sim.Evaluate(tt.packet)
+		if err != nil {
+			t.Errorf("[%v] Evaluate returned error %v", tt.name, err)
+			continue
+		}
+		if res.Action != tt.action || res.Rule == nil || *res.Rule.ID != tt.rule {
+			t.Errorf("[%v] Evaluate returned %v by %v, want %v by %v", tt.name, res.Action, res.Rule, tt.action, tt.rule)
+		}
+		if len(res.Trace) != tt.trace {
+			t.Errorf("[%v] Evaluate returned trace of %d steps, want %d", tt.name, len(res.Trace), tt.trace)
+		}
+	}
+}
+
+func TestEvaluate_trace(t *testing.T) {
+	res, err := Evaluate(testRules(), packet(enf.ProtocolTCP, "fd00:8f80:0:1::5", 40000, "fd00:8f80:0:2::9", 443))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broken, https := res.Trace[0], res.Trace[1]
+	if *broken.Rule.ID != "broken" || broken.Matched || broken.Reason == "" {
+		t.Errorf("First trace step is %+v, want the broken rule with a reason", broken)
+	}
+	if *https.Rule.ID != "https" || !https.Matched {
+		t.Errorf("Second trace step is %+v, want the matching https rule", https)
+	}
+}
+
+func TestEvaluate_default(t *testing.T) {
+	p := packet(enf.ProtocolUDP, "fd00::1", 53, "fd00::2", 53)
+	p.Direction = enf.DirectionEgress
+
+	res, err := Evaluate(testRules(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Default() || res.Action != DefaultAction {
+		t.Errorf("Evaluate returned %v by %v, want the default action", res.Action, res.Rule)
+	}
+
+	res, _ = New(testRules(), &Options{DefaultAction: enf.ActionAccept}).Evaluate(p)
+	if res.Action != enf.ActionAccept {
+		t.Errorf("Evaluate returned %v, want the configured default ACCEPT", res.Action)
+	}
+}
+
+func TestEvaluate_invalidPacket(t *testing.T) {
+	p := packet(enf.ProtocolTCP, "fd00::1", 1, "10.0.0.1", 2)
+	if _, err := Evaluate(nil, p); err == nil {
+		t.Errorf("Expected error for mixed address families")
+	}
+
+	p = packet(enf.ProtocolAll, "fd00::1", 1, "fd00::2", 2)
+	if _, err := Evaluate(nil, p); !errors.Is(err, ErrMissingProtocol) {
+		t.Errorf("Expected ErrMissingProtocol, got %v", err)
+	}
+
+	if _, err := Evaluate(nil, Packet{Protocol: enf.ProtocolTCP}); !errors.Is(err, ErrMissingAddress) {
+		t.Errorf("Expected ErrMissingAddress, got %v", err)
+	}
+}
+
+func TestRule_Covers(t *testing.T) {
+	compile := func(r *enf.FirewallRule) *Rule {
+		r.Action = enf.ActionDrop.Ptr()
+		c, err := Compile(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	all := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr()})
+	subnet := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr(), SourceIP: enf.String("fd00:8f80:0:1::/64")})
+	host := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr(), SourceIP: enf.String("fd00:8f80:0:1::5"), Protocol: enf.ProtocolTCP.Ptr(), DestPort: enf.Int(22)})
+	ssh := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr(), DestPort: enf.Int(22)})
+	ping := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr(), Protocol: enf.ProtocolICMP6.Ptr()})
+	ip6 := compile(&enf.FirewallRule{Direction: enf.DirectionIngress.Ptr(), IPFamily: enf.IPFamily6.Ptr()})
+	egress := compile(&enf.FirewallRule{Direction: enf.DirectionEgress.Ptr()})
+
+	tests := []struct {
+		name string
+		r, o *Rule
+		want bool
+	}{
+		{"any covers net", all, subnet, true},
+		{"net covers host", subnet, host, true},
+		{"host does not cover net", host, subnet, false},
+		{"ssh covers host", ssh, host, true},
+		{"ssh does not cover any", ssh, all, false},
+		{"ssh covers ping", ssh, ping, true},
+		{"ip6 covers net", ip6, subnet, true},
+		{"ip6 does not cover any", ip6, all, false},
+		{"egress does not cover net", egress, subnet, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.Covers(tt.o); got != tt.want {
+			t.Errorf("[%v] Covers returned %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate_rangesAndICMPTypes(t *testing.T) {
+	rules := []*enf.FirewallRule{
+		{
+			ID: enf.String("web"), Priority: enf.Int(10),
+			Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(), Protocol: enf.ProtocolTCP.Ptr(),
+			DestPort: enf.Int(8000), DestPortEnd: enf.Int(8100),
+		},
+		{
+			ID: enf.String("echo"), Priority: enf.Int(20),
+			Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(), Protocol: enf.ProtocolICMP6.Ptr(),
+			ICMPType: enf.Int(128),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		packet Packet
+		action enf.FirewallAction
+	}{
+		{"first port", packet(enf.ProtocolTCP, "fd00::1", 40000, "fd00::2", 8000), enf.ActionAccept},
+		{"last port", packet(enf.ProtocolTCP, "fd00::1", 40000, "fd00::2", 8100), enf.ActionAccept},
+		{"outside range", packet(enf.ProtocolTCP, "fd00::1", 40000, "fd00::2", 8101), DefaultAction},
+		{"echo request", Packet{Protocol: enf.ProtocolICMP6, Direction: enf.DirectionIngress,
+			Source: netip.MustParseAddr("fd00::1"), Dest: netip.MustParseAddr("fd00::2"), ICMPType: 128}, enf.ActionAccept},
+		{"redirect", Packet{Protocol: enf.ProtocolICMP6, Direction: enf.DirectionIngress,
+			Source: netip.MustParseAddr("fd00::1"), Dest: netip.MustParseAddr("fd00::2"), ICMPType: 137}, DefaultAction},
+	}
+
+	for _, tt := range tests {
+		res, err := Evaluate(rules, tt.packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Action != tt.action {
+			t.Errorf("[%v] Evaluate returned %v, want %v", tt.name, res.Action, tt.action)
+		}
+	}
+
+	web, _ := Compile(rules[0])
+	narrow, _ := Compile(&enf.FirewallRule{
+		Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr(), Protocol: enf.ProtocolTCP.Ptr(),
+		DestPort: enf.Int(8050), DestPortEnd: enf.Int(8060),
+	})
+	if !web.Covers(narrow) || narrow.Covers(web) {
+		t.Errorf("Port range coverage is wrong")
+	}
+}
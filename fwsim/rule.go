@@ -0,0 +1,232 @@
+package fwsim
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Rule is a firewall rule compiled for matching. Fields that match
+// everything are left at their zero value.
+type Rule struct {
+	// Source is the rule the Rule was compiled from.
+	Source *enf.FirewallRule
+
+	Priority  int
+	Action    enf.FirewallAction
+	Direction enf.FirewallDirection
+	Family    enf.IPFamily
+	Protocol  enf.FirewallProtocol
+
+	// SourceIP and DestIP are the address prefixes the rule matches.
+	// An invalid (zero) prefix matches any address.
+	SourceIP netip.Prefix
+	DestIP   netip.Prefix
+
+	// SourcePorts and DestPorts are the ports the rule matches. The
+	// zero range matches any port.
+	SourcePorts PortRange
+	DestPorts   PortRange
+
+	// ICMPType and ICMPCode are the ICMP message type and code the
+	// rule matches. Nil matches any type or code.
+	ICMPType *int
+	ICMPCode *int
+}
+
+// PortRange is an inclusive range of ports. The zero value matches any
+// port.
+type PortRange struct {
+	First int
+	Last  int
+}
+
+func portRange(start, end *int) PortRange {
+	if start == nil || *start == 0 {
+		return PortRange{}
+	}
+	if end == nil {
+		return PortRange{*start, *start}
+	}
+	return PortRange{*start, *end}
+}
+
+// Any reports whether the range matches any port.
+func (r PortRange) Any() bool {
+	return r == PortRange{}
+}
+
+// Contains reports whether the port is in the range.
+func (r PortRange) Contains(port int) bool {
+	return r.Any() || r.First <= port && port <= r.Last
+}
+
+// Covers reports whether every port of the other range is in the range.
+func (r PortRange) Covers(o PortRange) bool {
+	if r.Any() {
+		return true
+	}
+	return !o.Any() && r.First <= o.First && o.Last <= r.Last
+}
+
+func (r PortRange) String() string {
+	switch {
+	case r.Any():
+		return "any"
+	case r.First == r.Last:
+		return fmt.Sprint(r.First)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// Compile compiles a firewall rule for matching. It returns an error
+// if the rule has no action or an address that cannot be parsed.
+func Compile(r *enf.FirewallRule) (*Rule, error) {
+	c := &Rule{Source: r}
+	if r.Priority != nil {
+		c.Priority = *r.Priority
+	}
+	if r.Action == nil || !r.Action.IsValid() {
+		return nil, fmt.Errorf("Invalid rule %v: missing or unknown action", ruleName(r))
+	}
+	c.Action = *r.Action
+	if r.Direction != nil {
+		c.Direction = *r.Direction
+	}
+	if r.IPFamily != nil {
+		c.Family = *r.IPFamily
+	}
+	if r.Protocol != nil && *r.Protocol != enf.ProtocolAll {
+		c.Protocol = *r.Protocol
+	}
+
+	var err error
+	if c.SourceIP, err = parsePrefix(r.SourceIP); err != nil {
+		return nil, fmt.Errorf("Invalid rule %v: source_ip: %v", ruleName(r), err)
+	}
+	if c.DestIP, err = parsePrefix(r.DestIP); err != nil {
+		return nil, fmt.Errorf("Invalid rule %v: dest_ip: %v", ruleName(r), err)
+	}
+	c.SourcePorts = portRange(r.SourcePort, r.SourcePortEnd)
+	c.DestPorts = portRange(r.DestPort, r.DestPortEnd)
+	c.ICMPType, c.ICMPCode = r.ICMPType, r.ICMPCode
+
+	return c, nil
+}
+
+// parsePrefix parses a rule address, which is either empty or "*" for
+// any address, a single address or a CIDR prefix.
+func parsePrefix(s *string) (netip.Prefix, error) {
+	if s == nil || *s == "" || *s == "*" {
+		return netip.Prefix{}, nil
+	}
+	if p, err := netip.ParsePrefix(*s); err == nil {
+		return p.Masked(), nil
+	}
+	a, err := netip.ParseAddr(*s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not an IP address or CIDR prefix", *s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// Match reports whether the rule matches the packet. If it does not,
+// the reason names the first field that differs.
+func (r *Rule) Match(p *Packet) (bool, string) {
+	if r.Direction != "" && r.Direction != p.Direction {
+		return false, fmt.Sprintf("direction %v does not match %v", p.Direction, r.Direction)
+	}
+	if r.Family != "" && r.Family != p.family() {
+		return false, fmt.Sprintf("family %v does not match %v", p.family(), r.Family)
+	}
+	if r.Protocol != "" && r.Protocol != p.Protocol {
+		return false, fmt.Sprintf("protocol %v does not match %v", p.Protocol, r.Protocol)
+	}
+	if r.SourceIP.IsValid() && !r.SourceIP.Contains(p.Source) {
+		return false, fmt.Sprintf("source %v is not in %v", p.Source, r.SourceIP)
+	}
+	if r.DestIP.IsValid() && !r.DestIP.Contains(p.Dest) {
+		return false, fmt.Sprintf("destination %v is not in %v", p.Dest, r.DestIP)
+	}
+	if p.hasPorts() {
+		if !r.SourcePorts.Contains(p.SourcePort) {
+			return false, fmt.Sprintf("source port %d is not in %v", p.SourcePort, r.SourcePorts)
+		}
+		if !r.DestPorts.Contains(p.DestPort) {
+			return false, fmt.Sprintf("destination port %d is not in %v", p.DestPort, r.DestPorts)
+		}
+	}
+	if p.isICMP() {
+		if r.ICMPType != nil && *r.ICMPType != p.ICMPType {
+			return false, fmt.Sprintf("ICMP type %d does not match %d", p.ICMPType, *r.ICMPType)
+		}
+		if r.ICMPCode != nil && *r.ICMPCode != p.ICMPCode {
+			return false, fmt.Sprintf("ICMP code %d does not match %d", p.ICMPCode, *r.ICMPCode)
+		}
+	}
+	return true, ""
+}
+
+// Covers reports whether the rule matches every packet that the other
+// rule matches, regardless of their actions.
+func (r *Rule) Covers(o *Rule) bool {
+	if r.Direction != "" && r.Direction != o.Direction {
+		return false
+	}
+	if r.Family != "" && r.Family != o.family() {
+		return false
+	}
+	if r.Protocol != "" && r.Protocol != o.Protocol {
+		return false
+	}
+	if !coversPrefix(r.SourceIP, o.SourceIP) || !coversPrefix(r.DestIP, o.DestIP) {
+		return false
+	}
+
+	// Ports only restrict packets that have them, and ICMP types only
+	// ICMP packets, so each only matters if the other rule matches
+	// such packets.
+	icmp := o.Protocol == enf.ProtocolICMP || o.Protocol == enf.ProtocolICMP6
+	if !icmp && !(r.SourcePorts.Covers(o.SourcePorts) && r.DestPorts.Covers(o.DestPorts)) {
+		return false
+	}
+	ported := o.Protocol == enf.ProtocolTCP || o.Protocol == enf.ProtocolUDP
+	return ported || coversInt(r.ICMPType, o.ICMPType) && coversInt(r.ICMPCode, o.ICMPCode)
+}
+
+// family returns the IP family the rule is restricted to, either
+// explicitly or by its addresses, or "" if it matches both.
+func (r *Rule) family() enf.IPFamily {
+	switch {
+	case r.Family != "":
+		return r.Family
+	case r.SourceIP.IsValid():
+		return addrFamily(r.SourceIP.Addr())
+	case r.DestIP.IsValid():
+		return addrFamily(r.DestIP.Addr())
+	}
+	return ""
+}
+
+func coversPrefix(p, o netip.Prefix) bool {
+	if !p.IsValid() {
+		return true
+	}
+	return o.IsValid() && p.Bits() <= o.Bits() && p.Contains(o.Addr())
+}
+
+func coversInt(p, o *int) bool {
+	return p == nil || o != nil && *p == *o
+}
+
+// ruleName returns a short name for a rule in messages.
+func ruleName(r *enf.FirewallRule) string {
+	if r.ID != nil && *r.ID != "" {
+		return *r.ID
+	}
+	if r.Priority != nil {
+		return fmt.Sprintf("at priority %d", *r.Priority)
+	}
+	return "without id"
+}
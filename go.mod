@@ -1,5 +1,8 @@
 module github.com/xaptum/go-enf
 
-go 1.12
+go 1.18
 
-require github.com/golangci/golangci-lint v1.18.0 // indirect
+require (
+	github.com/golangci/golangci-lint v1.18.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
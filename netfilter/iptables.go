@@ -0,0 +1,257 @@
+package netfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// ParseIPTables translates the output of iptables-save or
+// ip6tables-save. Rules in the INPUT, OUTPUT and FORWARD chains of the
+// filter table are translated, with the chain policies as catch-all
+// rules. The supported options are -s, -d, -p, --sport, --dport, the
+// multiport --sports and --dports, --icmp-type and --icmpv6-type, with
+// the targets ACCEPT, DROP and REJECT. Rules in other tables or chains,
+// or with other options or targets, are reported in Result.Issues.
+//
+// The returned error is only set if r cannot be read.
+func ParseIPTables(r io.Reader, opts *Options) (*Result, error) {
+	t := newTranslator(opts)
+	table := ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		o := Origin{Line: line, Text: text}
+
+		switch {
+		case text == "" || strings.HasPrefix(text, "#") || text == "COMMIT":
+		case strings.HasPrefix(text, "*"):
+			table = text[1:]
+		case strings.HasPrefix(text, ":"):
+			t.iptablesPolicy(table, text[1:], o)
+		default:
+			t.iptablesRule(table, text, o)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t.finish(), nil
+}
+
+// iptablesPolicy handles a chain declaration, ":CHAIN POLICY [p:b]".
+func (t *translator) iptablesPolicy(table, decl string, o Origin) {
+	fields := strings.Fields(decl)
+	if table != "filter" || len(fields) < 2 || fields[1] == "-" {
+		return
+	}
+	switch fields[1] {
+	case "ACCEPT":
+		t.addPolicy(fields[0], t.opts.Family, enf.ActionAccept, o)
+	case "DROP":
+		t.addPolicy(fields[0], t.opts.Family, enf.ActionDrop, o)
+	default:
+		t.issue(o, false, "policy %v is not supported", fields[1])
+	}
+}
+
+// iptablesRule translates a rule, "-A CHAIN options...", optionally
+// preceded by packet and byte counters.
+func (t *translator) iptablesRule(table, text string, o Origin) {
+	args, err := tokenize(text, "")
+	if err != nil {
+		t.issue(o, false, "%v", err)
+		return
+	}
+	if len(args) > 0 && strings.HasPrefix(args[0], "[") {
+		args = args[1:]
+	}
+
+	switch {
+	case table != "filter":
+		t.issue(o, false, "table %q is not supported", table)
+		return
+	case len(args) < 2 || args[0] != "-A":
+		t.issue(o, false, "expected -A CHAIN")
+		return
+	}
+	chain := args[1]
+	direction, ok := t.direction(chain)
+	if !ok || chain != strings.ToUpper(chain) {
+		t.issue(o, false, "user-defined chain %v is not supported", chain)
+		return
+	}
+
+	m := &match{direction: direction, family: t.opts.Family}
+	reject := false
+	for i := 2; i < len(args); i++ {
+		opt := args[i]
+		value := func() (string, error) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("option %v requires a value", opt)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var v string
+		switch opt {
+		case "!":
+			err = fmt.Errorf("negated matches are not supported")
+		case "-s", "--source":
+			if v, err = value(); err == nil {
+				m.src, err = parseAddrs(strings.Split(v, ","))
+			}
+		case "-d", "--destination":
+			if v, err = value(); err == nil {
+				m.dst, err = parseAddrs(strings.Split(v, ","))
+			}
+		case "-p", "--protocol":
+			if v, err = value(); err == nil {
+				var ok bool
+				if m.protocol, ok = parseProtocol(v); !ok {
+					err = fmt.Errorf("protocol %q is not supported", v)
+				}
+			}
+		case "-m", "--match":
+			if v, err = value(); err == nil {
+				switch v {
+				case "tcp", "udp", "icmp", "icmp6", "icmpv6", "multiport", "comment":
+				default:
+					err = fmt.Errorf("match %q is not supported", v)
+				}
+			}
+		case "--comment":
+			_, err = value()
+		case "--sport", "--source-port":
+			if v, err = value(); err == nil {
+				m.sports, err = parsePorts([]string{v}, ":")
+			}
+		case "--dport", "--destination-port":
+			if v, err = value(); err == nil {
+				m.dports, err = parsePorts([]string{v}, ":")
+			}
+		case "--sports", "--source-ports":
+			if v, err = value(); err == nil {
+				m.sports, err = parsePorts(strings.Split(v, ","), ":")
+			}
+		case "--dports", "--destination-ports":
+			if v, err = value(); err == nil {
+				m.dports, err = parsePorts(strings.Split(v, ","), ":")
+			}
+		case "--icmp-type", "--icmpv6-type":
+			if v, err = value(); err == nil && v != "any" {
+				protocol := enf.ProtocolICMP
+				if opt == "--icmpv6-type" {
+					protocol = enf.ProtocolICMP6
+				}
+				var spec icmpSpec
+				if spec.typ, spec.code, err = parseICMPType(v, protocol); err == nil {
+					m.icmp = []icmpSpec{spec}
+				}
+			}
+		case "-j", "--jump":
+			if v, err = value(); err == nil {
+				switch v {
+				case "ACCEPT":
+					m.action = enf.ActionAccept
+				case "DROP":
+					m.action = enf.ActionDrop
+				case "REJECT":
+					m.action, reject = enf.ActionDrop, true
+				default:
+					err = fmt.Errorf("target %v is not supported", v)
+				}
+			}
+		case "--reject-with":
+			_, err = value()
+		default:
+			err = fmt.Errorf("option %v is not supported", opt)
+		}
+		if err != nil {
+			t.issue(o, false, "%v", err)
+			return
+		}
+	}
+
+	if m.action == "" {
+		t.issue(o, false, "rule has no ACCEPT, DROP or REJECT target")
+		return
+	}
+	if t.add(m, o) && reject {
+		t.issue(o, true, "REJECT is translated to DROP")
+	}
+}
+
+func parseAddrs(values []string) ([]string, error) {
+	addrs := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		if addrs[i], err = parseAddr(v); err != nil {
+			return nil, err
+		}
+	}
+	return addrs, nil
+}
+
+func parsePorts(values []string, sep string) ([]portSpec, error) {
+	ports := make([]portSpec, len(values))
+	for i, v := range values {
+		var err error
+		if ports[i], err = parsePort(v, sep); err != nil {
+			return nil, err
+		}
+	}
+	return ports, nil
+}
+
+// tokenize splits a line into words. Double-quoted strings are single
+// words, with backslash escapes, and an unquoted # starts a comment.
+// Each character in punct is a word of its own.
+func tokenize(s, punct string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inWord, quoted := false, false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(s):
+			i++
+			cur.WriteByte(s[i])
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case quoted:
+			cur.WriteByte(c)
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '#' && !inWord:
+			flush()
+			return tokens, nil
+		case strings.IndexByte(punct, c) >= 0:
+			flush()
+			tokens = append(tokens, string(c))
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	flush()
+	return tokens, nil
+}
@@ -0,0 +1,177 @@
+package netfilter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// describe summarizes a request on one line, leaving out unset fields.
+func describe(r enf.FirewallRuleRequest) string {
+	parts := []string{
+		fmt.Sprint(*r.Priority), r.Direction.String(), r.Action.String(),
+	}
+	if r.IPFamily != nil {
+		parts = append(parts, r.IPFamily.String())
+	}
+	if r.Protocol != nil {
+		parts = append(parts, r.Protocol.String())
+	}
+	str := func(name string, v *string) {
+		if v != nil {
+			parts = append(parts, name+"="+*v)
+		}
+	}
+	num := func(name string, v *int) {
+		if v != nil {
+			parts = append(parts, fmt.Sprintf("%v=%d", name, *v))
+		}
+	}
+	str("src", r.SourceIP)
+	num("sport", r.SourcePort)
+	num("sport_end", r.SourcePortEnd)
+	str("dst", r.DestIP)
+	num("dport", r.DestPort)
+	num("dport_end", r.DestPortEnd)
+	num("type", r.ICMPType)
+	num("code", r.ICMPCode)
+	return strings.Join(parts, " ")
+}
+
+func describeResult(r *Result) ([]string, []string) {
+	var rules, issues []string
+	for i, rule := range r.Rules {
+		rules = append(rules, fmt.Sprintf("%d: %v", r.Origins[i].Line, describe(rule)))
+	}
+	for _, issue := range r.Issues {
+		issues = append(issues, fmt.Sprintf("%d: %v", issue.Line, issue.Reason))
+	}
+	return rules, issues
+}
+
+func TestParseIPTables(t *testing.T) {
+	input := `# Generated by iptables-save v1.8.7
+*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -p tcp --dport 80 -j REDIRECT --to-ports 8080
+COMMIT
+*filter
+:INPUT DROP [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [10:600]
+:LOGGING - [0:0]
+-A INPUT -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+[5:300] -A INPUT -s 10.0.0.0/8 -p tcp -m tcp --dport 22 -m comment --comment "ssh # admin" -j ACCEPT
+-A INPUT -s 192.0.2.1/32,192.0.2.2/32 -p udp -m multiport --dports 53,5000:5010 -j ACCEPT
+-A INPUT -p icmp -m icmp --icmp-type 8 -j ACCEPT
+-A INPUT -p tcp -m tcp --sport 1:65535 --dport 8080 -j REJECT --reject-with tcp-reset
+-A INPUT ! -s 10.0.0.0/8 -j DROP
+-A INPUT -i lo -j ACCEPT
+-A INPUT -j LOGGING
+-A OUTPUT -d 198.51.100.0/24 -j DROP
+-A FORWARD -p tcp --dport 443 -j ACCEPT
+-A LOGGING -j LOG
+COMMIT
+`
+	got, err := ParseIPTables(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ParseIPTables returned error %v", err)
+	}
+
+	rules, issues := describeResult(got)
+	wantRules := []string{
+		"12: 100 INGRESS ACCEPT IP4 TCP src=10.0.0.0/8 dport=22",
+		"13: 110 INGRESS ACCEPT IP4 UDP src=192.0.2.1 dport=53",
+		"13: 120 INGRESS ACCEPT IP4 UDP src=192.0.2.1 dport=5000 dport_end=5010",
+		"13: 130 INGRESS ACCEPT IP4 UDP src=192.0.2.2 dport=53",
+		"13: 140 INGRESS ACCEPT IP4 UDP src=192.0.2.2 dport=5000 dport_end=5010",
+		"14: 150 INGRESS ACCEPT ICMP type=8",
+		"15: 160 INGRESS DROP TCP dport=8080",
+		"19: 170 EGRESS DROP IP4 dst=198.51.100.0/24",
+		"20: 180 INGRESS ACCEPT TCP dport=443",
+		"7: 190 INGRESS DROP",
+		"9: 200 EGRESS ACCEPT",
+	}
+	wantIssues := []string{
+		"4: table \"nat\" is not supported",
+		"8: policy ACCEPT of chain FORWARD conflicts with policy DROP of chain INPUT for direction INGRESS",
+		"11: match \"conntrack\" is not supported",
+		"15: REJECT is translated to DROP",
+		"16: negated matches are not supported",
+		"17: option -i is not supported",
+		"18: target LOGGING is not supported",
+		"21: user-defined chain LOGGING is not supported",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("ParseIPTables returned rules\n%v\nwant\n%v", strings.Join(rules, "\n"), strings.Join(wantRules, "\n"))
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("ParseIPTables returned issues\n%v\nwant\n%v", strings.Join(issues, "\n"), strings.Join(wantIssues, "\n"))
+	}
+	if got.Exact() {
+		t.Errorf("Exact returned true with issues")
+	}
+}
+
+func TestParseIPTables_options(t *testing.T) {
+	input := `*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+-A INPUT -p ipv6-icmp -m icmp6 --icmpv6-type echo-request -j ACCEPT
+-A FORWARD -s fd00::/64 -j ACCEPT
+COMMIT
+`
+	opts := &Options{
+		Family:           enf.IPFamily6,
+		ForwardDirection: enf.DirectionEgress,
+		FirstPriority:    1000,
+		PriorityStep:     1,
+	}
+	got, err := ParseIPTables(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatalf("ParseIPTables returned error %v", err)
+	}
+
+	rules, issues := describeResult(got)
+	wantRules := []string{
+		"4: 1000 INGRESS ACCEPT IP6 ICMP6 type=128",
+		"5: 1001 EGRESS ACCEPT IP6 src=fd00::/64",
+		"2: 1002 INGRESS ACCEPT IP6",
+		"3: 1003 EGRESS DROP IP6",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("ParseIPTables returned rules\n%v\nwant\n%v", strings.Join(rules, "\n"), strings.Join(wantRules, "\n"))
+	}
+	if len(issues) != 0 || !got.Exact() {
+		t.Errorf("ParseIPTables returned issues %v, want none", issues)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		in    string
+		punct string
+		want  []string
+	}{
+		{`-A INPUT -j ACCEPT`, "", []string{"-A", "INPUT", "-j", "ACCEPT"}},
+		{`--comment "a \"b\" # c" -j DROP # note`, "", []string{"--comment", `a "b" # c`, "-j", "DROP"}},
+		{`tcp dport { 22, 80 } accept`, "{},", []string{"tcp", "dport", "{", "22", ",", "80", "}", "accept"}},
+	}
+	for _, tt := range tests {
+		got, err := tokenize(tt.in, tt.punct)
+		if err != nil {
+			t.Errorf("tokenize(%q) returned error %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) returned %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	if _, err := tokenize(`"open`, ""); err == nil {
+		t.Errorf("tokenize with an unterminated quote returned no error")
+	}
+}
@@ -0,0 +1,473 @@
+// Package netfilter translates Linux firewall rulesets, as printed by
+// iptables-save, ip6tables-save and nft list ruleset, into ENF firewall
+// rule requests.
+//
+// Only a subset of either format can be expressed as ENF rules: rules
+// of the filter table in the built-in input, output and forward chains
+// that match on addresses, protocol, ports and ICMP types, with an
+// ACCEPT, DROP or REJECT verdict. Everything else is reported as an
+// Issue with the line it came from, never silently dropped.
+package netfilter
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Options specifies the optional parameters to the translators.
+type Options struct {
+	// Family is the IP family of rules that don't have addresses, such
+	// as enf.IPFamily6 for ip6tables-save output. If empty, such rules
+	// apply to both families.
+	Family enf.IPFamily
+
+	// ForwardDirection is the direction of the rules of the forward
+	// chain. It defaults to enf.DirectionIngress.
+	ForwardDirection enf.FirewallDirection
+
+	// FirstPriority is the priority of the first rule. It defaults to
+	// 100.
+	FirstPriority int
+
+	// PriorityStep is the difference between the priorities of
+	// consecutive rules. It defaults to 10.
+	PriorityStep int
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.ForwardDirection == "" {
+		opts.ForwardDirection = enf.DirectionIngress
+	}
+	if opts.FirstPriority == 0 {
+		opts.FirstPriority = 100
+	}
+	if opts.PriorityStep == 0 {
+		opts.PriorityStep = 10
+	}
+	return opts
+}
+
+// Origin is the source line a rule was translated from.
+type Origin struct {
+	Line int
+	Text string
+}
+
+// Issue is a line that could not be translated exactly.
+type Issue struct {
+	Line int
+	Text string
+
+	// Reason describes what could not be expressed.
+	Reason string
+
+	// Approximated is true if the line was translated into rules that
+	// differ from the original, such as REJECT becoming DROP. If false,
+	// the line was not translated at all.
+	Approximated bool
+}
+
+func (i *Issue) String() string {
+	what := "skipped"
+	if i.Approximated {
+		what = "approximated"
+	}
+	return fmt.Sprintf("line %d: %v: %v: %v", i.Line, what, i.Reason, i.Text)
+}
+
+// Result is a translated ruleset.
+type Result struct {
+	// Rules are the translated rules, with priorities assigned in the
+	// order of the input. Chain policies become catch-all rules after
+	// all other rules.
+	Rules []enf.FirewallRuleRequest
+
+	// Origins[i] is the line Rules[i] was translated from.
+	Origins []Origin
+
+	// Issues are the lines that could not be translated exactly.
+	Issues []*Issue
+}
+
+// Exact reports whether every line was translated exactly.
+func (r *Result) Exact() bool {
+	return len(r.Issues) == 0
+}
+
+// portSpec is a port or an inclusive port range.
+type portSpec struct {
+	first, last int
+}
+
+// icmpSpec is an ICMP type and optional code.
+type icmpSpec struct {
+	typ, code *int
+}
+
+// match is a rule being translated. Its addresses, ports and ICMP types
+// are sets, and a request is made for every combination of them.
+type match struct {
+	direction enf.FirewallDirection
+	family    enf.IPFamily
+	protocol  enf.FirewallProtocol
+	src, dst  []string
+	sports    []portSpec
+	dports    []portSpec
+	icmp      []icmpSpec
+	action    enf.FirewallAction
+}
+
+// translator collects the rules and issues of a ruleset.
+type translator struct {
+	opts     Options
+	result   *Result
+	policies []policy
+}
+
+type policy struct {
+	direction enf.FirewallDirection
+
+	// family is the family of the table of the chain, or "" for both.
+	family enf.IPFamily
+	action enf.FirewallAction
+	chain  string
+	origin Origin
+}
+
+func newTranslator(opts *Options) *translator {
+	return &translator{opts: opts.withDefaults(), result: new(Result)}
+}
+
+func (t *translator) issue(o Origin, approximated bool, format string, args ...interface{}) {
+	t.result.Issues = append(t.result.Issues, &Issue{
+		Line:         o.Line,
+		Text:         o.Text,
+		Reason:       fmt.Sprintf(format, args...),
+		Approximated: approximated,
+	})
+}
+
+// direction returns the direction of a built-in chain, by its iptables
+// name or nftables hook.
+func (t *translator) direction(chain string) (enf.FirewallDirection, bool) {
+	switch strings.ToLower(chain) {
+	case "input":
+		return enf.DirectionIngress, true
+	case "output":
+		return enf.DirectionEgress, true
+	case "forward":
+		return t.opts.ForwardDirection, true
+	}
+	return "", false
+}
+
+// add expands a match into requests and reports whether they were
+// added. Requests that fail validation are reported instead.
+func (t *translator) add(m *match, o Origin) bool {
+	base := enf.FirewallRuleRequest{
+		Action:    m.action.Ptr(),
+		Direction: m.direction.Ptr(),
+	}
+	if m.protocol != "" {
+		base.Protocol = m.protocol.Ptr()
+	}
+
+	rules := []enf.FirewallRuleRequest{base}
+	rules = expand(rules, len(m.src), func(r *enf.FirewallRuleRequest, i int) {
+		r.SourceIP = enf.String(m.src[i])
+	})
+	rules = expand(rules, len(m.dst), func(r *enf.FirewallRuleRequest, i int) {
+		r.DestIP = enf.String(m.dst[i])
+	})
+	rules = expand(rules, len(m.sports), func(r *enf.FirewallRuleRequest, i int) {
+		r.SourcePort, r.SourcePortEnd = m.sports[i].ports()
+	})
+	rules = expand(rules, len(m.dports), func(r *enf.FirewallRuleRequest, i int) {
+		r.DestPort, r.DestPortEnd = m.dports[i].ports()
+	})
+	rules = expand(rules, len(m.icmp), func(r *enf.FirewallRuleRequest, i int) {
+		r.ICMPType, r.ICMPCode = m.icmp[i].typ, m.icmp[i].code
+	})
+
+	for i := range rules {
+		r := &rules[i]
+		if family := t.family(m.family, r.SourceIP, r.DestIP); family != "" {
+			r.IPFamily = family.Ptr()
+		}
+		// Priorities are assigned once all rules are known; validate
+		// with a placeholder.
+		r.Priority = enf.Int(0)
+		if err := r.Validate(); err != nil {
+			t.issue(o, false, "%v", err)
+			return false
+		}
+	}
+	for _, r := range rules {
+		t.result.Rules = append(t.result.Rules, r)
+		t.result.Origins = append(t.result.Origins, o)
+	}
+	return true
+}
+
+// expand returns a copy of every rule for each of n values, set by the
+// given function. If n is 0, the rules are returned unchanged.
+func expand(rules []enf.FirewallRuleRequest, n int, set func(r *enf.FirewallRuleRequest, i int)) []enf.FirewallRuleRequest {
+	if n == 0 {
+		return rules
+	}
+	expanded := make([]enf.FirewallRuleRequest, 0, len(rules)*n)
+	for _, r := range rules {
+		for i := 0; i < n; i++ {
+			set(&r, i)
+			expanded = append(expanded, r)
+		}
+	}
+	return expanded
+}
+
+// family returns the family of a rule: the family of the table or
+// match, else of its addresses, else the default from the options.
+func (t *translator) family(family enf.IPFamily, addrs ...*string) enf.IPFamily {
+	if family != "" {
+		return family
+	}
+	for _, addr := range addrs {
+		if addr == nil {
+			continue
+		}
+		a := *addr
+		if p, err := netip.ParsePrefix(a); err == nil {
+			a = p.Addr().String()
+		}
+		if ip, err := netip.ParseAddr(a); err == nil {
+			if ip.Is4() {
+				return enf.IPFamily4
+			}
+			return enf.IPFamily6
+		}
+	}
+	return t.opts.Family
+}
+
+// addPolicy records the policy of a built-in chain of a table of the
+// given family, or of both families if it is "". Policies become
+// catch-all rules once all rules have been added. A packet must be
+// accepted by every chain it passes, so when the policies of a
+// direction disagree for a family, the conflict is reported and DROP
+// applies to that family.
+func (t *translator) addPolicy(chain string, family enf.IPFamily, action enf.FirewallAction, o Origin) {
+	direction, ok := t.direction(chain)
+	if !ok {
+		return
+	}
+	add := true
+	for i := range t.policies {
+		p := &t.policies[i]
+		if p.direction != direction || !familiesOverlap(p.family, family) {
+			continue
+		}
+		if p.action != action {
+			scope := fmt.Sprintf("direction %v", direction)
+			f := p.family
+			if f == "" {
+				f = family
+			}
+			if f != "" {
+				scope += fmt.Sprintf(" and family %v", f)
+			}
+			t.issue(o, false, "policy %v of chain %v conflicts with policy %v of chain %v for %v",
+				action, chain, p.action, p.chain, scope)
+		}
+		switch {
+		case p.family == family:
+			add = false
+			if action == enf.ActionDrop && p.action != action {
+				*p = policy{direction, family, action, chain, o}
+			}
+		case p.family == "":
+			// The policy of both families already drops what this
+			// one would accept.
+			add = add && !(action == enf.ActionAccept && p.action == enf.ActionDrop)
+		case action == enf.ActionDrop:
+			p.action, p.chain, p.origin = action, chain, o
+		}
+	}
+	if add {
+		t.policies = append(t.policies, policy{direction, family, action, chain, o})
+	}
+}
+
+// familiesOverlap reports whether the families, where "" is both, have
+// a family in common.
+func familiesOverlap(a, b enf.IPFamily) bool {
+	return a == "" || b == "" || a == b
+}
+
+// finish adds the policies and assigns the priorities. The policies of
+// a single family come before those of both families, so that they are
+// not shadowed.
+func (t *translator) finish() *Result {
+	sort.SliceStable(t.policies, func(i, j int) bool {
+		return t.policies[i].family != "" && t.policies[j].family == ""
+	})
+	for _, p := range t.policies {
+		r := enf.FirewallRuleRequest{Action: p.action.Ptr(), Direction: p.direction.Ptr()}
+		if p.family != "" {
+			r.IPFamily = p.family.Ptr()
+		}
+		t.result.Rules = append(t.result.Rules, r)
+		t.result.Origins = append(t.result.Origins, p.origin)
+	}
+	for i := range t.result.Rules {
+		t.result.Rules[i].Priority = enf.Int(t.opts.FirstPriority + i*t.opts.PriorityStep)
+	}
+	return t.result
+}
+
+// ports returns the port and end of range of a request. A range of
+// every port is returned as any port.
+func (p portSpec) ports() (*int, *int) {
+	switch {
+	case p.first <= 1 && p.last == 65535:
+		return nil, nil
+	case p.last == p.first:
+		return enf.Int(p.first), nil
+	}
+	return enf.Int(p.first), enf.Int(p.last)
+}
+
+// parsePort parses a port or a port range, with the given separator
+// between the first and last port.
+func parsePort(s, sep string) (portSpec, error) {
+	first, last := s, s
+	if i := strings.Index(s, sep); i >= 0 {
+		first, last = s[:i], s[i+len(sep):]
+	}
+	f, err1 := strconv.Atoi(first)
+	l, err2 := strconv.Atoi(last)
+	if err1 != nil || err2 != nil || f < 0 || l > 65535 || l < f {
+		return portSpec{}, fmt.Errorf("invalid port %q", s)
+	}
+	return portSpec{f, l}, nil
+}
+
+// parseAddr parses an address or prefix, returning single-address
+// prefixes as plain addresses.
+func parseAddr(s string) (string, error) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid address %q", s)
+		}
+		return a.String(), nil
+	}
+	if p.IsSingleIP() {
+		return p.Addr().String(), nil
+	}
+	return p.String(), nil
+}
+
+// parseProtocol maps a protocol name to an ENF protocol.
+func parseProtocol(s string) (enf.FirewallProtocol, bool) {
+	switch strings.ToLower(s) {
+	case "all", "any", "0":
+		return enf.ProtocolAll, true
+	case "tcp", "6":
+		return enf.ProtocolTCP, true
+	case "udp", "17":
+		return enf.ProtocolUDP, true
+	case "icmp", "1":
+		return enf.ProtocolICMP, true
+	case "icmpv6", "ipv6-icmp", "icmp6", "58":
+		return enf.ProtocolICMP6, true
+	}
+	return "", false
+}
+
+// ICMP message types by the names used by iptables and nftables.
+var (
+	icmpTypes = map[string]int{
+		"echo-reply":              0,
+		"destination-unreachable": 3,
+		"source-quench":           4,
+		"redirect":                5,
+		"echo-request":            8,
+		"router-advertisement":    9,
+		"router-solicitation":     10,
+		"time-exceeded":           11,
+		"parameter-problem":       12,
+		"timestamp-request":       13,
+		"timestamp-reply":         14,
+	}
+	icmp6Types = map[string]int{
+		"destination-unreachable": 1,
+		"packet-too-big":          2,
+		"time-exceeded":           3,
+		"parameter-problem":       4,
+		"echo-request":            128,
+		"echo-reply":              129,
+		"mld-listener-query":      130,
+		"mld-listener-report":     131,
+		"mld-listener-done":       132,
+		"mld-listener-reduction":  132,
+		"nd-router-solicit":       133,
+		"router-solicitation":     133,
+		"nd-router-advert":        134,
+		"router-advertisement":    134,
+		"nd-neighbor-solicit":     135,
+		"neighbor-solicitation":   135,
+		"neighbour-solicitation":  135,
+		"nd-neighbor-advert":      136,
+		"neighbor-advertisement":  136,
+		"neighbour-advertisement": 136,
+		"nd-redirect":             137,
+		"redirect":                137,
+	}
+)
+
+// parseICMPType parses an ICMP type given by name or number, optionally
+// followed by /code.
+func parseICMPType(s string, protocol enf.FirewallProtocol) (typ, code *int, err error) {
+	name, codeStr := s, ""
+	if i := strings.Index(s, "/"); i >= 0 {
+		name, codeStr = s[:i], s[i+1:]
+	}
+
+	names := icmpTypes
+	if protocol == enf.ProtocolICMP6 {
+		names = icmp6Types
+	}
+	if t, ok := names[strings.ToLower(name)]; ok {
+		typ = enf.Int(t)
+	} else if t, err := strconv.Atoi(name); err == nil && t >= 0 && t <= 255 {
+		typ = enf.Int(t)
+	} else {
+		return nil, nil, fmt.Errorf("unknown ICMP type %q", name)
+	}
+
+	if codeStr != "" {
+		if code, err = parseICMPCode(codeStr); err != nil {
+			return nil, nil, err
+		}
+	}
+	return typ, code, nil
+}
+
+// parseICMPCode parses a numeric ICMP code.
+func parseICMPCode(s string) (*int, error) {
+	c, err := strconv.Atoi(s)
+	if err != nil || c < 0 || c > 255 {
+		return nil, fmt.Errorf("invalid ICMP code %q", s)
+	}
+	return enf.Int(c), nil
+}
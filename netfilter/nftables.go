@@ -0,0 +1,464 @@
+package netfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// ParseNFTables translates the output of nft list ruleset. Rules in
+// filter chains of ip, ip6 and inet tables that hook input, output or
+// forward are translated, with the chain policies as catch-all rules
+// of the family of their table. The supported matches are ip and ip6
+// saddr, daddr, protocol and nexthdr, tcp and udp sport and dport,
+// icmp and icmpv6 type and code, and meta l4proto and nfproto, with
+// anonymous sets of values, and the verdicts accept, drop and reject.
+// Other tables, chains, matches and statements, and base chains that
+// share a hook, are reported in Result.Issues.
+//
+// The returned error is only set if r cannot be read.
+func ParseNFTables(r io.Reader, opts *Options) (*Result, error) {
+	p := &nftParser{t: newTranslator(opts)}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		p.line(Origin{Line: line, Text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return p.t.finish(), nil
+}
+
+// nftParser tracks the table and chain of the line being parsed.
+type nftParser struct {
+	t *translator
+
+	// table is the family of the current table, or "" outside of one.
+	table string
+
+	// chain is the current chain, or nil outside of one.
+	chain *nftChain
+
+	// skip is the depth of the block being skipped, such as a set.
+	skip int
+
+	// bases are the translated base chains.
+	bases []nftBase
+}
+
+type nftChain struct {
+	name, typ, hook string
+}
+
+// nftBase is a base chain and the family of its table.
+type nftBase struct {
+	chain  *nftChain
+	family enf.IPFamily
+	line   int
+}
+
+func (p *nftParser) line(o Origin) {
+	tokens, err := tokenize(o.Text, "{},;")
+	if err != nil {
+		p.t.issue(o, false, "%v", err)
+		return
+	}
+	if len(tokens) == 0 {
+		return
+	}
+	if p.skip > 0 {
+		p.skip += count(tokens, "{") - count(tokens, "}")
+		return
+	}
+
+	switch {
+	case tokens[0] == "}":
+		if p.chain != nil {
+			p.chain = nil
+		} else {
+			p.table = ""
+		}
+	case p.table == "":
+		if tokens[0] == "table" && len(tokens) >= 3 {
+			p.table = tokens[1]
+		} else {
+			p.t.issue(o, false, "expected table")
+		}
+	case p.chain == nil:
+		if tokens[0] == "chain" && len(tokens) >= 2 {
+			p.chain = &nftChain{name: tokens[1]}
+		} else {
+			// Sets, maps and other objects are only used through
+			// rules that refer to them, which are reported.
+			p.skip = count(tokens, "{") - count(tokens, "}")
+		}
+	case tokens[0] == "type":
+		p.chainType(tokens, o)
+	case tokens[0] == "comment":
+	default:
+		p.rule(tokens, o)
+	}
+}
+
+// chainType handles the declaration of a base chain,
+// "type TYPE hook HOOK priority PRIORITY; policy POLICY;".
+func (p *nftParser) chainType(tokens []string, o Origin) {
+	var policy string
+	for i := 0; i+1 < len(tokens); i++ {
+		switch tokens[i] {
+		case "type":
+			p.chain.typ = tokens[i+1]
+		case "hook":
+			p.chain.hook = tokens[i+1]
+		case "policy":
+			policy = tokens[i+1]
+		}
+	}
+	if _, err := p.direction(); err != nil {
+		if policy != "" && policy != "accept" {
+			p.t.issue(o, false, "%v", err)
+		}
+		return
+	}
+
+	// A packet must be accepted by every base chain of its hook, which
+	// first-match rules cannot express.
+	family := p.family()
+	for _, b := range p.bases {
+		if b.chain.hook == p.chain.hook && familiesOverlap(b.family, family) {
+			p.t.issue(o, false, "chain %v also hooks %v, like chain %v on line %d; a packet must be accepted by both, which is not supported",
+				p.chain.name, p.chain.hook, b.chain.name, b.line)
+			break
+		}
+	}
+	p.bases = append(p.bases, nftBase{p.chain, family, o.Line})
+
+	switch policy {
+	case "":
+	case "accept":
+		p.t.addPolicy(p.chain.hook, family, enf.ActionAccept, o)
+	case "drop":
+		p.t.addPolicy(p.chain.hook, family, enf.ActionDrop, o)
+	default:
+		p.t.issue(o, false, "policy %v is not supported", policy)
+	}
+}
+
+// direction returns the direction of the rules of the current chain, or
+// an error if they cannot be translated.
+func (p *nftParser) direction() (enf.FirewallDirection, error) {
+	switch {
+	case p.table != "ip" && p.table != "ip6" && p.table != "inet":
+		return "", fmt.Errorf("table family %v is not supported", p.table)
+	case p.chain.hook == "":
+		return "", fmt.Errorf("regular chain %v is not supported", p.chain.name)
+	case p.chain.typ != "filter":
+		return "", fmt.Errorf("chain type %v is not supported", p.chain.typ)
+	}
+	direction, ok := p.t.direction(p.chain.hook)
+	if !ok {
+		return "", fmt.Errorf("hook %v is not supported", p.chain.hook)
+	}
+	return direction, nil
+}
+
+// family returns the family of the current table: IP4 for ip, IP6 for
+// ip6, and the family from the options for inet.
+func (p *nftParser) family() enf.IPFamily {
+	switch p.table {
+	case "ip":
+		return enf.IPFamily4
+	case "ip6":
+		return enf.IPFamily6
+	}
+	return p.t.opts.Family
+}
+
+// rule translates a rule of the current chain.
+func (p *nftParser) rule(tokens []string, o Origin) {
+	direction, err := p.direction()
+	if err != nil {
+		p.t.issue(o, false, "%v", err)
+		return
+	}
+
+	m := &match{direction: direction, family: p.family()}
+
+	r := &nftRule{tokens: tokens}
+	reject := false
+	for err == nil && !r.done() {
+		switch word := r.next(); word {
+		case "ip", "ip6":
+			err = r.ip(m, word)
+		case "tcp", "udp":
+			err = r.transport(m, word)
+		case "icmp", "icmpv6":
+			err = r.icmp(m, word)
+		case "meta":
+			err = r.meta(m)
+		case "counter":
+			if r.peek() == "packets" {
+				r.i += 4
+			}
+		case "comment":
+			r.next()
+		case "accept":
+			m.action = enf.ActionAccept
+		case "drop":
+			m.action = enf.ActionDrop
+		case "reject":
+			m.action, reject = enf.ActionDrop, true
+			if r.peek() == "with" {
+				for !r.done() && r.peek() != "comment" {
+					r.next()
+				}
+			}
+		default:
+			err = fmt.Errorf("%q is not supported", word)
+		}
+	}
+	if err != nil {
+		p.t.issue(o, false, "%v", err)
+		return
+	}
+
+	if m.action == "" {
+		p.t.issue(o, false, "rule has no accept, drop or reject verdict")
+		return
+	}
+	if p.t.add(m, o) && reject {
+		p.t.issue(o, true, "reject is translated to drop")
+	}
+}
+
+// nftRule is a cursor over the words of a rule.
+type nftRule struct {
+	tokens []string
+	i      int
+}
+
+func (r *nftRule) done() bool {
+	return r.i >= len(r.tokens)
+}
+
+func (r *nftRule) peek() string {
+	if r.done() {
+		return ""
+	}
+	return r.tokens[r.i]
+}
+
+func (r *nftRule) next() string {
+	word := r.peek()
+	r.i++
+	return word
+}
+
+// values reads the right-hand side of a match: a value or an anonymous
+// set of values, optionally preceded by ==.
+func (r *nftRule) values(what string) ([]string, error) {
+	v := r.next()
+	if v == "==" {
+		v = r.next()
+	}
+	switch {
+	case v == "":
+		return nil, fmt.Errorf("%v requires a value", what)
+	case v == "!=" || v == "<" || v == ">" || v == "<=" || v == ">=":
+		return nil, fmt.Errorf("operator %v is not supported", v)
+	case strings.HasPrefix(v, "@"):
+		return nil, fmt.Errorf("named set %v is not supported", v)
+	case v != "{":
+		return []string{v}, nil
+	}
+
+	var values []string
+	for {
+		switch v := r.next(); v {
+		case "":
+			return nil, fmt.Errorf("unterminated set")
+		case "}":
+			return values, nil
+		case ",":
+		default:
+			values = append(values, v)
+		}
+	}
+}
+
+// value reads the right-hand side of a match that must be a single
+// value.
+func (r *nftRule) value(what string) (string, error) {
+	values, err := r.values(what)
+	if err != nil {
+		return "", err
+	}
+	if len(values) != 1 {
+		return "", fmt.Errorf("sets of %v are not supported", what)
+	}
+	return values[0], nil
+}
+
+func (r *nftRule) ip(m *match, word string) error {
+	m.family = enf.IPFamily4
+	if word == "ip6" {
+		m.family = enf.IPFamily6
+	}
+
+	field := r.next()
+	what := word + " " + field
+	switch field {
+	case "saddr", "daddr":
+		values, err := r.values(what)
+		if err != nil {
+			return err
+		}
+		addrs, err := parseAddrs(values)
+		if err != nil {
+			return err
+		}
+		if field == "saddr" {
+			m.src = addrs
+		} else {
+			m.dst = addrs
+		}
+	case "protocol", "nexthdr":
+		return r.protocol(m, what)
+	default:
+		return fmt.Errorf("%q is not supported", what)
+	}
+	return nil
+}
+
+func (r *nftRule) transport(m *match, word string) error {
+	protocol, _ := parseProtocol(word)
+	if err := setProtocol(m, protocol); err != nil {
+		return err
+	}
+
+	field := r.next()
+	what := word + " " + field
+	switch field {
+	case "sport", "dport":
+		values, err := r.values(what)
+		if err != nil {
+			return err
+		}
+		ports, err := parsePorts(values, "-")
+		if err != nil {
+			return err
+		}
+		if field == "sport" {
+			m.sports = ports
+		} else {
+			m.dports = ports
+		}
+	default:
+		return fmt.Errorf("%q is not supported", what)
+	}
+	return nil
+}
+
+func (r *nftRule) icmp(m *match, word string) error {
+	protocol, _ := parseProtocol(word)
+	if err := setProtocol(m, protocol); err != nil {
+		return err
+	}
+
+	field := r.next()
+	what := word + " " + field
+	switch field {
+	case "type":
+		values, err := r.values(what)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			typ, _, err := parseICMPType(v, protocol)
+			if err != nil {
+				return err
+			}
+			m.icmp = append(m.icmp, icmpSpec{typ: typ})
+		}
+	case "code":
+		v, err := r.value(what)
+		if err != nil {
+			return err
+		}
+		if len(m.icmp) == 0 {
+			return fmt.Errorf("%v without a type is not supported", what)
+		}
+		code, err := parseICMPCode(v)
+		if err != nil {
+			return err
+		}
+		for i := range m.icmp {
+			m.icmp[i].code = code
+		}
+	default:
+		return fmt.Errorf("%q is not supported", what)
+	}
+	return nil
+}
+
+func (r *nftRule) meta(m *match) error {
+	field := r.next()
+	what := "meta " + field
+	switch field {
+	case "l4proto":
+		return r.protocol(m, what)
+	case "nfproto":
+		v, err := r.value(what)
+		if err != nil {
+			return err
+		}
+		switch v {
+		case "ipv4":
+			m.family = enf.IPFamily4
+		case "ipv6":
+			m.family = enf.IPFamily6
+		default:
+			return fmt.Errorf("%v %v is not supported", what, v)
+		}
+	default:
+		return fmt.Errorf("%q is not supported", what)
+	}
+	return nil
+}
+
+func (r *nftRule) protocol(m *match, what string) error {
+	v, err := r.value(what)
+	if err != nil {
+		return err
+	}
+	protocol, ok := parseProtocol(v)
+	if !ok {
+		return fmt.Errorf("protocol %q is not supported", v)
+	}
+	return setProtocol(m, protocol)
+}
+
+// setProtocol sets the protocol of a match, which may be implied by
+// several of its parts.
+func setProtocol(m *match, protocol enf.FirewallProtocol) error {
+	if m.protocol != "" && m.protocol != protocol {
+		return fmt.Errorf("conflicting protocols %v and %v", m.protocol, protocol)
+	}
+	m.protocol = protocol
+	return nil
+}
+
+func count(tokens []string, s string) int {
+	n := 0
+	for _, t := range tokens {
+		if t == s {
+			n++
+		}
+	}
+	return n
+}
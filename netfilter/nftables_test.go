@@ -0,0 +1,210 @@
+package netfilter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNFTables(t *testing.T) {
+	input := `table inet filter {
+	set blocked {
+		type ipv4_addr
+		elements = { 192.0.2.9,
+			     192.0.2.10 }
+	}
+
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		ip saddr @blocked drop
+		ip saddr 10.0.0.0/8 tcp dport 22 counter packets 3 bytes 180 accept comment "ssh"
+		ip6 saddr { fd00::/64, fd01::1 } udp dport { 53, 5000-5010 } accept
+		icmpv6 type { echo-request, nd-neighbor-solicit } accept
+		icmp type destination-unreachable icmp code 4 accept
+		meta l4proto tcp tcp dport 8080 reject with tcp reset # handle 12
+		tcp dport != 443 drop
+		iifname "lo" accept
+		jump logging
+		counter
+	}
+
+	chain logging {
+		log prefix "dropped: "
+	}
+
+	chain output {
+		type filter hook output priority filter; policy accept;
+		ip daddr 198.51.100.0/24 drop
+	}
+}
+table ip nat {
+	chain prerouting {
+		type nat hook prerouting priority dstnat; policy accept;
+		tcp dport 80 redirect to :8080
+	}
+}
+table ip6 filter6 {
+	chain forward {
+		type filter hook forward priority filter; policy drop;
+		meta l4proto udp accept
+	}
+}
+`
+	got, err := ParseNFTables(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ParseNFTables returned error %v", err)
+	}
+
+	rules, issues := describeResult(got)
+	wantRules := []string{
+		"12: 100 INGRESS ACCEPT IP4 TCP src=10.0.0.0/8 dport=22",
+		"13: 110 INGRESS ACCEPT IP6 UDP src=fd00::/64 dport=53",
+		"13: 120 INGRESS ACCEPT IP6 UDP src=fd00::/64 dport=5000 dport_end=5010",
+		"13: 130 INGRESS ACCEPT IP6 UDP src=fd01::1 dport=53",
+		"13: 140 INGRESS ACCEPT IP6 UDP src=fd01::1 dport=5000 dport_end=5010",
+		"14: 150 INGRESS ACCEPT ICMP6 type=128",
+		"14: 160 INGRESS ACCEPT ICMP6 type=135",
+		"15: 170 INGRESS ACCEPT ICMP type=3 code=4",
+		"16: 180 INGRESS DROP TCP dport=8080",
+		"29: 190 EGRESS DROP IP4 dst=198.51.100.0/24",
+		"41: 200 INGRESS ACCEPT IP6 UDP",
+		"40: 210 INGRESS DROP IP6",
+		"9: 220 INGRESS DROP",
+		"28: 230 EGRESS ACCEPT",
+	}
+	wantIssues := []string{
+		"10: \"ct\" is not supported",
+		"11: named set @blocked is not supported",
+		"16: reject is translated to drop",
+		"17: operator != is not supported",
+		"18: \"iifname\" is not supported",
+		"19: \"jump\" is not supported",
+		"20: rule has no accept, drop or reject verdict",
+		"24: regular chain logging is not supported",
+		"35: chain type nat is not supported",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("ParseNFTables returned rules\n%v\nwant\n%v", strings.Join(rules, "\n"), strings.Join(wantRules, "\n"))
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("ParseNFTables returned issues\n%v\nwant\n%v", strings.Join(issues, "\n"), strings.Join(wantIssues, "\n"))
+	}
+}
+
+func TestParseNFTables_policyFamilies(t *testing.T) {
+	input := `table ip filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		tcp dport 22 accept
+	}
+}
+table ip6 filter {
+	chain input {
+		type filter hook input priority filter; policy accept;
+	}
+}
+table ip6 other {
+	chain input {
+		type filter hook input priority filter; policy drop;
+	}
+}
+`
+	got, err := ParseNFTables(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ParseNFTables returned error %v", err)
+	}
+
+	rules, issues := describeResult(got)
+	wantRules := []string{
+		"4: 100 INGRESS ACCEPT IP4 TCP dport=22",
+		"3: 110 INGRESS DROP IP4",
+		"14: 120 INGRESS DROP IP6",
+	}
+	wantIssues := []string{
+		"14: chain input also hooks input, like chain input on line 9; a packet must be accepted by both, which is not supported",
+		"14: policy DROP of chain input conflicts with policy ACCEPT of chain input for direction INGRESS and family IP6",
+	}
+	if !reflect.DeepEqual(rules, wantRules) {
+		t.Errorf("ParseNFTables returned rules\n%v\nwant\n%v", strings.Join(rules, "\n"), strings.Join(wantRules, "\n"))
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("ParseNFTables returned issues\n%v\nwant\n%v", strings.Join(issues, "\n"), strings.Join(wantIssues, "\n"))
+	}
+}
+
+func TestParseNFTables_policyConflicts(t *testing.T) {
+	tests := []struct {
+		input  string
+		rules  []string
+		issues []string
+	}{
+		{
+			"table ip filter {\nchain input {\ntype filter hook input priority filter; policy accept;\n}\n}\n" +
+				"table inet filter {\nchain input {\ntype filter hook input priority filter; policy drop;\n}\n}\n",
+			[]string{"8: 100 INGRESS DROP IP4", "8: 110 INGRESS DROP"},
+			[]string{
+				"8: chain input also hooks input, like chain input on line 3; a packet must be accepted by both, which is not supported",
+				"8: policy DROP of chain input conflicts with policy ACCEPT of chain input for direction INGRESS and family IP4",
+			},
+		},
+		{
+			"table inet filter {\nchain input {\ntype filter hook input priority filter; policy drop;\n}\n}\n" +
+				"table ip6 filter {\nchain input {\ntype filter hook input priority filter; policy accept;\n}\n}\n",
+			[]string{"3: 100 INGRESS DROP"},
+			[]string{
+				"8: chain input also hooks input, like chain input on line 3; a packet must be accepted by both, which is not supported",
+				"8: policy ACCEPT of chain input conflicts with policy DROP of chain input for direction INGRESS and family IP6",
+			},
+		},
+		{
+			"table ip filter {\nchain input {\ntype filter hook input priority filter;\n}\n}\n" +
+				"table ip6 filter {\nchain input {\ntype filter hook input priority filter; policy drop;\n}\n}\n",
+			[]string{"8: 100 INGRESS DROP IP6"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNFTables(strings.NewReader(tt.input), nil)
+		if err != nil {
+			t.Fatalf("ParseNFTables returned error %v", err)
+		}
+		rules, issues := describeResult(got)
+		if !reflect.DeepEqual(rules, tt.rules) {
+			t.Errorf("ParseNFTables returned rules\n%v\nwant\n%v", strings.Join(rules, "\n"), strings.Join(tt.rules, "\n"))
+		}
+		if !reflect.DeepEqual(issues, tt.issues) {
+			t.Errorf("ParseNFTables returned issues\n%v\nwant\n%v", strings.Join(issues, "\n"), strings.Join(tt.issues, "\n"))
+		}
+	}
+}
+
+func TestParseNFTables_invalid(t *testing.T) {
+	input := `table inet filter {
+	chain input {
+		type filter hook input priority filter;
+		ip saddr 10.0.0.0/8 ip6 daddr fd00::1 accept
+		tcp dport 70000 accept
+		udp sport 53 icmp type echo-request accept
+	}
+}
+`
+	got, err := ParseNFTables(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ParseNFTables returned error %v", err)
+	}
+	if len(got.Rules) != 0 {
+		t.Errorf("ParseNFTables returned rules %v, want none", got.Rules)
+	}
+
+	lines := []int{4, 5, 6}
+	if len(got.Issues) != len(lines) {
+		t.Fatalf("ParseNFTables returned issues %v, want one for each of lines %v", got.Issues, lines)
+	}
+	for i, issue := range got.Issues {
+		if issue.Line != lines[i] || issue.Approximated {
+			t.Errorf("Issue %d is %v, want a skipped line %d", i, issue, lines[i])
+		}
+	}
+}
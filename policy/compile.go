@@ -0,0 +1,225 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Options specifies the optional parameters to Compile.
+type Options struct {
+	// FirstPriority is the priority of the first rule of each network.
+	// It defaults to 100.
+	FirstPriority int
+
+	// PriorityStep is the difference between the priorities of
+	// consecutive rules. It defaults to 10.
+	PriorityStep int
+}
+
+// Rule is a compiled firewall rule with the policy statement it came
+// from.
+type Rule struct {
+	Request enf.FirewallRuleRequest
+
+	// Flow is the flow that produced the rule, or nil if the rule
+	// implements the default stance.
+	Flow *Flow
+
+	// FlowIndex is the index of Flow in Policy.Flows, or -1.
+	FlowIndex int
+
+	// Reason explains why the rule exists.
+	Reason string
+}
+
+// NetworkRules are the compiled rules of a network, in priority order.
+type NetworkRules struct {
+	Network enf.NetworkAddr
+	Rules   []*Rule
+}
+
+// Requests returns the requests of the rules, such as for
+// enf.FirewallService.SyncRules.
+func (n *NetworkRules) Requests() []enf.FirewallRuleRequest {
+	requests := make([]enf.FirewallRuleRequest, len(n.Rules))
+	for i, r := range n.Rules {
+		requests[i] = r.Request
+	}
+	return requests
+}
+
+// Compiled is a compiled policy.
+type Compiled struct {
+	// Networks are the rules of every network with a member in a
+	// group, sorted by network address.
+	Networks []*NetworkRules
+}
+
+// Network returns the rules of the given network, or nil if the policy
+// has no members in it.
+func (c *Compiled) Network(network enf.NetworkAddr) *NetworkRules {
+	for _, n := range c.Networks {
+		if n.Network == network {
+			return n
+		}
+	}
+	return nil
+}
+
+// Compile compiles the policy into the firewall rules of each network
+// with a member in one of its groups. For every flow and every pair of
+// members of its groups, the network of the source member gets an
+// EGRESS rule and the network of the destination member an INGRESS
+// rule. A rule that another statement already produced for the same
+// network is only kept once, with the earlier statement as its reason.
+// Each network then gets a catch-all INGRESS and EGRESS rule for the
+// default stance.
+func Compile(p *Policy, opts *Options) (*Compiled, error) {
+	groups, err := p.validate()
+	if err != nil {
+		return nil, err
+	}
+	first, step := 100, 10
+	if opts != nil && opts.FirstPriority != 0 {
+		first = opts.FirstPriority
+	}
+	if opts != nil && opts.PriorityStep != 0 {
+		step = opts.PriorityStep
+	}
+
+	c := &compiler{networks: make(map[enf.NetworkAddr]*network)}
+	for _, g := range p.Groups {
+		for _, m := range g.members() {
+			c.network(m.network)
+		}
+	}
+
+	for i, f := range p.Flows {
+		for _, src := range groups[f.From].members() {
+			for _, dst := range groups[f.To].members() {
+				if src.addr == dst.addr {
+					continue
+				}
+				for _, direction := range []enf.FirewallDirection{enf.DirectionEgress, enf.DirectionIngress} {
+					r := flowRule(f, i, src, dst, direction)
+					if err := r.Request.Validate(); err != nil {
+						return nil, fmt.Errorf("Flow %d: %w", i, err)
+					}
+					on := dst.network
+					if direction == enf.DirectionEgress {
+						on = src.network
+					}
+					c.network(on).add(r)
+				}
+			}
+		}
+	}
+
+	action := enf.ActionDrop
+	if p.Default == StanceAllow {
+		action = enf.ActionAccept
+	}
+	stance := p.Default
+	if stance == "" {
+		stance = StanceDeny
+	}
+	for _, n := range c.networks {
+		for _, direction := range []enf.FirewallDirection{enf.DirectionIngress, enf.DirectionEgress} {
+			n.add(&Rule{
+				Request: enf.FirewallRuleRequest{
+					Priority:  enf.Int(0),
+					Action:    action.Ptr(),
+					Direction: direction.Ptr(),
+					IPFamily:  enf.IPFamily6.Ptr(),
+				},
+				FlowIndex: -1,
+				Reason: fmt.Sprintf("default stance %v: %v traffic of %v that no flow matches",
+					stance, strings.ToLower(direction.String()), n.rules.Network),
+			})
+		}
+	}
+
+	compiled := &Compiled{}
+	for _, n := range c.networks {
+		for i, r := range n.rules.Rules {
+			r.Request.Priority = enf.Int(first + i*step)
+		}
+		compiled.Networks = append(compiled.Networks, n.rules)
+	}
+	sort.Slice(compiled.Networks, func(i, j int) bool {
+		a, b := compiled.Networks[i].Network.Prefix(), compiled.Networks[j].Network.Prefix()
+		return a.Addr().Less(b.Addr())
+	})
+	return compiled, nil
+}
+
+// flowRule returns the rule for one direction of a flow between two
+// members.
+func flowRule(f *Flow, index int, src, dst member, direction enf.FirewallDirection) *Rule {
+	r := enf.FirewallRuleRequest{
+		Priority:  enf.Int(0),
+		Action:    f.action().Ptr(),
+		Direction: direction.Ptr(),
+		IPFamily:  enf.IPFamily6.Ptr(),
+		SourceIP:  enf.String(src.addr),
+		DestIP:    enf.String(dst.addr),
+		ICMPType:  f.ICMPType,
+		ICMPCode:  f.ICMPCode,
+	}
+	if f.Protocol != "" {
+		r.Protocol = f.Protocol.Ptr()
+	}
+	if f.Port != 0 {
+		r.DestPort = enf.Int(f.Port)
+	}
+	if f.PortEnd != 0 {
+		r.DestPortEnd = enf.Int(f.PortEnd)
+	}
+
+	name := ""
+	if f.Name != "" {
+		name = fmt.Sprintf(" %q", f.Name)
+	}
+	return &Rule{
+		Request:   r,
+		Flow:      f,
+		FlowIndex: index,
+		Reason: fmt.Sprintf("flow %d%v (%v): %v from %v (%v) to %v (%v)",
+			index, name, f, strings.ToLower(direction.String()), src.addr, f.From, dst.addr, f.To),
+	}
+}
+
+// compiler collects the rules of each network.
+type compiler struct {
+	networks map[enf.NetworkAddr]*network
+}
+
+func (c *compiler) network(addr enf.NetworkAddr) *network {
+	n := c.networks[addr]
+	if n == nil {
+		n = &network{rules: &NetworkRules{Network: addr}, seen: make(map[string]bool)}
+		c.networks[addr] = n
+	}
+	return n
+}
+
+// network is the rules of a network being compiled.
+type network struct {
+	rules *NetworkRules
+
+	// seen holds the requests already added, without their priority.
+	seen map[string]bool
+}
+
+func (n *network) add(r *Rule) {
+	data, _ := json.Marshal(r.Request)
+	if n.seen[string(data)] {
+		return
+	}
+	n.seen[string(data)] = true
+	n.rules.Rules = append(n.rules.Rules, r)
+}
@@ -0,0 +1,172 @@
+// Package policy describes network segmentation in terms of groups of
+// networks and endpoints and the flows allowed between them, and
+// compiles it into the firewall rules of each network.
+//
+// A flow from group A to group B needs two rules: an EGRESS rule on the
+// network of every member of A, and an INGRESS rule on the network of
+// every member of B. Traffic that no flow matches gets the policy's
+// default stance, compiled into catch-all rules after the flows. Flows
+// are compiled in order, so an earlier flow takes precedence over a
+// later one that matches the same traffic.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+var (
+	ErrMissingGroupName = errors.New("Missing required group name")
+	ErrDuplicateGroup   = errors.New("Duplicate group")
+	ErrEmptyGroup       = errors.New("Group has no networks or endpoints")
+	ErrUnknownGroup     = errors.New("Unknown group")
+	ErrUnknownStance    = errors.New("Unknown default stance")
+)
+
+// Stance is what happens to traffic that no flow matches.
+type Stance string
+
+// The default stances.
+const (
+	StanceDeny  Stance = "deny"
+	StanceAllow Stance = "allow"
+)
+
+// Policy is a segmentation policy.
+type Policy struct {
+	Groups []*Group `json:"groups" yaml:"groups"`
+	Flows  []*Flow  `json:"flows" yaml:"flows"`
+
+	// Default is the stance for traffic that no flow matches. It
+	// defaults to StanceDeny.
+	Default Stance `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Group is a named set of networks and endpoints.
+type Group struct {
+	Name      string             `json:"name" yaml:"name"`
+	Networks  []enf.NetworkAddr  `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Endpoints []enf.EndpointAddr `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+}
+
+// Flow is traffic from the members of one group to the members of
+// another.
+type Flow struct {
+	// Name is an optional label used in explanations.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+
+	// Protocol is the protocol of the flow. If empty, the flow covers
+	// every protocol.
+	Protocol enf.FirewallProtocol `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+
+	// Port and PortEnd are the destination port or port range of a TCP
+	// or UDP flow. If Port is 0, the flow covers every port.
+	Port    int `json:"port,omitempty" yaml:"port,omitempty"`
+	PortEnd int `json:"port_end,omitempty" yaml:"port_end,omitempty"`
+
+	// ICMPType and ICMPCode restrict an ICMP6 flow to a message type.
+	ICMPType *int `json:"icmp_type,omitempty" yaml:"icmp_type,omitempty"`
+	ICMPCode *int `json:"icmp_code,omitempty" yaml:"icmp_code,omitempty"`
+
+	// Action is ACCEPT for allowed flows, the default, or DROP for
+	// denied ones, which can carve exceptions out of later flows or an
+	// allow stance.
+	Action enf.FirewallAction `json:"action,omitempty" yaml:"action,omitempty"`
+}
+
+func (f *Flow) action() enf.FirewallAction {
+	if f.Action == "" {
+		return enf.ActionAccept
+	}
+	return f.Action
+}
+
+// String describes the flow, such as "app -> db TCP 5432".
+func (f *Flow) String() string {
+	parts := []string{f.From, "->", f.To}
+	if f.Protocol != "" {
+		parts = append(parts, f.Protocol.String())
+	}
+	switch {
+	case f.Port != 0 && f.PortEnd != 0:
+		parts = append(parts, fmt.Sprintf("%d-%d", f.Port, f.PortEnd))
+	case f.Port != 0:
+		parts = append(parts, fmt.Sprint(f.Port))
+	}
+	if f.ICMPType != nil {
+		typ := fmt.Sprintf("type %d", *f.ICMPType)
+		if f.ICMPCode != nil {
+			typ += fmt.Sprintf(" code %d", *f.ICMPCode)
+		}
+		parts = append(parts, typ)
+	}
+	if f.action() != enf.ActionAccept {
+		parts = append(parts, f.action().String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// members returns the prefixes of the members of the group, with the
+// network each belongs to.
+func (g *Group) members() []member {
+	var members []member
+	for _, n := range g.Networks {
+		members = append(members, member{n.String(), n})
+	}
+	for _, e := range g.Endpoints {
+		members = append(members, member{e.String(), e.Network()})
+	}
+	return members
+}
+
+// member is a network or endpoint of a group.
+type member struct {
+	addr    string
+	network enf.NetworkAddr
+}
+
+// validate checks that the groups are well-formed and that every flow
+// refers to known groups.
+func (p *Policy) validate() (map[string]*Group, error) {
+	groups := make(map[string]*Group)
+	for i, g := range p.Groups {
+		switch {
+		case g == nil || g.Name == "":
+			return nil, fmt.Errorf("Group %d: %w", i, ErrMissingGroupName)
+		case groups[g.Name] != nil:
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateGroup, g.Name)
+		case len(g.Networks) == 0 && len(g.Endpoints) == 0:
+			return nil, fmt.Errorf("%w: %q", ErrEmptyGroup, g.Name)
+		}
+		for _, m := range g.members() {
+			if m.addr == "" {
+				return nil, fmt.Errorf("Group %q: %w: missing address", g.Name, enf.ErrValidation)
+			}
+		}
+		groups[g.Name] = g
+	}
+
+	for i, f := range p.Flows {
+		if f == nil {
+			return nil, fmt.Errorf("Flow %d: %w", i, enf.ErrValidation)
+		}
+		for _, name := range []string{f.From, f.To} {
+			if groups[name] == nil {
+				return nil, fmt.Errorf("Flow %d: %w: %q", i, ErrUnknownGroup, name)
+			}
+		}
+	}
+
+	switch p.Default {
+	case "", StanceDeny, StanceAllow:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStance, p.Default)
+	}
+	return groups, nil
+}
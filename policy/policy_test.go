@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+	"github.com/xaptum/go-enf/fwsim"
+)
+
+var (
+	appNet = enf.MustParseNetworkAddr("fd00:8f80:8000:1::/64")
+	dbNet  = enf.MustParseNetworkAddr("fd00:8f80:8000:2::/64")
+	db     = enf.MustParseEndpointAddr("fd00:8f80:8000:2::5")
+	admin  = enf.MustParseEndpointAddr("fd00:8f80:8000:2::9")
+)
+
+func testPolicy() *Policy {
+	return &Policy{
+		Groups: []*Group{
+			{Name: "app", Networks: []enf.NetworkAddr{appNet}},
+			{Name: "db", Endpoints: []enf.EndpointAddr{db}},
+			{Name: "admin", Endpoints: []enf.EndpointAddr{admin}},
+		},
+		Flows: []*Flow{
+			{Name: "postgres", From: "app", To: "db", Protocol: enf.ProtocolTCP, Port: 5432},
+			{From: "admin", To: "db", Protocol: enf.ProtocolICMP6, ICMPType: enf.Int(128)},
+			{From: "admin", To: "app", Protocol: enf.ProtocolTCP, Port: 22, Action: enf.ActionDrop},
+		},
+	}
+}
+
+// describe summarizes a compiled rule on one line.
+func describe(r *Rule) string {
+	req := r.Request
+	s := fmt.Sprintf("%d %v %v", *req.Priority, *req.Direction, *req.Action)
+	if req.SourceIP != nil {
+		s += fmt.Sprintf(" %v -> %v", *req.SourceIP, *req.DestIP)
+	}
+	if req.Protocol != nil {
+		s += " " + req.Protocol.String()
+	}
+	if req.DestPort != nil {
+		s += fmt.Sprintf(":%d", *req.DestPort)
+	}
+	if req.ICMPType != nil {
+		s += fmt.Sprintf(" type %d", *req.ICMPType)
+	}
+	return fmt.Sprintf("%v [flow %d]", s, r.FlowIndex)
+}
+
+func TestCompile(t *testing.T) {
+	compiled, err := Compile(testPolicy(), nil)
+	if err != nil {
+		t.Fatalf("Compile returned error %v", err)
+	}
+	if len(compiled.Networks) != 2 || compiled.Networks[0].Network != appNet || compiled.Networks[1].Network != dbNet {
+		t.Fatalf("Compile returned networks %+v, want %v and %v", compiled.Networks, appNet, dbNet)
+	}
+
+	want := map[enf.NetworkAddr][]string{
+		appNet: {
+			"100 EGRESS ACCEPT fd00:8f80:8000:1::/64 -> fd00:8f80:8000:2::5 TCP:5432 [flow 0]",
+			"110 INGRESS DROP fd00:8f80:8000:2::9 -> fd00:8f80:8000:1::/64 TCP:22 [flow 2]",
+			"120 INGRESS DROP [flow -1]",
+			"130 EGRESS DROP [flow -1]",
+		},
+		dbNet: {
+			"100 INGRESS ACCEPT fd00:8f80:8000:1::/64 -> fd00:8f80:8000:2::5 TCP:5432 [flow 0]",
+			"110 EGRESS ACCEPT fd00:8f80:8000:2::9 -> fd00:8f80:8000:2::5 ICMP6 type 128 [flow 1]",
+			"120 INGRESS ACCEPT fd00:8f80:8000:2::9 -> fd00:8f80:8000:2::5 ICMP6 type 128 [flow 1]",
+			"130 EGRESS DROP fd00:8f80:8000:2::9 -> fd00:8f80:8000:1::/64 TCP:22 [flow 2]",
+			"140 INGRESS DROP [flow -1]",
+			"150 EGRESS DROP [flow -1]",
+		},
+	}
+	for network, rules := range want {
+		var got []string
+		for _, r := range compiled.Network(network).Rules {
+			got = append(got, describe(r))
+		}
+		if !reflect.DeepEqual(got, rules) {
+			t.Errorf("Compile returned rules for %v\n%v\nwant\n%v", network, strings.Join(got, "\n"), strings.Join(rules, "\n"))
+		}
+	}
+
+	r := compiled.Network(dbNet).Rules[0]
+	wantReason := `flow 0 "postgres" (app -> db TCP 5432): ingress from fd00:8f80:8000:1::/64 (app) to fd00:8f80:8000:2::5 (db)`
+	if r.Reason != wantReason || r.Flow == nil || r.Flow.Name != "postgres" {
+		t.Errorf("Rule has reason %q, want %q", r.Reason, wantReason)
+	}
+	r = compiled.Network(dbNet).Rules[5]
+	if want := "default stance deny: egress traffic of fd00:8f80:8000:2::/64 that no flow matches"; r.Reason != want || r.Flow != nil {
+		t.Errorf("Rule has reason %q, want %q", r.Reason, want)
+	}
+
+	if compiled.Network(enf.MustParseNetworkAddr("fd00:8f80:8000:3::/64")) != nil {
+		t.Errorf("Network returned rules for a network without members")
+	}
+}
+
+// TestCompile_simulate checks that the compiled rules of both networks
+// allow exactly the flows of the policy.
+func TestCompile_simulate(t *testing.T) {
+	p := testPolicy()
+	p.Default = StanceAllow
+	compiled, err := Compile(p, &Options{FirstPriority: 1, PriorityStep: 1})
+	if err != nil {
+		t.Fatalf("Compile returned error %v", err)
+	}
+
+	simulators := make(map[enf.NetworkAddr]*fwsim.Simulator)
+	for _, n := range compiled.Networks {
+		var rules []*enf.FirewallRule
+		for _, r := range n.Rules {
+			req := r.Request
+			rules = append(rules, &enf.FirewallRule{
+				Priority: req.Priority, Action: req.Action, Direction: req.Direction,
+				IPFamily: req.IPFamily, Protocol: req.Protocol,
+				SourceIP: req.SourceIP, DestIP: req.DestIP, DestPort: req.DestPort,
+				ICMPType: req.ICMPType, ICMPCode: req.ICMPCode,
+			})
+		}
+		simulators[n.Network] = fwsim.New(rules, nil)
+	}
+
+	tests := []struct {
+		src, dst string
+		port     int
+		want     enf.FirewallAction
+	}{
+		{"fd00:8f80:8000:1::7", "fd00:8f80:8000:2::5", 5432, enf.ActionAccept},
+		{"fd00:8f80:8000:2::9", "fd00:8f80:8000:1::7", 22, enf.ActionDrop},
+		{"fd00:8f80:8000:2::9", "fd00:8f80:8000:1::7", 443, enf.ActionAccept},
+	}
+	for _, tt := range tests {
+		src, dst := netip.MustParseAddr(tt.src), netip.MustParseAddr(tt.dst)
+		egress := fwsim.Packet{Protocol: enf.ProtocolTCP, Direction: enf.DirectionEgress, Source: src, Dest: dst, DestPort: tt.port}
+		ingress := egress
+		ingress.Direction = enf.DirectionIngress
+
+		from, _ := enf.EndpointAddrFrom(src)
+		to, _ := enf.EndpointAddrFrom(dst)
+		for _, check := range []struct {
+			network enf.NetworkAddr
+			packet  fwsim.Packet
+		}{{from.Network(), egress}, {to.Network(), ingress}} {
+			res, err := simulators[check.network].Evaluate(check.packet)
+			if err != nil {
+				t.Fatalf("Evaluate returned error %v", err)
+			}
+			if res.Action != tt.want {
+				t.Errorf("%v -> %v:%d on %v returned %v, want %v", src, dst, tt.port, check.network, res.Action, tt.want)
+			}
+		}
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *Policy)
+		want   error
+	}{
+		{"missing name", func(p *Policy) { p.Groups[0].Name = "" }, ErrMissingGroupName},
+		{"duplicate group", func(p *Policy) { p.Groups[1].Name = "app" }, ErrDuplicateGroup},
+		{"empty group", func(p *Policy) { p.Groups[2].Endpoints = nil }, ErrEmptyGroup},
+		{"zero address", func(p *Policy) { p.Groups[2].Endpoints[0] = enf.EndpointAddr{} }, enf.ErrValidation},
+		{"unknown group", func(p *Policy) { p.Flows[1].To = "web" }, ErrUnknownGroup},
+		{"unknown stance", func(p *Policy) { p.Default = "maybe" }, ErrUnknownStance},
+		{"invalid flow", func(p *Policy) { p.Flows[1].Port = 80 }, enf.ErrValidation},
+	}
+	for _, tt := range tests {
+		p := testPolicy()
+		tt.modify(p)
+		if _, err := Compile(p, nil); !errors.Is(err, tt.want) {
+			t.Errorf("[%v] Compile returned error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package reachability
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// WriteCSV writes the matrix as CSV. The first row and the first column
+// hold the network addresses, and each cell lists the services the
+// network of its row can send to the network of its column, separated
+// by semicolons.
+func (m *Matrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"from \\ to"}
+	for _, n := range m.Networks {
+		header = append(header, *n.Network)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for i, n := range m.Networks {
+		record := []string{*n.Network}
+		for j := range m.Networks {
+			record = append(record, joinServices(m.Services[i][j], "; "))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDOT writes the matrix as a Graphviz digraph with a node for each
+// network and an edge for each pair of networks that can reach each
+// other, labeled with the services. Edges with partially allowed
+// services are dashed.
+func (m *Matrix) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph reachability {")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+	for _, n := range m.Networks {
+		fmt.Fprintf(bw, "\t%v [label=%v];\n", strconv.Quote(*n.Network), strconv.Quote(label(n)))
+	}
+	for i, from := range m.Networks {
+		for j, to := range m.Networks {
+			services := m.Services[i][j]
+			if len(services) == 0 {
+				continue
+			}
+			attrs := "label=" + strconv.Quote(joinServices(services, "\n"))
+			if partial(services) {
+				attrs += ", style=dashed"
+			}
+			fmt.Fprintf(bw, "\t%v -> %v [%v];\n", strconv.Quote(*from.Network), strconv.Quote(*to.Network), attrs)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func joinServices(services []Service, sep string) string {
+	strs := make([]string, len(services))
+	for i, s := range services {
+		strs[i] = s.String()
+	}
+	return strings.Join(strs, sep)
+}
+
+func partial(services []Service) bool {
+	for _, s := range services {
+		if s.Partial {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the label of a network node: its name, if it has one,
+// and its address.
+func label(n *enf.Network) string {
+	if n.Name == nil || *n.Name == "" {
+		return *n.Network
+	}
+	return *n.Name + "\n" + *n.Network
+}
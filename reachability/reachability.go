@@ -0,0 +1,407 @@
+// Package reachability computes which networks of a domain can reach
+// which, and on what ports, from their firewall rules.
+//
+// Traffic from network A to network B must pass the EGRESS rules of A
+// and the INGRESS rules of B. Rules are evaluated as in package fwsim:
+// by priority, first match wins, with a default action for traffic no
+// rule matches. The rules of both networks split the addresses, ports
+// and ICMP types into ranges that every rule treats alike, and one
+// packet from each range is evaluated, so the result is exact without
+// enumerating every packet.
+package reachability
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/xaptum/go-enf/enf"
+	"github.com/xaptum/go-enf/fwsim"
+)
+
+// Options specifies the optional parameters to Build and Compute.
+type Options struct {
+	// DefaultAction is the action for traffic that matches no rule. If
+	// empty, fwsim.DefaultAction is used.
+	DefaultAction enf.FirewallAction
+}
+
+// Service is a range of traffic one network can send to another.
+type Service struct {
+	// Protocol is enf.ProtocolTCP, enf.ProtocolUDP or
+	// enf.ProtocolICMP6.
+	Protocol enf.FirewallProtocol
+
+	// First and Last are the range of destination ports of TCP and UDP
+	// services, or of message types of ICMP6 services.
+	First int
+	Last  int
+
+	// Partial reports whether only some of the traffic in the range is
+	// allowed, such as from some endpoints of the source network only.
+	Partial bool
+}
+
+// String describes the service, such as "TCP 443" or "UDP any".
+func (s Service) String() string {
+	var str string
+	switch {
+	case s.Protocol == enf.ProtocolICMP6 && s.First == 0 && s.Last == 255:
+		str = "ICMP6 any"
+	case s.Protocol == enf.ProtocolICMP6 && s.First == s.Last:
+		str = fmt.Sprintf("ICMP6 type %d", s.First)
+	case s.Protocol == enf.ProtocolICMP6:
+		str = fmt.Sprintf("ICMP6 type %d-%d", s.First, s.Last)
+	case s.First == 1 && s.Last == 65535:
+		str = fmt.Sprintf("%v any", s.Protocol)
+	case s.First == s.Last:
+		str = fmt.Sprintf("%v %d", s.Protocol, s.First)
+	default:
+		str = fmt.Sprintf("%v %d-%d", s.Protocol, s.First, s.Last)
+	}
+	if s.Partial {
+		str += " (partial)"
+	}
+	return str
+}
+
+// Matrix is the reachability between the networks of a domain.
+type Matrix struct {
+	// Networks are the networks, sorted by address.
+	Networks []*enf.Network
+
+	// Services[i][j] is the traffic Networks[i] can send to
+	// Networks[j], sorted by protocol and port. It is empty if
+	// Networks[i] cannot reach Networks[j].
+	Services [][][]Service
+}
+
+// Allowed returns the traffic the from network can send to the to
+// network, given by address.
+func (m *Matrix) Allowed(from, to enf.NetworkAddr) []Service {
+	i, j := m.index(from), m.index(to)
+	if i < 0 || j < 0 {
+		return nil
+	}
+	return m.Services[i][j]
+}
+
+func (m *Matrix) index(addr enf.NetworkAddr) int {
+	for i, n := range m.Networks {
+		if a, err := enf.ParseNetworkAddr(*n.Network); err == nil && a == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+// Build lists the networks of the domain and the firewall rules of each
+// network, and computes their reachability.
+func Build(ctx context.Context, client *enf.Client, domain string, opts *Options) (*Matrix, error) {
+	networks, _, err := client.Network.ListNetworks(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[enf.NetworkAddr][]*enf.FirewallRule)
+	for _, n := range networks {
+		addr, err := networkAddr(n)
+		if err != nil {
+			return nil, err
+		}
+		if rules[addr], _, err = client.Firewall.ListRulesByAddr(ctx, addr); err != nil {
+			return nil, fmt.Errorf("Network %v: %w", addr, err)
+		}
+	}
+	return Compute(networks, rules, opts)
+}
+
+// Compute computes the reachability between the networks from the
+// firewall rules of each network. A network without rules only has the
+// default action.
+func Compute(networks []*enf.Network, rules map[enf.NetworkAddr][]*enf.FirewallRule, opts *Options) (*Matrix, error) {
+	var simOpts *fwsim.Options
+	if opts != nil {
+		simOpts = &fwsim.Options{DefaultAction: opts.DefaultAction}
+	}
+
+	type node struct {
+		network *enf.Network
+		prefix  netip.Prefix
+		egress  []*fwsim.Rule
+		ingress []*fwsim.Rule
+		sim     *fwsim.Simulator
+	}
+	nodes := make([]*node, 0, len(networks))
+	for _, n := range networks {
+		addr, err := networkAddr(n)
+		if err != nil {
+			return nil, err
+		}
+		nd := &node{network: n, prefix: addr.Prefix()}
+		for _, r := range rules[addr] {
+			c, err := fwsim.Compile(r)
+			if err != nil {
+				// The simulator never matches it either.
+				continue
+			}
+			switch c.Direction {
+			case enf.DirectionEgress:
+				nd.egress = append(nd.egress, c)
+			case enf.DirectionIngress:
+				nd.ingress = append(nd.ingress, c)
+			default:
+				nd.egress = append(nd.egress, c)
+				nd.ingress = append(nd.ingress, c)
+			}
+		}
+		nd.sim = fwsim.New(rules[addr], simOpts)
+		nodes = append(nodes, nd)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].prefix.Addr().Less(nodes[j].prefix.Addr())
+	})
+
+	m := &Matrix{
+		Networks: make([]*enf.Network, len(nodes)),
+		Services: make([][][]Service, len(nodes)),
+	}
+	for i, from := range nodes {
+		m.Networks[i] = from.network
+		m.Services[i] = make([][]Service, len(nodes))
+		for j, to := range nodes {
+			relevant := append(append([]*fwsim.Rule(nil), from.egress...), to.ingress...)
+			p := &pair{
+				egress:  from.sim,
+				ingress: to.sim,
+				sources: points(from.prefix, relevant, func(r *fwsim.Rule) netip.Prefix { return r.SourceIP }),
+				dests:   points(to.prefix, relevant, func(r *fwsim.Rule) netip.Prefix { return r.DestIP }),
+				rules:   relevant,
+			}
+			services, err := p.services()
+			if err != nil {
+				return nil, err
+			}
+			m.Services[i][j] = services
+		}
+	}
+	return m, nil
+}
+
+func networkAddr(n *enf.Network) (enf.NetworkAddr, error) {
+	if n == nil || n.Network == nil {
+		return enf.NetworkAddr{}, enf.ErrMissingNetwork
+	}
+	return enf.ParseNetworkAddr(*n.Network)
+}
+
+// pair evaluates the traffic from one network to another.
+type pair struct {
+	egress, ingress *fwsim.Simulator
+	sources, dests  []netip.Addr
+	rules           []*fwsim.Rule
+}
+
+// services returns the traffic allowed from the source network to the
+// destination network.
+func (p *pair) services() ([]Service, error) {
+	sports := starts(portIntervals(p.rules, func(r *fwsim.Rule) fwsim.PortRange { return r.SourcePorts }))
+	dports := portIntervals(p.rules, func(r *fwsim.Rule) fwsim.PortRange { return r.DestPorts })
+	types, codes := icmpIntervals(p.rules)
+
+	var services []Service
+	for _, protocol := range []enf.FirewallProtocol{enf.ProtocolTCP, enf.ProtocolUDP} {
+		for _, iv := range dports {
+			packet := fwsim.Packet{Protocol: protocol, DestPort: iv.first}
+			allowed, total, err := p.count(packet, sports, func(pk *fwsim.Packet, v int) { pk.SourcePort = v })
+			if err != nil {
+				return nil, err
+			}
+			services = appendService(services, protocol, iv, allowed, total)
+		}
+	}
+	for _, iv := range types {
+		packet := fwsim.Packet{Protocol: enf.ProtocolICMP6, ICMPType: iv.first}
+		allowed, total, err := p.count(packet, codes, func(pk *fwsim.Packet, v int) { pk.ICMPCode = v })
+		if err != nil {
+			return nil, err
+		}
+		services = appendService(services, enf.ProtocolICMP6, iv, allowed, total)
+	}
+	return services, nil
+}
+
+// count evaluates the packet from every source to every destination,
+// once for each of the values set by the given function, and returns
+// how many were allowed through both networks.
+func (p *pair) count(packet fwsim.Packet, values []int, set func(*fwsim.Packet, int)) (allowed, total int, err error) {
+	for _, src := range p.sources {
+		for _, dst := range p.dests {
+			for _, v := range values {
+				pk := packet
+				pk.Source, pk.Dest = src, dst
+				set(&pk, v)
+
+				ok := true
+				for _, check := range []struct {
+					sim       *fwsim.Simulator
+					direction enf.FirewallDirection
+				}{{p.egress, enf.DirectionEgress}, {p.ingress, enf.DirectionIngress}} {
+					pk.Direction = check.direction
+					res, err := check.sim.Evaluate(pk)
+					if err != nil {
+						return 0, 0, err
+					}
+					ok = ok && res.Action == enf.ActionAccept
+				}
+				if ok {
+					allowed++
+				}
+				total++
+			}
+		}
+	}
+	return allowed, total, nil
+}
+
+// appendService adds the interval to the services, merging it with the
+// last service if they are adjacent and alike.
+func appendService(services []Service, protocol enf.FirewallProtocol, iv interval, allowed, total int) []Service {
+	if allowed == 0 {
+		return services
+	}
+	s := Service{Protocol: protocol, First: iv.first, Last: iv.last, Partial: allowed < total}
+	if n := len(services); n > 0 {
+		last := &services[n-1]
+		if last.Protocol == s.Protocol && last.Partial == s.Partial && last.Last+1 == s.First {
+			last.Last = s.Last
+			return services
+		}
+	}
+	return append(services, s)
+}
+
+// interval is an inclusive range of ports or ICMP types.
+type interval struct {
+	first, last int
+}
+
+// split splits [min, max] at the given boundaries, each the first value
+// of a new interval.
+func split(min, max int, boundaries []int) []interval {
+	sort.Ints(boundaries)
+	var intervals []interval
+	first := min
+	for _, b := range boundaries {
+		if b > first && b <= max {
+			intervals = append(intervals, interval{first, b - 1})
+			first = b
+		}
+	}
+	return append(intervals, interval{first, max})
+}
+
+func starts(intervals []interval) []int {
+	values := make([]int, len(intervals))
+	for i, iv := range intervals {
+		values[i] = iv.first
+	}
+	return values
+}
+
+// portIntervals splits the ports into the ranges the rules treat alike.
+func portIntervals(rules []*fwsim.Rule, ports func(*fwsim.Rule) fwsim.PortRange) []interval {
+	var boundaries []int
+	for _, r := range rules {
+		if pr := ports(r); !pr.Any() {
+			boundaries = append(boundaries, pr.First, pr.Last+1)
+		}
+	}
+	return split(1, 65535, boundaries)
+}
+
+// icmpIntervals splits the ICMP types into the ranges the rules treat
+// alike, and returns the codes that need to be evaluated for each: the
+// codes of the rules and one other.
+func icmpIntervals(rules []*fwsim.Rule) ([]interval, []int) {
+	var boundaries []int
+	seen := make(map[int]bool)
+	var codes []int
+	for _, r := range rules {
+		if r.ICMPType != nil {
+			boundaries = append(boundaries, *r.ICMPType, *r.ICMPType+1)
+		}
+		if r.ICMPCode != nil && !seen[*r.ICMPCode] {
+			seen[*r.ICMPCode] = true
+			codes = append(codes, *r.ICMPCode)
+		}
+	}
+	for c := 0; c <= 255; c++ {
+		if !seen[c] {
+			codes = append(codes, c)
+			break
+		}
+	}
+	return split(0, 255, boundaries), codes
+}
+
+// points returns one address of the network from each part of it that
+// the rules treat alike. Rule prefixes either contain each other or are
+// disjoint, so each part is a prefix without the smaller prefixes in it.
+func points(network netip.Prefix, rules []*fwsim.Rule, prefix func(*fwsim.Rule) netip.Prefix) []netip.Addr {
+	prefixes := []netip.Prefix{network}
+	for _, r := range rules {
+		p := prefix(r)
+		if p.IsValid() && p.Bits() > network.Bits() && network.Contains(p.Addr()) {
+			prefixes = append(prefixes, p)
+		}
+	}
+
+	var addrs []netip.Addr
+	seen := make(map[netip.Addr]bool)
+	for _, q := range prefixes {
+		var inner []netip.Prefix
+		for _, p := range prefixes {
+			if p.Bits() > q.Bits() && q.Contains(p.Addr()) {
+				inner = append(inner, p)
+			}
+		}
+		if a, ok := pick(q, inner); ok && !seen[a] {
+			seen[a] = true
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// pick returns an address of the prefix that is in none of the excluded
+// prefixes, if there is one.
+func pick(p netip.Prefix, exclude []netip.Prefix) (netip.Addr, bool) {
+	var overlapping []netip.Prefix
+	for _, e := range exclude {
+		if !e.Overlaps(p) {
+			continue
+		}
+		if e.Bits() <= p.Bits() {
+			return netip.Addr{}, false
+		}
+		overlapping = append(overlapping, e)
+	}
+	if len(overlapping) == 0 {
+		return p.Addr(), true
+	}
+
+	// Try both halves of the prefix.
+	bits := p.Bits() + 1
+	lo := netip.PrefixFrom(p.Addr(), bits)
+	b := p.Addr().AsSlice()
+	b[p.Bits()/8] |= 0x80 >> (p.Bits() % 8)
+	hiAddr, _ := netip.AddrFromSlice(b)
+	hi := netip.PrefixFrom(hiAddr, bits)
+
+	if a, ok := pick(lo, overlapping); ok {
+		return a, true
+	}
+	return pick(hi, overlapping)
+}
@@ -0,0 +1,186 @@
+package reachability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+var (
+	appNet = enf.MustParseNetworkAddr("fd00:8f80:8000:1::/64")
+	dbNet  = enf.MustParseNetworkAddr("fd00:8f80:8000:2::/64")
+	opsNet = enf.MustParseNetworkAddr("fd00:8f80:8000:3::/64")
+)
+
+func testNetworks() []*enf.Network {
+	return []*enf.Network{
+		{Name: enf.String("ops"), Network: enf.String(opsNet.String())},
+		{Name: enf.String("app"), Network: enf.String(appNet.String())},
+		{Name: enf.String("db"), Network: enf.String(dbNet.String())},
+	}
+}
+
+func testRules() map[enf.NetworkAddr][]*enf.FirewallRule {
+	rule := func(priority int, direction enf.FirewallDirection, protocol enf.FirewallProtocol, src, dst string) *enf.FirewallRule {
+		r := &enf.FirewallRule{
+			Priority:  enf.Int(priority),
+			Action:    enf.ActionAccept.Ptr(),
+			Direction: direction.Ptr(),
+			IPFamily:  enf.IPFamily6.Ptr(),
+			Protocol:  protocol.Ptr(),
+		}
+		if src != "" {
+			r.SourceIP = enf.String(src)
+		}
+		if dst != "" {
+			r.DestIP = enf.String(dst)
+		}
+		return r
+	}
+
+	toDB := rule(10, enf.DirectionEgress, enf.ProtocolTCP, "", dbNet.String())
+	toDB.DestPort = enf.Int(5432)
+	ping := rule(20, enf.DirectionEgress, enf.ProtocolICMP6, "", "")
+	ping.ICMPType = enf.Int(128)
+	fromApp := rule(10, enf.DirectionIngress, enf.ProtocolTCP, appNet.String(), "")
+	fromApp.DestPort, fromApp.DestPortEnd = enf.Int(5000), enf.Int(6000)
+
+	return map[enf.NetworkAddr][]*enf.FirewallRule{
+		appNet: {ping, toDB},
+		dbNet: {
+			fromApp,
+			rule(20, enf.DirectionIngress, enf.ProtocolICMP6, "fd00:8f80:8000:1::5", ""),
+		},
+		opsNet: {
+			rule(10, enf.DirectionEgress, enf.ProtocolAll, "", ""),
+			rule(10, enf.DirectionIngress, enf.ProtocolAll, appNet.String(), ""),
+		},
+	}
+}
+
+func TestCompute(t *testing.T) {
+	m, err := Compute(testNetworks(), testRules(), nil)
+	if err != nil {
+		t.Fatalf("Compute returned error %v", err)
+	}
+
+	var names []string
+	for _, n := range m.Networks {
+		names = append(names, *n.Name)
+	}
+	if want := []string{"app", "db", "ops"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Compute returned networks %v, want %v", names, want)
+	}
+
+	tests := []struct {
+		from, to enf.NetworkAddr
+		want     string
+	}{
+		{appNet, dbNet, "TCP 5432; ICMP6 type 128 (partial)"},
+		{appNet, opsNet, "ICMP6 type 128"},
+		{appNet, appNet, ""},
+		{dbNet, appNet, ""},
+		{opsNet, appNet, ""},
+		{opsNet, dbNet, ""},
+		{opsNet, opsNet, ""},
+	}
+	for _, tt := range tests {
+		if got := joinServices(m.Allowed(tt.from, tt.to), "; "); got != tt.want {
+			t.Errorf("Allowed(%v, %v) returned %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestCompute_defaultAccept(t *testing.T) {
+	rules := map[enf.NetworkAddr][]*enf.FirewallRule{
+		dbNet: {{
+			Priority: enf.Int(1), Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			Protocol: enf.ProtocolTCP.Ptr(), DestPort: enf.Int(22),
+		}},
+	}
+	m, err := Compute(testNetworks(), rules, &Options{DefaultAction: enf.ActionAccept})
+	if err != nil {
+		t.Fatalf("Compute returned error %v", err)
+	}
+
+	want := []Service{
+		{Protocol: enf.ProtocolTCP, First: 1, Last: 21},
+		{Protocol: enf.ProtocolTCP, First: 23, Last: 65535},
+		{Protocol: enf.ProtocolUDP, First: 1, Last: 65535},
+		{Protocol: enf.ProtocolICMP6, First: 0, Last: 255},
+	}
+	if got := m.Allowed(appNet, dbNet); !reflect.DeepEqual(got, want) {
+		t.Errorf("Allowed returned %v, want %v", got, want)
+	}
+	if got := joinServices(m.Allowed(opsNet, appNet), ", "); got != "TCP any, UDP any, ICMP6 any" {
+		t.Errorf("Allowed returned %q for a network without rules", got)
+	}
+}
+
+func TestMatrix_export(t *testing.T) {
+	networks := testNetworks()[1:]
+	m, err := Compute(networks, testRules(), nil)
+	if err != nil {
+		t.Fatalf("Compute returned error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned error %v", err)
+	}
+	wantCSV := `from \ to,fd00:8f80:8000:1::/64,fd00:8f80:8000:2::/64
+fd00:8f80:8000:1::/64,,TCP 5432; ICMP6 type 128 (partial)
+fd00:8f80:8000:2::/64,,
+`
+	if buf.String() != wantCSV {
+		t.Errorf("WriteCSV wrote\n%v\nwant\n%v", buf.String(), wantCSV)
+	}
+
+	buf.Reset()
+	if err := m.WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT returned error %v", err)
+	}
+	wantDOT := `digraph reachability {
+	node [shape=box];
+	"fd00:8f80:8000:1::/64" [label="app\nfd00:8f80:8000:1::/64"];
+	"fd00:8f80:8000:2::/64" [label="db\nfd00:8f80:8000:2::/64"];
+	"fd00:8f80:8000:1::/64" -> "fd00:8f80:8000:2::/64" [label="TCP 5432\nICMP6 type 128 (partial)", style=dashed];
+}
+`
+	if buf.String() != wantDOT {
+		t.Errorf("WriteDOT wrote\n%v\nwant\n%v", buf.String(), wantDOT)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/xcr/v2/domains/fd00:8f80:8000::/48/nws", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := json.Marshal(testNetworks())
+		fmt.Fprintf(w, `{"data": %s, "page": {"curr": -1, "next": -1, "prev": -1}}`, data)
+	})
+	for network, rules := range testRules() {
+		rules := rules
+		mux.HandleFunc(fmt.Sprintf("/api/xfw/v1/%v/rule", network.PathEscape()), func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(rules)
+		})
+	}
+
+	client, _ := enf.NewClient(server.URL, nil)
+	m, err := Build(context.Background(), client, "fd00:8f80:8000::/48", nil)
+	if err != nil {
+		t.Fatalf("Build returned error %v", err)
+	}
+	if got := joinServices(m.Allowed(appNet, dbNet), "; "); got != "TCP 5432; ICMP6 type 128 (partial)" {
+		t.Errorf("Allowed returned %q", got)
+	}
+}
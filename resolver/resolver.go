@@ -0,0 +1,400 @@
+// Package resolver answers DNS queries offline against ENF zone data,
+// to show what a name would resolve to from a network without querying
+// the ENF resolvers.
+//
+// A Resolver holds zones, their records and the networks each zone is
+// attached to, loaded through the DNS API with Load or from zone files
+// with ReadZoneFile. Resolve follows CNAME chains across the zones and
+// only uses the zones attached to the network the query comes from. The
+// Answer lists every record used, and Server serves the same answers
+// over UDP for testing device firmware.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+	"github.com/xaptum/go-enf/zonefile"
+)
+
+// MaxCNAMEs is the number of CNAME records Resolve follows before
+// giving up on a chain.
+const MaxCNAMEs = 8
+
+// Zone is a DNS zone with its records and the networks it is served
+// to.
+type Zone struct {
+	// Name is the domain name of the zone, such as fleet.internal.
+	Name string
+
+	// Records are the records of the zone. Their names are relative to
+	// the zone, with "@" for the zone itself, unless they end with a
+	// dot, and so are the names in their data.
+	Records []*enf.Record
+
+	// Networks are the networks the zone is attached to.
+	Networks []enf.NetworkAddr
+}
+
+// Load lists the zones of the client's domain, with their records and
+// the networks they are attached to, and returns a resolver for them.
+func Load(ctx context.Context, client *enf.Client) (*Resolver, error) {
+	zones, _, err := client.DNS.ListZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []*Zone
+	for _, z := range zones {
+		if z.ID == nil || z.ZoneDomainName == nil {
+			continue
+		}
+		zone := &Zone{Name: *z.ZoneDomainName}
+		zone.Records, _, err = client.DNS.ListRecords(ctx, *z.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		attached, _, err := client.DNS.ListZoneNetworks(ctx, *z.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range attached {
+			if a.EnfNetwork == nil {
+				continue
+			}
+			network, err := enf.ParseNetworkAddr(*a.EnfNetwork)
+			if err != nil {
+				return nil, fmt.Errorf("Zone %v: %w", zone.Name, err)
+			}
+			zone.Networks = append(zone.Networks, network)
+		}
+		loaded = append(loaded, zone)
+	}
+	return New(loaded...), nil
+}
+
+// ReadZoneFile reads a zone file, such as one written by zonefile.Write,
+// as a zone attached to the networks. The issues are the lines of the
+// file that could not be imported.
+func ReadZoneFile(r io.Reader, opts *zonefile.Options, networks ...enf.NetworkAddr) (*Zone, []*zonefile.Issue, error) {
+	res, err := zonefile.Parse(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	zone := &Zone{Name: *res.Zone.ZoneDomainName, Networks: networks}
+	for _, req := range res.Records {
+		zone.Records = append(zone.Records, &enf.Record{Name: req.Name, TTL: req.TTL, Type: req.Type, Data: req.Data})
+	}
+	return zone, res.Issues, nil
+}
+
+// Resolver answers queries against a set of zones. It is safe for
+// concurrent use.
+type Resolver struct {
+	zones    []*zone
+	networks map[enf.NetworkAddr]bool
+}
+
+// zone is a Zone indexed by fully qualified, lower case names.
+type zone struct {
+	*Zone
+	name     string
+	records  map[string][]*enf.Record
+	nodes    map[string]bool
+	networks map[enf.NetworkAddr]bool
+}
+
+// New returns a resolver for the zones.
+func New(zones ...*Zone) *Resolver {
+	r := &Resolver{networks: make(map[enf.NetworkAddr]bool)}
+	for _, z := range zones {
+		idx := &zone{
+			Zone:     z,
+			name:     canonical(z.Name),
+			records:  make(map[string][]*enf.Record),
+			nodes:    make(map[string]bool),
+			networks: make(map[enf.NetworkAddr]bool),
+		}
+		for _, rec := range z.Records {
+			if rec == nil || rec.Type == nil {
+				continue
+			}
+			owner := absolute(stringValue(rec.Name), idx.name)
+			if !inZone(owner, idx.name) {
+				continue
+			}
+			idx.records[owner] = append(idx.records[owner], rec)
+			for n := owner; n != idx.name; n = parent(n) {
+				idx.nodes[n] = true
+			}
+		}
+		for _, n := range z.Networks {
+			idx.networks[n] = true
+			r.networks[n] = true
+		}
+		r.zones = append(r.zones, idx)
+	}
+	return r
+}
+
+// Status is the outcome of a query.
+type Status int
+
+const (
+	// Success means the name has records of the type, or a CNAME chain
+	// that leads outside the zones.
+	Success Status = iota
+
+	// NoData means the name exists, but has no records of the type.
+	NoData
+
+	// NXDomain means the name does not exist.
+	NXDomain
+
+	// Refused means no zone for the name is served to the network.
+	Refused
+
+	// ServFail means a CNAME chain loops or is longer than MaxCNAMEs.
+	ServFail
+)
+
+var statusNames = []string{"NOERROR", "NODATA", "NXDOMAIN", "REFUSED", "SERVFAIL"}
+
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+	return statusNames[s]
+}
+
+// rcode returns the DNS response code of the status.
+func (s Status) rcode() int {
+	switch s {
+	case NXDomain:
+		return 3
+	case Refused:
+		return 5
+	case ServFail:
+		return 2
+	}
+	return 0
+}
+
+// RecordRef is a record used to answer a query.
+type RecordRef struct {
+	// Zone is the name of the zone the record belongs to.
+	Zone string
+
+	// Name is the fully qualified name the record answers for. For a
+	// wildcard record, it is the name that was queried.
+	Name string
+
+	Record *enf.Record
+}
+
+// Target returns the fully qualified form of a name in the record's
+// data, such as the target of a CNAME record.
+func (r *RecordRef) Target(name string) string {
+	return absolute(name, r.Zone)
+}
+
+func (r *RecordRef) String() string {
+	ttl := "-"
+	if r.Record.TTL != nil {
+		ttl = fmt.Sprint(*r.Record.TTL)
+	}
+	data, err := zonefile.FormatData(*r.Record.Type, r.Record.Data)
+	if err != nil {
+		data = "(" + err.Error() + ")"
+	}
+	return fmt.Sprintf("%v %v %v %v (zone %v)", r.Name, ttl, *r.Record.Type, data, strings.TrimSuffix(r.Zone, "."))
+}
+
+// Answer is the answer to a query and how it was found.
+type Answer struct {
+	Name    string
+	Type    enf.RecordType
+	Network enf.NetworkAddr
+	Status  Status
+
+	// CNAMEs are the CNAME records followed from the name, in order.
+	CNAMEs []*RecordRef
+
+	// Records are the records of the type at the end of the chain.
+	Records []*RecordRef
+
+	// Reason explains a status other than Success, or a chain that ends
+	// outside the zones.
+	Reason string
+}
+
+// String returns the answer as the question and status on the first
+// line, followed by the records used, one per line.
+func (a *Answer) String() string {
+	var b strings.Builder
+	from := "any network"
+	if a.Network.IsValid() {
+		from = a.Network.String()
+	}
+	fmt.Fprintf(&b, "%v %v from %v: %v", a.Name, a.Type, from, a.Status)
+	if a.Reason != "" {
+		fmt.Fprintf(&b, " (%v)", a.Reason)
+	}
+	for _, r := range append(append([]*RecordRef(nil), a.CNAMEs...), a.Records...) {
+		b.WriteString("\n  " + r.String())
+	}
+	return b.String()
+}
+
+// Resolve answers a query for the name and type from the network,
+// using only the zones attached to it. If network is the zero
+// NetworkAddr, every zone is used. CNAME records are followed unless
+// the type is CNAME.
+func (r *Resolver) Resolve(network enf.NetworkAddr, name string, typ enf.RecordType) *Answer {
+	a := &Answer{Name: canonical(name), Type: typ, Network: network}
+	seen := make(map[string]bool)
+	for name := a.Name; ; {
+		z := r.zone(network, name)
+		if z == nil {
+			if len(a.CNAMEs) > 0 {
+				a.Reason = fmt.Sprintf("%v is outside the zones served to the network", name)
+				return a
+			}
+			a.Status, a.Reason = Refused, r.refusal(name)
+			return a
+		}
+
+		owner, records := z.lookup(name)
+		if records == nil {
+			if owner != "" {
+				a.Status, a.Reason = NoData, fmt.Sprintf("%v has no records", name)
+			} else {
+				a.Status, a.Reason = NXDomain, fmt.Sprintf("%v does not exist in zone %v", name, strings.TrimSuffix(z.name, "."))
+			}
+			return a
+		}
+
+		var cname *RecordRef
+		for _, rec := range records {
+			ref := &RecordRef{Zone: z.name, Name: name, Record: rec}
+			switch *rec.Type {
+			case typ:
+				a.Records = append(a.Records, ref)
+			case enf.RecordTypeCNAME:
+				cname = ref
+			}
+		}
+		if len(a.Records) > 0 || cname == nil {
+			if len(a.Records) == 0 {
+				a.Status, a.Reason = NoData, fmt.Sprintf("%v has no %v records", name, typ)
+			}
+			return a
+		}
+
+		a.CNAMEs = append(a.CNAMEs, cname)
+		seen[name] = true
+		if cname.Record.Data == nil || cname.Record.Data.CNAME == nil {
+			a.Status, a.Reason = ServFail, fmt.Sprintf("CNAME record of %v has no target", name)
+			return a
+		}
+		name = cname.Target(*cname.Record.Data.CNAME)
+		switch {
+		case seen[name]:
+			a.Status, a.Reason = ServFail, fmt.Sprintf("CNAME chain loops at %v", name)
+			return a
+		case len(a.CNAMEs) >= MaxCNAMEs:
+			a.Status, a.Reason = ServFail, fmt.Sprintf("CNAME chain is longer than %d records", MaxCNAMEs)
+			return a
+		}
+	}
+}
+
+// zone returns the closest zone of the name served to the network, or
+// nil if there is none.
+func (r *Resolver) zone(network enf.NetworkAddr, name string) *zone {
+	var best *zone
+	for _, z := range r.zones {
+		if !inZone(name, z.name) || (network.IsValid() && !z.networks[network]) {
+			continue
+		}
+		if best == nil || len(z.name) > len(best.name) {
+			best = z
+		}
+	}
+	return best
+}
+
+// refusal explains why no zone of the name is served.
+func (r *Resolver) refusal(name string) string {
+	for _, z := range r.zones {
+		if inZone(name, z.name) {
+			return fmt.Sprintf("zone %v is not attached to the network", strings.TrimSuffix(z.name, "."))
+		}
+	}
+	return fmt.Sprintf("no zone for %v", name)
+}
+
+// lookup returns the records of the name in the zone, and the name they
+// are owned by, which is a wildcard name if the name has no records of
+// its own. It returns the name and no records if the name exists but
+// has no records, and "" if it does not exist.
+func (z *zone) lookup(name string) (string, []*enf.Record) {
+	if records := z.records[name]; records != nil {
+		return name, records
+	}
+	if z.nodes[name] || name == z.name {
+		return name, nil
+	}
+
+	// The wildcard of the closest existing ancestor answers for names
+	// that don't exist (RFC 4592).
+	for p := parent(name); ; p = parent(p) {
+		if z.nodes[p] || p == z.name {
+			wildcard := "*." + p
+			if records := z.records[wildcard]; records != nil {
+				return wildcard, records
+			}
+			return "", nil
+		}
+	}
+}
+
+// canonical returns the fully qualified, lower case form of a name.
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+}
+
+// absolute returns the fully qualified, lower case form of a name
+// relative to the zone, unless it ends with a dot.
+func absolute(name, zone string) string {
+	switch {
+	case name == "" || name == "@":
+		return zone
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(name) + "." + zone
+}
+
+// inZone reports whether the fully qualified name is within the zone.
+func inZone(name, zone string) bool {
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// parent returns the fully qualified name without its first label.
+func parent(name string) string {
+	if i := strings.Index(name, "."); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+	return "."
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
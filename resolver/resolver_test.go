@@ -0,0 +1,175 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+	"github.com/xaptum/go-enf/zonefile"
+)
+
+var (
+	netA = enf.MustParseNetworkAddr("fd00:8f80:8000:1::/64")
+	netB = enf.MustParseNetworkAddr("fd00:8f80:8000:2::/64")
+	netC = enf.MustParseNetworkAddr("fd00:8f80:8000:3::/64")
+)
+
+func record(name string, typ enf.RecordType, data *enf.RecordData) *enf.Record {
+	return &enf.Record{Name: enf.String(name), Type: typ.Ptr(), Data: data}
+}
+
+func aaaa(name, addr string) *enf.Record {
+	return record(name, enf.RecordTypeAAAA, &enf.RecordData{IPv6: enf.String(addr)})
+}
+
+func cname(name, target string) *enf.Record {
+	return record(name, enf.RecordTypeCNAME, &enf.RecordData{CNAME: enf.String(target)})
+}
+
+func testResolver() *Resolver {
+	www := aaaa("www", "fd00:8f80:8000:1::10")
+	www.TTL = enf.Int(60)
+	return New(
+		&Zone{
+			Name:     "Fleet.internal",
+			Networks: []enf.NetworkAddr{netA, netB},
+			Records: []*enf.Record{
+				cname("api", "www"),
+				www,
+				cname("legacy", "gw.plant1.example."),
+				cname("ext", "host.example.com."),
+				cname("loop1", "loop2"),
+				cname("loop2", "loop1.fleet.internal."),
+				aaaa("*.dev", "fd00:8f80:8000:1::99"),
+				aaaa("a.b", "fd00:8f80:8000:1::ab"),
+				record("@", enf.RecordTypeTXT, &enf.RecordData{TXT: []string{"hello"}}),
+			},
+		},
+		&Zone{
+			Name:     "plant1.example.",
+			Networks: []enf.NetworkAddr{netA},
+			Records:  []*enf.Record{aaaa("gw", "fd00:8f80:8000:2::1")},
+		},
+		&Zone{
+			Name:     "fleet.internal",
+			Networks: []enf.NetworkAddr{netC},
+			Records:  []*enf.Record{aaaa("www", "fd00:8f80:8000:3::10")},
+		},
+	)
+}
+
+// summarize returns the answer as its status and the names and data of
+// the records used.
+func summarize(a *Answer) string {
+	parts := []string{a.Status.String()}
+	for _, r := range append(append([]*RecordRef(nil), a.CNAMEs...), a.Records...) {
+		data, _ := zonefile.FormatData(*r.Record.Type, r.Record.Data)
+		parts = append(parts, fmt.Sprintf("%v %v %v", r.Name, *r.Record.Type, data))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r := testResolver()
+	tests := []struct {
+		network enf.NetworkAddr
+		name    string
+		typ     enf.RecordType
+		want    string
+	}{
+		{netA, "API.fleet.internal", enf.RecordTypeAAAA, "NOERROR; api.fleet.internal. CNAME www; www.fleet.internal. AAAA fd00:8f80:8000:1::10"},
+		{netA, "api.fleet.internal.", enf.RecordTypeCNAME, "NOERROR; api.fleet.internal. CNAME www"},
+		{netA, "legacy.fleet.internal", enf.RecordTypeAAAA, "NOERROR; legacy.fleet.internal. CNAME gw.plant1.example.; gw.plant1.example. AAAA fd00:8f80:8000:2::1"},
+		{netB, "legacy.fleet.internal", enf.RecordTypeAAAA, "NOERROR; legacy.fleet.internal. CNAME gw.plant1.example."},
+		{netA, "ext.fleet.internal", enf.RecordTypeAAAA, "NOERROR; ext.fleet.internal. CNAME host.example.com."},
+		{netB, "gw.plant1.example", enf.RecordTypeAAAA, "REFUSED"},
+		{netA, "host.example.com", enf.RecordTypeAAAA, "REFUSED"},
+		{netA, "nope.fleet.internal", enf.RecordTypeAAAA, "NXDOMAIN"},
+		{netA, "b.fleet.internal", enf.RecordTypeAAAA, "NODATA"},
+		{netA, "fleet.internal", enf.RecordTypeAAAA, "NODATA"},
+		{netA, "fleet.internal", enf.RecordTypeTXT, `NOERROR; fleet.internal. TXT "hello"`},
+		{netA, "x.y.dev.fleet.internal", enf.RecordTypeAAAA, "NOERROR; x.y.dev.fleet.internal. AAAA fd00:8f80:8000:1::99"},
+		{netA, "x.a.b.fleet.internal", enf.RecordTypeAAAA, "NXDOMAIN"},
+		{netA, "loop1.fleet.internal", enf.RecordTypeAAAA, "SERVFAIL; loop1.fleet.internal. CNAME loop2; loop2.fleet.internal. CNAME loop1.fleet.internal."},
+		{netC, "www.fleet.internal", enf.RecordTypeAAAA, "NOERROR; www.fleet.internal. AAAA fd00:8f80:8000:3::10"},
+		{netC, "api.fleet.internal", enf.RecordTypeAAAA, "NXDOMAIN"},
+		{enf.NetworkAddr{}, "gw.plant1.example", enf.RecordTypeAAAA, "NOERROR; gw.plant1.example. AAAA fd00:8f80:8000:2::1"},
+	}
+	for _, tt := range tests {
+		a := r.Resolve(tt.network, tt.name, tt.typ)
+		if got := summarize(a); got != tt.want {
+			t.Errorf("Resolve(%v, %v, %v) returned %q, want %q", tt.network, tt.name, tt.typ, got, tt.want)
+		}
+	}
+
+	a := r.Resolve(netB, "gw.plant1.example", enf.RecordTypeAAAA)
+	if a.Reason != "zone plant1.example is not attached to the network" {
+		t.Errorf("Resolve returned reason %q", a.Reason)
+	}
+
+	a = r.Resolve(netA, "api.fleet.internal", enf.RecordTypeAAAA)
+	want := "api.fleet.internal. AAAA from fd00:8f80:8000:1::/64: NOERROR\n" +
+		"  api.fleet.internal. - CNAME www (zone fleet.internal)\n" +
+		"  www.fleet.internal. 60 AAAA fd00:8f80:8000:1::10 (zone fleet.internal)"
+	if a.String() != want {
+		t.Errorf("Answer.String returned\n%v\nwant\n%v", a, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/xdns/2019-05-27/zones", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"id": "z1", "zone_domain_name": "fleet.internal"}]}`)
+	})
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/z1/records", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"id": "r1", "name": "api", "type": "CNAME", "rdata": {"cname": "www.fleet.internal."}},
+			{"id": "r2", "name": "www", "type": "AAAA", "ttl": 60, "rdata": {"ipv6": "fd00:8f80:8000:1::10"}}
+		]}`)
+	})
+	mux.HandleFunc("/api/xdns/2019-05-27/zones/z1/networks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"zone_id": "z1", "enf_network": "fd00:8f80:8000:1::/64"}]}`)
+	})
+
+	client, _ := enf.NewClient(server.URL, nil)
+	r, err := Load(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Load returned error %v", err)
+	}
+	if got, want := summarize(r.Resolve(netA, "api.fleet.internal", enf.RecordTypeAAAA)), "NOERROR; api.fleet.internal. CNAME www.fleet.internal.; www.fleet.internal. AAAA fd00:8f80:8000:1::10"; got != want {
+		t.Errorf("Resolve returned %q, want %q", got, want)
+	}
+	if got := r.Resolve(netB, "api.fleet.internal", enf.RecordTypeAAAA).Status; got != Refused {
+		t.Errorf("Resolve returned %v from a network the zone is not attached to", got)
+	}
+}
+
+func TestReadZoneFile(t *testing.T) {
+	file := "$ORIGIN fleet.internal.\n" +
+		"api\tIN\tCNAME\twww\n" +
+		"www\t60\tIN\tAAAA\tfd00:8f80:8000:1::10\n" +
+		"@\tIN\tNS\tns1\n"
+	zone, issues, err := ReadZoneFile(strings.NewReader(file), nil, netA)
+	if err != nil {
+		t.Fatalf("ReadZoneFile returned error %v", err)
+	}
+	if zone.Name != "fleet.internal" || len(zone.Records) != 2 || !reflect.DeepEqual(zone.Networks, []enf.NetworkAddr{netA}) {
+		t.Errorf("ReadZoneFile returned zone %+v", zone)
+	}
+	if len(issues) != 1 || issues[0].Line != 4 {
+		t.Errorf("ReadZoneFile returned issues %v", issues)
+	}
+
+	r := New(zone)
+	if got, want := summarize(r.Resolve(netA, "api.fleet.internal", enf.RecordTypeAAAA)), "NOERROR; api.fleet.internal. CNAME www.fleet.internal.; www.fleet.internal. AAAA fd00:8f80:8000:1::10"; got != want {
+		t.Errorf("Resolve returned %q, want %q", got, want)
+	}
+}
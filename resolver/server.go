@@ -0,0 +1,294 @@
+package resolver
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// DefaultTTL is the TTL Server gives the records that have none.
+const DefaultTTL = 300
+
+// maxUDPSize is the size of a DNS message over UDP without EDNS (RFC
+// 1035, section 4.2.1). Larger responses are truncated.
+const maxUDPSize = 512
+
+var errFormat = errors.New("malformed DNS message")
+
+// recordTypes maps the DNS type codes to the record types of ENF.
+var recordTypes = map[uint16]enf.RecordType{
+	28: enf.RecordTypeAAAA,
+	5:  enf.RecordTypeCNAME,
+	16: enf.RecordTypeTXT,
+	33: enf.RecordTypeSRV,
+	12: enf.RecordTypePTR,
+	15: enf.RecordTypeMX,
+}
+
+// Server answers DNS queries over UDP from a Resolver. It answers
+// authoritatively for the loaded zones, with the records of Resolve:
+// the CNAME chain followed by the records of the requested type.
+type Server struct {
+	Resolver *Resolver
+
+	// Network is the network queries are resolved from if the source
+	// address of a query is not in a network of the loaded zones, as
+	// when testing on a local address. If zero, such queries use every
+	// zone.
+	Network enf.NetworkAddr
+
+	// TTL is the TTL of the records that have none. It defaults to
+	// DefaultTTL.
+	TTL int
+}
+
+// ListenAndServe listens on the UDP address, such as "[::1]:5353", and
+// serves queries until the listener fails.
+func (s *Server) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return s.Serve(conn)
+}
+
+// Serve serves queries received on the connection until reading from
+// it fails or it is closed. Malformed messages are ignored, and so are
+// responses that cannot be sent to a client, such as one whose address
+// has become unreachable.
+func (s *Server) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		resp, err := s.handle(buf[:n], s.network(addr))
+		if err != nil {
+			continue
+		}
+		if _, err := conn.WriteTo(resp, addr); errors.Is(err, net.ErrClosed) {
+			return err
+		}
+	}
+}
+
+// network returns the network a query from the address is resolved
+// from.
+func (s *Server) network(addr net.Addr) enf.NetworkAddr {
+	if udp, ok := addr.(*net.UDPAddr); ok {
+		a, _ := netip.AddrFromSlice(udp.IP)
+		if e, err := enf.EndpointAddrFrom(a.WithZone("")); err == nil && s.Resolver.networks[e.Network()] {
+			return e.Network()
+		}
+	}
+	return s.Network
+}
+
+// handle returns the response to a query message.
+func (s *Server) handle(msg []byte, network enf.NetworkAddr) ([]byte, error) {
+	if len(msg) < 12 || msg[2]&0x80 != 0 {
+		return nil, errFormat
+	}
+	id := binary.BigEndian.Uint16(msg)
+	flags := binary.BigEndian.Uint16(msg[2:])
+	resp := &message{id: id, flags: 0x8000 | 0x0400 | flags&0x0100}
+
+	opcode := (flags >> 11) & 0xf
+	qdcount := binary.BigEndian.Uint16(msg[4:])
+	name, end, err := readName(msg, 12)
+	if opcode != 0 {
+		resp.rcode = 4
+	} else if qdcount != 1 || err != nil || len(msg) < end+4 {
+		resp.rcode = 1
+	}
+	if err == nil && len(msg) >= end+4 {
+		resp.question = msg[12 : end+4]
+	}
+	if resp.rcode != 0 {
+		return resp.bytes(), nil
+	}
+
+	qtype := binary.BigEndian.Uint16(msg[end:])
+	qclass := binary.BigEndian.Uint16(msg[end+2:])
+	typ, ok := recordTypes[qtype]
+	switch {
+	case qclass != 1:
+		resp.rcode = 5
+		return resp.bytes(), nil
+	case !ok:
+		typ = enf.RecordType("TYPE" + strconv.Itoa(int(qtype)))
+	}
+
+	a := s.Resolver.Resolve(network, name, typ)
+	resp.rcode = a.Status.rcode()
+	for _, ref := range append(append([]*RecordRef(nil), a.CNAMEs...), a.Records...) {
+		rr, err := s.encode(ref)
+		if err != nil {
+			continue
+		}
+		resp.answers = append(resp.answers, rr)
+	}
+	return resp.bytes(), nil
+}
+
+// message is a DNS response.
+type message struct {
+	id       uint16
+	flags    uint16
+	rcode    int
+	question []byte
+	answers  [][]byte
+}
+
+// bytes returns the wire format of the message, truncated with the TC
+// bit set if it does not fit in maxUDPSize.
+func (m *message) bytes() []byte {
+	size := 12 + len(m.question)
+	for _, rr := range m.answers {
+		size += len(rr)
+	}
+	flags := m.flags | uint16(m.rcode)
+	answers := m.answers
+	if size > maxUDPSize {
+		flags |= 0x0200
+		answers = nil
+	}
+
+	b := make([]byte, 12, size)
+	binary.BigEndian.PutUint16(b, m.id)
+	binary.BigEndian.PutUint16(b[2:], flags)
+	if m.question != nil {
+		binary.BigEndian.PutUint16(b[4:], 1)
+	}
+	binary.BigEndian.PutUint16(b[6:], uint16(len(answers)))
+	b = append(b, m.question...)
+	for _, rr := range answers {
+		b = append(b, rr...)
+	}
+	return b
+}
+
+// encode returns the wire format of the record.
+func (s *Server) encode(ref *RecordRef) ([]byte, error) {
+	r := ref.Record
+	d := r.Data
+	if d == nil {
+		return nil, errFormat
+	}
+
+	var code uint16
+	for c, t := range recordTypes {
+		if t == *r.Type {
+			code = c
+		}
+	}
+	var rdata []byte
+	var err error
+	switch *r.Type {
+	case enf.RecordTypeAAAA:
+		a, perr := netip.ParseAddr(stringValue(d.IPv6))
+		if perr != nil || !a.Is6() {
+			return nil, errFormat
+		}
+		b := a.As16()
+		rdata = b[:]
+	case enf.RecordTypeCNAME:
+		rdata, err = appendName(nil, ref.Target(stringValue(d.CNAME)))
+	case enf.RecordTypePTR:
+		rdata, err = appendName(nil, ref.Target(stringValue(d.PTRDName)))
+	case enf.RecordTypeTXT:
+		for _, txt := range d.TXT {
+			if len(txt) > 255 {
+				return nil, errFormat
+			}
+			rdata = append(append(rdata, byte(len(txt))), txt...)
+		}
+	case enf.RecordTypeSRV:
+		rdata = appendUint16(nil, uint16(intValue(d.Priority)))
+		rdata = appendUint16(rdata, uint16(intValue(d.Weight)))
+		rdata = appendUint16(rdata, uint16(intValue(d.Port)))
+		rdata, err = appendName(rdata, ref.Target(stringValue(d.Target)))
+	case enf.RecordTypeMX:
+		rdata = appendUint16(nil, uint16(intValue(d.Preference)))
+		rdata, err = appendName(rdata, ref.Target(stringValue(d.Exchange)))
+	default:
+		return nil, errFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if r.TTL != nil {
+		ttl = *r.TTL
+	}
+	rr, err := appendName(nil, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	rr = appendUint16(rr, code)
+	rr = appendUint16(rr, 1)
+	rr = append(rr, byte(ttl>>24), byte(ttl>>16), byte(ttl>>8), byte(ttl))
+	rr = appendUint16(rr, uint16(len(rdata)))
+	return append(rr, rdata...), nil
+}
+
+// readName reads an uncompressed name at the offset of the message,
+// returning it in lower case with a trailing dot, and the offset after
+// it.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	for {
+		if off >= len(msg) {
+			return "", 0, errFormat
+		}
+		n := int(msg[off])
+		off++
+		switch {
+		case n == 0:
+			return strings.ToLower(strings.Join(labels, ".")) + ".", off, nil
+		case n > 63 || off+n > len(msg):
+			return "", 0, errFormat
+		}
+		labels = append(labels, string(msg[off:off+n]))
+		off += n
+	}
+}
+
+// appendName appends the wire format of a fully qualified name.
+func appendName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, errFormat
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, errFormat
+			}
+			b = append(append(b, byte(len(label))), label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
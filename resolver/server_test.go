@@ -0,0 +1,145 @@
+package resolver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// query returns a DNS query message for the name and type code.
+func query(t *testing.T, id uint16, name string, qtype uint16) []byte {
+	msg := []byte{byte(id >> 8), byte(id), 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	msg, err := appendName(msg, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg = appendUint16(msg, qtype)
+	return appendUint16(msg, 1)
+}
+
+func TestServer_handle(t *testing.T) {
+	s := &Server{Resolver: testResolver(), TTL: 30}
+
+	q := query(t, 0x1234, "api.fleet.internal", 28)
+	resp, err := s.handle(q, netA)
+	if err != nil {
+		t.Fatalf("handle returned error %v", err)
+	}
+
+	want := append([]byte{0x12, 0x34, 0x85, 0x00, 0, 1, 0, 2, 0, 0, 0, 0}, q[12:]...)
+	want, _ = appendName(want, "api.fleet.internal.")
+	want = append(want, 0, 5, 0, 1, 0, 0, 0, 30, 0, 20)
+	want, _ = appendName(want, "www.fleet.internal.")
+	want, _ = appendName(want, "www.fleet.internal.")
+	want = append(want, 0, 28, 0, 1, 0, 0, 0, 60, 0, 16)
+	want = append(want, 0xfd, 0, 0x8f, 0x80, 0x80, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0x10)
+	if !bytes.Equal(resp, want) {
+		t.Errorf("handle returned\n% x\nwant\n% x", resp, want)
+	}
+
+	tests := []struct {
+		name    string
+		qtype   uint16
+		network enf.NetworkAddr
+		rcode   byte
+		answers uint16
+	}{
+		{"nope.fleet.internal", 28, netA, 3, 0},
+		{"gw.plant1.example", 28, netB, 5, 0},
+		{"www.fleet.internal", 1, netA, 0, 0},
+		{"loop1.fleet.internal", 28, netA, 2, 2},
+		{"fleet.internal", 16, netA, 0, 1},
+	}
+	for _, tt := range tests {
+		resp, err := s.handle(query(t, 1, tt.name, tt.qtype), tt.network)
+		if err != nil {
+			t.Fatalf("handle returned error %v", err)
+		}
+		if rcode, answers := resp[3]&0xf, binary.BigEndian.Uint16(resp[6:]); rcode != tt.rcode || answers != tt.answers {
+			t.Errorf("handle returned rcode %d with %d answers for %v, want %d with %d", rcode, answers, tt.name, tt.rcode, tt.answers)
+		}
+	}
+
+	if _, err := s.handle(q[:8], netA); err == nil {
+		t.Errorf("handle returned no error for a short message")
+	}
+	bad := append([]byte(nil), q...)
+	bad[12] = 0xc0
+	if resp, _ := s.handle(bad, netA); resp[3]&0xf != 1 {
+		t.Errorf("handle returned rcode %d for a compressed question", resp[3]&0xf)
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	s := &Server{Resolver: testResolver(), Network: netC}
+	go s.Serve(conn)
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := client.Write(query(t, 7, "www.fleet.internal", 28)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 512)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The query comes from an IPv4 address, so it is resolved from
+	// the server's network.
+	if resp := buf[:n]; binary.BigEndian.Uint16(resp) != 7 || binary.BigEndian.Uint16(resp[6:]) != 1 || !bytes.HasSuffix(resp, []byte{0xfd, 0, 0x8f, 0x80, 0x80, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 0x10}) {
+		t.Errorf("Serve returned % x", resp)
+	}
+}
+
+// failingConn serves the queued queries and fails every write with the
+// next queued error.
+type failingConn struct {
+	net.PacketConn
+	queries [][]byte
+	errs    []error
+}
+
+func (c *failingConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if len(c.queries) == 0 {
+		return 0, nil, io.EOF
+	}
+	n := copy(b, c.queries[0])
+	c.queries = c.queries[1:]
+	return n, &net.UDPAddr{IP: net.ParseIP("::1"), Port: 53}, nil
+}
+
+func (c *failingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	err := c.errs[0]
+	c.errs = c.errs[1:]
+	return 0, err
+}
+
+func TestServer_Serve_writeErrors(t *testing.T) {
+	s := &Server{Resolver: testResolver(), Network: netA}
+	q := query(t, 1, "www.fleet.internal", 28)
+
+	conn := &failingConn{queries: [][]byte{q, q, q}, errs: []error{errors.New("network is unreachable"), net.ErrClosed}}
+	if err := s.Serve(conn); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Serve returned %v after the connection was closed", err)
+	}
+	if len(conn.queries) != 1 {
+		t.Errorf("Serve read %d queries, want 2", 3-len(conn.queries))
+	}
+}
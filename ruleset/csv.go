@@ -0,0 +1,185 @@
+package ruleset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// encodeCSV writes a header with every field, followed by one row per
+// rule. Fields that are not set are left empty.
+func encodeCSV(w io.Writer, doc *document) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return err
+	}
+	for _, r := range doc.Rules {
+		record := make([]string, len(fields))
+		for i, f := range fields {
+			record[i] = r.get(f)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// decodeCSV reads a header naming the fields, in any order, followed by
+// one row per rule. Lines starting with # are ignored.
+func decodeCSV(data []byte) ([]*rule, []int, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, csvError(err)
+	}
+	for _, name := range header {
+		if !isField(name) {
+			line, _ := cr.FieldPos(0)
+			return nil, nil, &ParseError{Line: line, Err: fmt.Errorf("unknown column %q", name)}
+		}
+	}
+
+	var rules []*rule
+	var lines []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, csvError(err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		r := new(rule)
+		for i, value := range record {
+			if err := r.set(header[i], value); err != nil {
+				return nil, nil, &ParseError{Line: line, Err: err}
+			}
+		}
+		rules = append(rules, r)
+		lines = append(lines, line)
+	}
+	return rules, lines, nil
+}
+
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &ParseError{Line: parseErr.Line, Err: parseErr.Err}
+	}
+	return err
+}
+
+// get returns the value of the named field as text, or "" if it is not
+// set.
+func (r *rule) get(name string) string {
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	num := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+
+	switch name {
+	case "priority":
+		return num(r.Priority)
+	case "action":
+		if r.Action != nil {
+			return r.Action.String()
+		}
+	case "direction":
+		if r.Direction != nil {
+			return r.Direction.String()
+		}
+	case "ip_family":
+		if r.IPFamily != nil {
+			return r.IPFamily.String()
+		}
+	case "protocol":
+		if r.Protocol != nil {
+			return r.Protocol.String()
+		}
+	case "source_ip":
+		return str(r.SourceIP)
+	case "source_port":
+		return num(r.SourcePort)
+	case "dest_ip":
+		return str(r.DestIP)
+	case "dest_port":
+		return num(r.DestPort)
+	case "source_port_end":
+		return num(r.SourceEnd)
+	case "dest_port_end":
+		return num(r.DestEnd)
+	case "icmp_type":
+		return num(r.ICMPType)
+	case "icmp_code":
+		return num(r.ICMPCode)
+	}
+	return ""
+}
+
+// set sets the named field from text. An empty value leaves the field
+// unset.
+func (r *rule) set(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	num := func() (*int, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %q is not a number", name, value)
+		}
+		return &n, nil
+	}
+
+	var err error
+	switch name {
+	case "priority":
+		r.Priority, err = num()
+	case "action":
+		r.Action = enf.FirewallAction(value).Ptr()
+	case "direction":
+		r.Direction = enf.FirewallDirection(value).Ptr()
+	case "ip_family":
+		r.IPFamily = enf.IPFamily(value).Ptr()
+	case "protocol":
+		r.Protocol = enf.FirewallProtocol(value).Ptr()
+	case "source_ip":
+		r.SourceIP = &value
+	case "source_port":
+		r.SourcePort, err = num()
+	case "dest_ip":
+		r.DestIP = &value
+	case "dest_port":
+		r.DestPort, err = num()
+	case "source_port_end":
+		r.SourceEnd, err = num()
+	case "dest_port_end":
+		r.DestEnd, err = num()
+	case "icmp_type":
+		r.ICMPType, err = num()
+	case "icmp_code":
+		r.ICMPCode, err = num()
+	}
+	return err
+}
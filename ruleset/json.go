@@ -0,0 +1,95 @@
+package ruleset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+func encodeJSON(w io.Writer, doc *document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// decodeJSON decodes a JSON ruleset one rule at a time, so that every
+// rule can be traced back to its line.
+func decodeJSON(data []byte) ([]*rule, []int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	fail := func(err error, offset int64) error {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+		case err == io.EOF:
+			err = io.ErrUnexpectedEOF
+		}
+		return &ParseError{Line: lineAt(data, offset), Err: err}
+	}
+
+	expect := func(want json.Delim) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return fail(err, dec.InputOffset())
+		}
+		if tok != want {
+			return fail(fmt.Errorf("expected %q, found %v", want, tok), dec.InputOffset())
+		}
+		return nil
+	}
+
+	var rules []*rule
+	var lines []int
+	if err := expect('{'); err != nil {
+		return nil, nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, fail(err, dec.InputOffset())
+		}
+		if key, _ := tok.(string); key != "rules" {
+			return nil, nil, fail(fmt.Errorf("unknown field %q", tok), dec.InputOffset())
+		}
+		if err := expect('['); err != nil {
+			return nil, nil, err
+		}
+		for dec.More() {
+			start := skipSpace(data, dec.InputOffset())
+			r := new(rule)
+			if err := dec.Decode(r); err != nil {
+				return nil, nil, fail(err, start)
+			}
+			rules = append(rules, r)
+			lines = append(lines, lineAt(data, start))
+		}
+		if err := expect(']'); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := expect('}'); err != nil {
+		return nil, nil, err
+	}
+	return rules, lines, nil
+}
+
+// skipSpace returns the offset of the next value after the given
+// offset, skipping white space and separators.
+func skipSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
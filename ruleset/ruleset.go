@@ -0,0 +1,263 @@
+// Package ruleset reads and writes firewall rulesets in an exchange
+// format suited for version control and for moving policies between
+// environments.
+//
+// A ruleset holds the fields of enf.FirewallRuleRequest only, without
+// the fields assigned by the server such as the rule ID and network.
+// Rules are always written in ascending priority order, so that the
+// same ruleset is written the same way every time. Rulesets can be
+// written as JSON, YAML or CSV, and every format reads back what it
+// wrote.
+package ruleset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Format is the encoding of a ruleset.
+type Format string
+
+// The supported ruleset formats.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatCSV  Format = "csv"
+)
+
+var ErrUnknownFormat = errors.New("Unknown ruleset format")
+
+// FormatFromPath returns the format of a file from its extension:
+// .json, .yaml, .yml or .csv.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("%w: %v", ErrUnknownFormat, path)
+}
+
+// ParseError is an error in a ruleset that is being decoded.
+type ParseError struct {
+	// Line is the line the error was found on, starting at 1, or 0 if
+	// it is not known.
+	Line int
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error, such as an *enf.ValidationError.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// document is the top-level structure of JSON and YAML rulesets.
+type document struct {
+	Rules []*rule `json:"rules" yaml:"rules"`
+}
+
+// rule is a rule in the exchange format.
+type rule struct {
+	Priority   *int                   `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Action     *enf.FirewallAction    `json:"action,omitempty" yaml:"action,omitempty"`
+	Direction  *enf.FirewallDirection `json:"direction,omitempty" yaml:"direction,omitempty"`
+	IPFamily   *enf.IPFamily          `json:"ip_family,omitempty" yaml:"ip_family,omitempty"`
+	Protocol   *enf.FirewallProtocol  `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	SourceIP   *string                `json:"source_ip,omitempty" yaml:"source_ip,omitempty"`
+	SourcePort *int                   `json:"source_port,omitempty" yaml:"source_port,omitempty"`
+	SourceEnd  *int                   `json:"source_port_end,omitempty" yaml:"source_port_end,omitempty"`
+	DestIP     *string                `json:"dest_ip,omitempty" yaml:"dest_ip,omitempty"`
+	DestPort   *int                   `json:"dest_port,omitempty" yaml:"dest_port,omitempty"`
+	DestEnd    *int                   `json:"dest_port_end,omitempty" yaml:"dest_port_end,omitempty"`
+	ICMPType   *int                   `json:"icmp_type,omitempty" yaml:"icmp_type,omitempty"`
+	ICMPCode   *int                   `json:"icmp_code,omitempty" yaml:"icmp_code,omitempty"`
+}
+
+// fields are the names of the rule fields, in the order they are
+// written.
+var fields = []string{
+	"priority", "action", "direction", "ip_family", "protocol",
+	"source_ip", "source_port", "source_port_end",
+	"dest_ip", "dest_port", "dest_port_end", "icmp_type", "icmp_code",
+}
+
+func isField(name string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fromRequest(r *enf.FirewallRuleRequest) *rule {
+	return &rule{
+		Priority:   r.Priority,
+		Action:     r.Action,
+		Direction:  r.Direction,
+		IPFamily:   r.IPFamily,
+		Protocol:   r.Protocol,
+		SourceIP:   r.SourceIP,
+		SourcePort: r.SourcePort,
+		SourceEnd:  r.SourcePortEnd,
+		DestIP:     r.DestIP,
+		DestPort:   r.DestPort,
+		DestEnd:    r.DestPortEnd,
+		ICMPType:   r.ICMPType,
+		ICMPCode:   r.ICMPCode,
+	}
+}
+
+func (r *rule) request() enf.FirewallRuleRequest {
+	return enf.FirewallRuleRequest{
+		Priority:      r.Priority,
+		Action:        r.Action,
+		Direction:     r.Direction,
+		IPFamily:      r.IPFamily,
+		Protocol:      r.Protocol,
+		SourceIP:      r.SourceIP,
+		SourcePort:    r.SourcePort,
+		SourcePortEnd: r.SourceEnd,
+		DestIP:        r.DestIP,
+		DestPort:      r.DestPort,
+		DestPortEnd:   r.DestEnd,
+		ICMPType:      r.ICMPType,
+		ICMPCode:      r.ICMPCode,
+	}
+}
+
+// FromRules returns requests that recreate the rules, such as those
+// returned by enf.FirewallService.ListRules, sorted by priority.
+func FromRules(rules []*enf.FirewallRule) []enf.FirewallRuleRequest {
+	requests := make([]enf.FirewallRuleRequest, 0, len(rules))
+	for _, r := range rules {
+		if r != nil {
+			requests = append(requests, *r.Request())
+		}
+	}
+	sortRules(requests)
+	return requests
+}
+
+// sortRules sorts the rules by priority. Rules with the same priority
+// are sorted by their other fields, so the order does not depend on the
+// order the server returned them in.
+func sortRules(rules []enf.FirewallRuleRequest) {
+	keys := make([]string, len(rules))
+	for i := range rules {
+		data, _ := json.Marshal(fromRequest(&rules[i]))
+		keys[i] = string(data)
+	}
+
+	idx := make([]int, len(rules))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		pa, pb := priority(&rules[idx[a]]), priority(&rules[idx[b]])
+		if pa != pb {
+			return pa < pb
+		}
+		return keys[idx[a]] < keys[idx[b]]
+	})
+
+	sorted := make([]enf.FirewallRuleRequest, len(rules))
+	for i, j := range idx {
+		sorted[i] = rules[j]
+	}
+	copy(rules, sorted)
+}
+
+func priority(r *enf.FirewallRuleRequest) int {
+	if r.Priority == nil {
+		return 0
+	}
+	return *r.Priority
+}
+
+// Encode writes the rules in the given format, sorted by priority.
+// The given slice is not modified.
+func Encode(w io.Writer, format Format, rules []enf.FirewallRuleRequest) error {
+	sorted := append([]enf.FirewallRuleRequest(nil), rules...)
+	sortRules(sorted)
+
+	doc := &document{Rules: make([]*rule, len(sorted))}
+	for i := range sorted {
+		doc.Rules[i] = fromRequest(&sorted[i])
+	}
+
+	switch format {
+	case FormatJSON:
+		return encodeJSON(w, doc)
+	case FormatYAML:
+		return encodeYAML(w, doc)
+	case FormatCSV:
+		return encodeCSV(w, doc)
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// Decode reads rules in the given format. Every rule is validated, so
+// the result is ready to be passed to enf.FirewallService.CreateRule or
+// SyncRules. Errors are returned as *ParseError with the line of the
+// problem.
+func Decode(r io.Reader, format Format) ([]enf.FirewallRuleRequest, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+
+	var rules []*rule
+	var lines []int
+	var err error
+	switch format {
+	case FormatJSON:
+		rules, lines, err = decodeJSON(data)
+	case FormatYAML:
+		rules, lines, err = decodeYAML(data)
+	case FormatCSV:
+		rules, lines, err = decodeCSV(data)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]enf.FirewallRuleRequest, len(rules))
+	for i, r := range rules {
+		requests[i] = r.request()
+		if err := requests[i].Validate(); err != nil {
+			return nil, &ParseError{Line: lines[i], Err: err}
+		}
+	}
+	return requests, nil
+}
+
+// lineAt returns the line of the byte at the given offset.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,156 @@
+package ruleset
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+func testRequests() []enf.FirewallRuleRequest {
+	return []enf.FirewallRuleRequest{
+		{
+			Priority: enf.Int(20), Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+		},
+		{
+			Priority: enf.Int(10), Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			IPFamily: enf.IPFamily6.Ptr(), Protocol: enf.ProtocolTCP.Ptr(),
+			SourceIP: enf.String("fd00:8f80:8000:1::/64"), SourcePort: enf.Int(0),
+			DestIP: enf.String("fd00:8f80:8000:2::9"), DestPort: enf.Int(443),
+		},
+		{
+			Priority: enf.Int(30), Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			Protocol: enf.ProtocolTCP.Ptr(), DestPort: enf.Int(8000), DestPortEnd: enf.Int(8100),
+		},
+		{
+			Priority: enf.Int(40), Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionIngress.Ptr(),
+			Protocol: enf.ProtocolICMP6.Ptr(), ICMPType: enf.Int(128), ICMPCode: enf.Int(0),
+		},
+	}
+}
+
+func TestFromRules(t *testing.T) {
+	rules := []*enf.FirewallRule{
+		{ID: enf.String("b"), Network: enf.String("N"), Priority: enf.Int(5), Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionEgress.Ptr()},
+		{ID: enf.String("a"), Network: enf.String("N"), Priority: enf.Int(5), Action: enf.ActionAccept.Ptr(), Direction: enf.DirectionEgress.Ptr()},
+		{ID: enf.String("c"), Network: enf.String("N"), Priority: enf.Int(1), Action: enf.ActionDrop.Ptr(), Direction: enf.DirectionIngress.Ptr()},
+	}
+
+	got := FromRules(rules)
+	want := []enf.FirewallRuleRequest{*rules[2].Request(), *rules[1].Request(), *rules[0].Request()}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromRules returned %+v, want %+v", got, want)
+	}
+
+	// The order must not depend on the order of the input.
+	rules[0], rules[1] = rules[1], rules[0]
+	if got := FromRules(rules); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromRules returned %+v for reordered input, want %+v", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	in := testRequests()
+	want := []enf.FirewallRuleRequest{in[1], in[0], in[2], in[3]}
+
+	for _, format := range []Format{FormatJSON, FormatYAML, FormatCSV} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, format, in); err != nil {
+			t.Errorf("[%v] Encode returned error %v", format, err)
+			continue
+		}
+		encoded := buf.String()
+
+		got, err := Decode(&buf, format)
+		if err != nil {
+			t.Errorf("[%v] Decode returned error %v for\n%v", format, err, encoded)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("[%v] Decode returned %+v, want %+v", format, got, want)
+		}
+
+		// Encoding again must give the same output.
+		var again bytes.Buffer
+		if err := Encode(&again, format, got); err != nil || again.String() != encoded {
+			t.Errorf("[%v] Encode is not stable:\n%v\n%v", format, encoded, again.String())
+		}
+	}
+
+	if *in[0].Priority != 20 {
+		t.Errorf("Encode modified its input")
+	}
+}
+
+func TestEncode_YAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, FormatYAML, testRequests()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want := "rules:\n  - priority: 20\n    action: DROP\n    direction: INGRESS\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Encode returned\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestDecode_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		in     string
+		line   int
+	}{
+		{"json syntax", FormatJSON, "{\"rules\": [\n{\"priority\": 1,\n\"action\": }\n]}", 3},
+		{"json type", FormatJSON, "{\"rules\": [\n  {\"priority\": \"high\"}\n]}", 2},
+		{"json unknown field", FormatJSON, "{\"rules\": [\n  {\"id\": \"x\"}\n]}", 2},
+		{"json invalid rule", FormatJSON, "{\"rules\": [\n  {\"priority\": 1, \"action\": \"DROP\", \"direction\": \"INGRESS\"},\n  {\"priority\": 2, \"action\": \"ALLOW\", \"direction\": \"INGRESS\"}\n]}", 3},
+		{"json truncated", FormatJSON, "{\"rules\": [\n", 2},
+		{"yaml syntax", FormatYAML, "rules:\n  - priority: 1\n\taction: DROP\n", 3},
+		{"yaml type", FormatYAML, "rules:\n  - priority: 1\n  - priority: high\n", 3},
+		{"yaml unknown field", FormatYAML, "rules:\n  - priority: 1\n    network: N\n", 3},
+		{"yaml invalid rule", FormatYAML, "rules:\n  - priority: 1\n    action: DROP\n    direction: INGRESS\n  - priority: 2\n    action: DROP\n", 5},
+		{"csv unknown column", FormatCSV, "priority,id\n1,x\n", 1},
+		{"csv number", FormatCSV, "priority,action,direction\n1,DROP,INGRESS\nx,DROP,INGRESS\n", 3},
+		{"csv field count", FormatCSV, "priority,action,direction\n1,DROP\n", 2},
+		{"csv invalid rule", FormatCSV, "# comment\npriority,action,direction\n1,DROP,SIDEWAYS\n", 3},
+	}
+
+	for _, tt := range tests {
+		_, err := Decode(strings.NewReader(tt.in), tt.format)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("[%v] Decode returned %v, want *ParseError", tt.name, err)
+			continue
+		}
+		if parseErr.Line != tt.line {
+			t.Errorf("[%v] Decode returned error on line %d, want %d: %v", tt.name, parseErr.Line, tt.line, err)
+		}
+	}
+}
+
+func TestDecode_validation(t *testing.T) {
+	_, err := Decode(strings.NewReader("rules:\n  - priority: 1\n"), FormatYAML)
+	if !errors.Is(err, enf.ErrValidation) {
+		t.Errorf("Expected error matching enf.ErrValidation, got %v", err)
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]Format{
+		"rules.json": FormatJSON,
+		"a/b.YML":    FormatYAML,
+		"rules.yaml": FormatYAML,
+		"rules.csv":  FormatCSV,
+	}
+	for path, want := range tests {
+		if got, err := FormatFromPath(path); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) returned (%v, %v), want %v", path, got, err, want)
+		}
+	}
+	if _, err := FormatFromPath("rules.txt"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package ruleset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+func encodeYAML(w io.Writer, doc *document) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// decodeYAML decodes a YAML ruleset through its node tree, so that
+// every rule and field can be traced back to its line.
+func decodeYAML(data []byte) ([]*rule, []int, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, yamlError(err, 0)
+	}
+	if len(root.Content) == 0 {
+		return nil, nil, nil
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, nil, &ParseError{Line: doc.Line, Err: errors.New("expected a mapping with a rules field")}
+	}
+
+	var rules []*rule
+	var lines []int
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if key.Value != "rules" {
+			return nil, nil, &ParseError{Line: key.Line, Err: fmt.Errorf("unknown field %q", key.Value)}
+		}
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			continue
+		}
+		if value.Kind != yaml.SequenceNode {
+			return nil, nil, &ParseError{Line: value.Line, Err: errors.New("rules must be a list")}
+		}
+
+		for _, item := range value.Content {
+			if item.Kind != yaml.MappingNode {
+				return nil, nil, &ParseError{Line: item.Line, Err: errors.New("rule must be a mapping")}
+			}
+			for j := 0; j < len(item.Content); j += 2 {
+				if k := item.Content[j]; !isField(k.Value) {
+					return nil, nil, &ParseError{Line: k.Line, Err: fmt.Errorf("unknown field %q", k.Value)}
+				}
+			}
+
+			r := new(rule)
+			if err := item.Decode(r); err != nil {
+				return nil, nil, yamlError(err, item.Line)
+			}
+			rules = append(rules, r)
+			lines = append(lines, item.Line)
+		}
+	}
+	return rules, lines, nil
+}
+
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError converts an error from the YAML package into a
+// *ParseError, taking the line from the message if it has one. Syntax
+// errors count lines from 0, type errors from 1.
+func yamlError(err error, line int) error {
+	msg := err.Error()
+	offset := 1
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+		offset = 0
+	}
+	if m := yamlLine.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		line += offset
+		msg = m[2]
+	}
+	return &ParseError{Line: line, Err: errors.New(msg)}
+}
@@ -0,0 +1,238 @@
+package zonefile
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// ParseData parses the data of a record of the given type from its
+// fields in presentation format, such as ["10", "mail"] for MX. Relative
+// names are qualified with the origin.
+func ParseData(typ enf.RecordType, fields []string, origin string) (*enf.RecordData, error) {
+	want := map[enf.RecordType]int{
+		enf.RecordTypeAAAA: 1, enf.RecordTypeCNAME: 1, enf.RecordTypePTR: 1,
+		enf.RecordTypeMX: 2, enf.RecordTypeSRV: 4,
+	}[typ]
+	switch {
+	case typ == enf.RecordTypeTXT && len(fields) == 0:
+		return nil, fmt.Errorf("TXT record requires at least one string")
+	case want > 0 && len(fields) != want:
+		return nil, fmt.Errorf("%v record requires %d fields, got %d", typ, want, len(fields))
+	}
+
+	d := new(enf.RecordData)
+	var err error
+	switch typ {
+	case enf.RecordTypeAAAA:
+		addr, perr := netip.ParseAddr(fields[0])
+		if perr != nil || !addr.Is6() || addr.Zone() != "" {
+			return nil, fmt.Errorf("invalid IPv6 address %q", fields[0])
+		}
+		d.IPv6 = enf.String(addr.String())
+	case enf.RecordTypeCNAME:
+		d.CNAME, err = name(fields[0], origin)
+	case enf.RecordTypePTR:
+		d.PTRDName, err = name(fields[0], origin)
+	case enf.RecordTypeMX:
+		if d.Preference, err = uint16Field("preference", fields[0]); err == nil {
+			d.Exchange, err = name(fields[1], origin)
+		}
+	case enf.RecordTypeSRV:
+		if d.Priority, err = uint16Field("priority", fields[0]); err != nil {
+			break
+		}
+		if d.Weight, err = uint16Field("weight", fields[1]); err != nil {
+			break
+		}
+		if d.Port, err = uint16Field("port", fields[2]); err != nil {
+			break
+		}
+		d.Target, err = name(fields[3], origin)
+	case enf.RecordTypeTXT:
+		for _, f := range fields {
+			s, err := unescape(f)
+			if err != nil {
+				return nil, err
+			}
+			if len(s) > 255 {
+				return nil, fmt.Errorf("TXT string is longer than 255 bytes")
+			}
+			d.TXT = append(d.TXT, s)
+		}
+	default:
+		return nil, fmt.Errorf("record type %v is not supported", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// FormatData returns the data of a record of the given type in
+// presentation format.
+func FormatData(typ enf.RecordType, d *enf.RecordData) (string, error) {
+	if d == nil {
+		return "", fmt.Errorf("%v record has no data", typ)
+	}
+	missing := func(fields ...string) (string, error) {
+		return "", fmt.Errorf("%v record requires %v", typ, strings.Join(fields, ", "))
+	}
+
+	switch typ {
+	case enf.RecordTypeAAAA:
+		if d.IPv6 == nil {
+			return missing("ipv6")
+		}
+		return *d.IPv6, nil
+	case enf.RecordTypeCNAME:
+		if d.CNAME == nil {
+			return missing("cname")
+		}
+		return *d.CNAME, nil
+	case enf.RecordTypePTR:
+		if d.PTRDName == nil {
+			return missing("ptrdname")
+		}
+		return *d.PTRDName, nil
+	case enf.RecordTypeMX:
+		if d.Preference == nil || d.Exchange == nil {
+			return missing("preference", "exchange")
+		}
+		return fmt.Sprintf("%d %v", *d.Preference, *d.Exchange), nil
+	case enf.RecordTypeSRV:
+		if d.Priority == nil || d.Weight == nil || d.Port == nil || d.Target == nil {
+			return missing("priority", "weight", "port", "target")
+		}
+		return fmt.Sprintf("%d %d %d %v", *d.Priority, *d.Weight, *d.Port, *d.Target), nil
+	case enf.RecordTypeTXT:
+		if len(d.TXT) == 0 {
+			return missing("txt")
+		}
+		strs := make([]string, len(d.TXT))
+		for i, s := range d.TXT {
+			strs[i] = quote(s)
+		}
+		return strings.Join(strs, " "), nil
+	}
+	return "", fmt.Errorf("record type %v is not supported", typ)
+}
+
+func name(s, origin string) (*string, error) {
+	n, err := absolute(s, origin)
+	if err != nil {
+		return nil, err
+	}
+	return enf.String(n), nil
+}
+
+func uint16Field(field, s string) (*int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || v > 65535 {
+		return nil, fmt.Errorf("invalid %v %q", field, s)
+	}
+	return enf.Int(v), nil
+}
+
+// unescape decodes the \X and \DDD escapes of a character string.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+			v, _ := strconv.Atoi(s[i+1 : i+4])
+			if v > 255 {
+				return "", fmt.Errorf("invalid escape \\%v", s[i+1:i+4])
+			}
+			b.WriteByte(byte(v))
+			i += 3
+			continue
+		}
+		if i+1 == len(s) {
+			return "", fmt.Errorf("trailing backslash in %q", s)
+		}
+		i++
+		b.WriteByte(s[i])
+	}
+	return b.String(), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// quote returns the character string in double quotes, escaping quotes,
+// backslashes and unprintable bytes.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// fqdn returns the name with a trailing dot.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// absolute returns the fully qualified form of a name, which is
+// relative to the origin unless it ends with a dot. "@" is the origin.
+func absolute(name, origin string) (string, error) {
+	switch {
+	case name == "@":
+		if origin == "" {
+			return "", ErrMissingOrigin
+		}
+		return origin, nil
+	case strings.HasSuffix(name, ".") && !strings.HasSuffix(name, `\.`):
+		return name, nil
+	case origin == "":
+		return "", ErrMissingOrigin
+	case origin == ".":
+		return name + ".", nil
+	}
+	return name + "." + origin, nil
+}
+
+// relative returns the name relative to the zone, both fully qualified,
+// or "@" for the zone itself. It reports false if the name is outside
+// the zone.
+func relative(name, zone string) (string, bool) {
+	switch {
+	case strings.EqualFold(name, zone):
+		return "@", true
+	case zone == ".":
+		return strings.TrimSuffix(name, "."), true
+	case len(name) > len(zone) && strings.EqualFold(name[len(name)-len(zone)-1:], "."+zone):
+		return name[:len(name)-len(zone)-1], true
+	}
+	return "", false
+}
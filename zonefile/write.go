@@ -0,0 +1,114 @@
+package zonefile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// Write writes the zone and its records as a zone file, with a $ORIGIN
+// directive for the zone and the description as a comment. Records are
+// sorted by name and type, with the zone's own records first. Records
+// without a TTL are written without one and get the default of the
+// server that loads the file.
+//
+// Records that cannot be written, such as records of types that are not
+// supported, are written as comments after the others and returned as
+// issues with the line of the comment. The returned error is only set if the zone has
+// no name or w fails.
+func Write(w io.Writer, zone *enf.Zone, records []*enf.Record) ([]*Issue, error) {
+	if zone == nil || zone.ZoneDomainName == nil || *zone.ZoneDomainName == "" {
+		return nil, ErrMissingOrigin
+	}
+
+	bw := bufio.NewWriter(w)
+	line := 0
+	writeLine := func(text string) {
+		fmt.Fprintln(bw, text)
+		line++
+	}
+
+	writeLine("$ORIGIN " + fqdn(*zone.ZoneDomainName))
+	if zone.Description != nil && *zone.Description != "" {
+		writeLine("; " + strings.Join(strings.Fields(*zone.Description), " "))
+	}
+
+	var issues []*Issue
+	var skipped []string
+	tw := tabwriter.NewWriter(bw, 0, 8, 1, ' ', 0)
+	for _, r := range sortRecords(records) {
+		entry, err := format(r)
+		if err != nil {
+			skipped = append(skipped, entry)
+			issues = append(issues, &Issue{Text: entry, Reason: err.Error()})
+			continue
+		}
+		fmt.Fprintln(tw, entry)
+		line++
+	}
+	tw.Flush()
+	for i, entry := range skipped {
+		writeLine("; " + entry)
+		issues[i].Line = line
+	}
+	return issues, bw.Flush()
+}
+
+// format returns the record as a tab-separated zone file entry, or a
+// partial entry and the reason it cannot be written.
+func format(r *enf.Record) (string, error) {
+	name := "@"
+	if r.Name != nil && *r.Name != "" {
+		name = *r.Name
+	}
+	ttl := ""
+	if r.TTL != nil {
+		ttl = strconv.Itoa(*r.TTL)
+	}
+	if r.Type == nil {
+		return strings.Join([]string{name, ttl, "IN"}, "\t"), fmt.Errorf("record has no type")
+	}
+
+	entry := strings.Join([]string{name, ttl, "IN", r.Type.String()}, "\t")
+	data, err := FormatData(*r.Type, r.Data)
+	if err != nil {
+		return entry, err
+	}
+	return entry + "\t" + data, nil
+}
+
+// sortRecords returns the records sorted by name and type, with the
+// records of the zone itself first.
+func sortRecords(records []*enf.Record) []*enf.Record {
+	sorted := make([]*enf.Record, 0, len(records))
+	for _, r := range records {
+		if r != nil {
+			sorted = append(sorted, r)
+		}
+	}
+	key := func(r *enf.Record) (string, string) {
+		name, typ := "", ""
+		if r.Name != nil && *r.Name != "@" {
+			name = strings.ToLower(*r.Name)
+		}
+		if r.Type != nil {
+			typ = r.Type.String()
+		}
+		return name, typ
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ni, ti := key(sorted[i])
+		nj, tj := key(sorted[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return ti < tj
+	})
+	return sorted
+}
@@ -0,0 +1,413 @@
+// Package zonefile reads and writes DNS zones in the master file format
+// of RFC 1035, as used by BIND, so that zones can be moved into and out
+// of ENF.
+//
+// ENF zones hold AAAA, CNAME, TXT, SRV, PTR and MX records. SOA and NS
+// records are managed by ENF, and other types and classes are not
+// supported. Parse reports such records as an Issue with the line they
+// came from rather than dropping them silently, and Write reports the
+// records it cannot render.
+package zonefile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+// ErrMissingOrigin is returned when a zone file uses a relative name,
+// or has no SOA record, without an origin from Options or $ORIGIN.
+var ErrMissingOrigin = errors.New("Missing origin")
+
+// Options specifies the optional parameters to Parse.
+type Options struct {
+	// Origin is the origin until the first $ORIGIN directive, such as
+	// "example.com.". If the file has no SOA record, it is also the
+	// name of the zone.
+	Origin string
+
+	// Description is the description of the zone.
+	Description string
+}
+
+// Issue is a line that could not be parsed or imported, or a record
+// that could not be written.
+type Issue struct {
+	Line int
+	Text string
+
+	// Reason describes the problem.
+	Reason string
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("line %d: %v: %v", i.Line, i.Reason, i.Text)
+}
+
+// Result is a parsed zone file.
+type Result struct {
+	// Zone is the zone, named after the owner of the SOA record or, if
+	// there is none, the origin from Options or the first $ORIGIN.
+	Zone *enf.CreateZoneRequest
+
+	// Records are the records of the zone, in the order of the file.
+	// Their names are relative to the zone, with "@" for the zone
+	// itself, and the names in their data are fully qualified.
+	Records []*enf.CreateRecordRequest
+
+	// Lines[i] is the line Records[i] starts on.
+	Lines []int
+
+	// Issues are the entries that were not imported.
+	Issues []*Issue
+}
+
+// entry is a directive or resource record, which may span several
+// lines between parentheses.
+type entry struct {
+	line       int
+	text       string
+	blankOwner bool
+	fields     []string
+}
+
+// record is a parsed resource record with a fully qualified owner.
+type record struct {
+	entry *entry
+	owner string
+	req   *enf.CreateRecordRequest
+}
+
+// parser holds the state of Parse.
+type parser struct {
+	origin  string
+	initial string
+	ttl     *int
+	lastTTL *int
+	owner   string
+	soa     string
+
+	records []*record
+	issues  []*Issue
+}
+
+func (p *parser) issue(e *entry, format string, args ...interface{}) {
+	p.issues = append(p.issues, &Issue{Line: e.line, Text: e.text, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Parse reads a zone file. It handles the $ORIGIN and $TTL directives,
+// relative names, "@", blank owners, optional TTLs and classes in
+// either order, TTL units such as 1h30m, comments and records that
+// span several lines between parentheses. Records without a TTL take
+// the $TTL value or else the previous record's TTL, and are left
+// without one, so that they get the zone's default, if there is
+// neither.
+//
+// The returned error is only set if r cannot be read or the file needs
+// an origin it doesn't have; every other problem is reported in
+// Result.Issues.
+func Parse(r io.Reader, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	p := &parser{}
+	if opts.Origin != "" {
+		p.origin = fqdn(opts.Origin)
+		p.initial = p.origin
+	}
+
+	entries, err := scan(r, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if err := p.entry(e); err != nil {
+			return nil, err
+		}
+	}
+
+	zone := p.soa
+	if zone == "" {
+		zone = p.initial
+	}
+	if zone == "" {
+		return nil, ErrMissingOrigin
+	}
+
+	res := &Result{Zone: &enf.CreateZoneRequest{ZoneDomainName: enf.String(strings.TrimSuffix(zone, "."))}}
+	if opts.Description != "" {
+		res.Zone.Description = enf.String(opts.Description)
+	}
+	for _, rec := range p.records {
+		name, ok := relative(rec.owner, zone)
+		if !ok {
+			p.issue(rec.entry, "%v is outside the zone %v", rec.owner, zone)
+			continue
+		}
+		rec.req.Name = enf.String(name)
+		res.Records = append(res.Records, rec.req)
+		res.Lines = append(res.Lines, rec.entry.line)
+	}
+	sort.SliceStable(p.issues, func(i, j int) bool { return p.issues[i].Line < p.issues[j].Line })
+	res.Issues = p.issues
+	return res, nil
+}
+
+// scan splits the input into entries, joining the lines between
+// parentheses and removing comments and quotes.
+func scan(r io.Reader, p *parser) ([]*entry, error) {
+	var entries []*entry
+	var cur *entry
+	depth := 0
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if cur == nil {
+			cur = &entry{
+				line:       line,
+				text:       strings.TrimSpace(text),
+				blankOwner: text != "" && (text[0] == ' ' || text[0] == '\t'),
+			}
+		}
+
+		var err error
+		cur.fields, depth, err = split(text, cur.fields, depth)
+		if err != nil {
+			p.issue(cur, "%v", err)
+			cur, depth = nil, 0
+			continue
+		}
+		if depth == 0 {
+			if len(cur.fields) > 0 {
+				entries = append(entries, cur)
+			}
+			cur = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil {
+		p.issue(cur, "unbalanced parentheses")
+	}
+	return entries, nil
+}
+
+// split appends the fields of a line to fields. Quoted strings are
+// single fields without their quotes, and escapes are kept for the
+// data parsers to decode.
+func split(line string, fields []string, depth int) ([]string, int, error) {
+	var field strings.Builder
+	inField := false
+	flush := func() {
+		if inField {
+			fields = append(fields, field.String())
+			field.Reset()
+			inField = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == ';':
+			flush()
+			return fields, depth, nil
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '(':
+			flush()
+			depth++
+		case c == ')':
+			flush()
+			if depth == 0 {
+				return nil, 0, fmt.Errorf("unbalanced parentheses")
+			}
+			depth--
+		case c == '"':
+			flush()
+			inField = true
+			for i++; ; i++ {
+				if i >= len(line) {
+					return nil, 0, fmt.Errorf("unterminated quote")
+				}
+				if line[i] == '"' {
+					break
+				}
+				if line[i] == '\\' && i+1 < len(line) {
+					field.WriteByte(line[i])
+					i++
+				}
+				field.WriteByte(line[i])
+			}
+			flush()
+		case c == '\\' && i+1 < len(line):
+			inField = true
+			field.WriteByte(c)
+			i++
+			field.WriteByte(line[i])
+		default:
+			inField = true
+			field.WriteByte(c)
+		}
+	}
+	flush()
+	return fields, depth, nil
+}
+
+// entry handles a directive or resource record.
+func (p *parser) entry(e *entry) error {
+	fields := e.fields
+	if !e.blankOwner && strings.HasPrefix(fields[0], "$") {
+		p.directive(e)
+		return nil
+	}
+
+	if e.blankOwner {
+		if p.owner == "" {
+			p.issue(e, "no previous owner")
+			return nil
+		}
+	} else {
+		owner, err := absolute(fields[0], p.origin)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", e.line, err)
+		}
+		p.owner = owner
+		fields = fields[1:]
+	}
+
+	var ttl *int
+	class := "IN"
+	for n := 0; n < 2 && len(fields) > 0; n++ {
+		if v, err := parseTTL(fields[0]); err == nil {
+			ttl = enf.Int(v)
+		} else if isClass(fields[0]) {
+			class = strings.ToUpper(fields[0])
+		} else {
+			break
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		p.issue(e, "missing record type")
+		return nil
+	}
+
+	if ttl != nil {
+		p.lastTTL = ttl
+	} else if p.ttl != nil {
+		ttl = p.ttl
+	} else {
+		ttl = p.lastTTL
+	}
+
+	typ := strings.ToUpper(fields[0])
+	switch {
+	case class != "IN":
+		p.issue(e, "class %v is not supported", class)
+		return nil
+	case typ == "SOA":
+		if p.soa == "" {
+			p.soa = p.owner
+		}
+		p.issue(e, "SOA records are managed by ENF")
+		return nil
+	case typ == "NS":
+		p.issue(e, "NS records are managed by ENF")
+		return nil
+	}
+	rtype, err := enf.ParseRecordType(typ)
+	if err != nil {
+		p.issue(e, "record type %v is not supported", typ)
+		return nil
+	}
+
+	data, err := ParseData(rtype, fields[1:], p.origin)
+	if errors.Is(err, ErrMissingOrigin) {
+		return fmt.Errorf("line %d: %w", e.line, err)
+	}
+	if err != nil {
+		p.issue(e, "%v", err)
+		return nil
+	}
+
+	req := &enf.CreateRecordRequest{Type: rtype.Ptr(), Data: data}
+	if ttl != nil {
+		req.TTL = enf.Int(*ttl)
+	}
+	p.records = append(p.records, &record{entry: e, owner: p.owner, req: req})
+	return nil
+}
+
+// directive handles a $ORIGIN or $TTL directive.
+func (p *parser) directive(e *entry) {
+	name, args := strings.ToUpper(e.fields[0]), e.fields[1:]
+	switch {
+	case name != "$ORIGIN" && name != "$TTL":
+		p.issue(e, "directive %v is not supported", e.fields[0])
+	case len(args) != 1:
+		p.issue(e, "%v requires one value", name)
+	case name == "$ORIGIN":
+		origin, err := absolute(args[0], p.origin)
+		if err != nil {
+			p.issue(e, "relative $ORIGIN without a previous origin")
+			return
+		}
+		p.origin = origin
+		if p.initial == "" {
+			p.initial = origin
+		}
+	default:
+		ttl, err := parseTTL(args[0])
+		if err != nil {
+			p.issue(e, "%v", err)
+			return
+		}
+		p.ttl = enf.Int(ttl)
+	}
+}
+
+func isClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS":
+		return true
+	}
+	return false
+}
+
+// parseTTL parses a TTL in seconds or with the units s, m, h, d and w,
+// as in 1h30m.
+func parseTTL(s string) (int, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	total, n := 0, 0
+	digits := false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			n = n*10 + int(c-'0')
+			digits = true
+			if n > 1<<31-1 {
+				return 0, fmt.Errorf("invalid TTL %q", s)
+			}
+			continue
+		}
+		unit := map[rune]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+		if unit == 0 || !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += n * unit
+		n, digits = 0, false
+	}
+	total += n
+	if total > 1<<31-1 {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return total, nil
+}
@@ -0,0 +1,178 @@
+package zonefile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaptum/go-enf/enf"
+)
+
+const testZone = `$ORIGIN plant1.example.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		3600 900 604800 300 )
+	IN	NS	ns1
+	IN	MX	10 mail
+sensor-1	300	IN	AAAA	fd00:8f80:8000:1:0:0:0:5
+sensor-2		AAAA	fd00:8f80:8000:1::6
+			TXT	"model=\"X2\"" "loc=hall 3"
+api	IN	30m	CNAME	sensor-1
+_mqtt._tcp	SRV	( 10 5
+		8883 api.plant1.example. )
+legacy	A	10.0.0.1
+$ORIGIN sub.plant1.example.
+gw	AAAA	fd00:8f80:8000:2::1
+other.example.	AAAA	fd00::1
+$INCLUDE other.zone
+bad	AAAA	10.0.0.2
+`
+
+// describe returns the records as "name ttl type data" lines.
+func describe(t *testing.T, records []*enf.CreateRecordRequest) []string {
+	var lines []string
+	for _, r := range records {
+		data, err := FormatData(*r.Type, r.Data)
+		if err != nil {
+			t.Fatalf("FormatData returned error %v", err)
+		}
+		ttl := "-"
+		if r.TTL != nil {
+			ttl = fmt.Sprint(*r.TTL)
+		}
+		lines = append(lines, fmt.Sprintf("%v %v %v %v", *r.Name, ttl, *r.Type, data))
+	}
+	return lines
+}
+
+func TestParse(t *testing.T) {
+	res, err := Parse(strings.NewReader(testZone), &Options{Description: "Plant 1"})
+	if err != nil {
+		t.Fatalf("Parse returned error %v", err)
+	}
+
+	if *res.Zone.ZoneDomainName != "plant1.example" || *res.Zone.Description != "Plant 1" {
+		t.Errorf("Parse returned zone %+v", res.Zone)
+	}
+
+	want := []string{
+		`@ 3600 MX 10 mail.plant1.example.`,
+		`sensor-1 300 AAAA fd00:8f80:8000:1::5`,
+		`sensor-2 3600 AAAA fd00:8f80:8000:1::6`,
+		`sensor-2 3600 TXT "model=\"X2\"" "loc=hall 3"`,
+		`api 1800 CNAME sensor-1.plant1.example.`,
+		`_mqtt._tcp 3600 SRV 10 5 8883 api.plant1.example.`,
+		`gw.sub 3600 AAAA fd00:8f80:8000:2::1`,
+	}
+	if got := describe(t, res.Records); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse returned records\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if want := []int{7, 8, 9, 10, 11, 12, 16}; !reflect.DeepEqual(res.Lines, want) {
+		t.Errorf("Parse returned lines %v, want %v", res.Lines, want)
+	}
+
+	var issues []string
+	for _, i := range res.Issues {
+		issues = append(issues, i.String())
+	}
+	wantIssues := []string{
+		"line 3: SOA records are managed by ENF: @	IN	SOA	ns1 hostmaster (",
+		"line 6: NS records are managed by ENF: IN	NS	ns1",
+		"line 14: record type A is not supported: legacy	A	10.0.0.1",
+		"line 17: other.example. is outside the zone plant1.example.: other.example.	AAAA	fd00::1",
+		"line 18: directive $INCLUDE is not supported: $INCLUDE other.zone",
+		`line 19: invalid IPv6 address "10.0.0.2": bad	AAAA	10.0.0.2`,
+	}
+	if !reflect.DeepEqual(issues, wantIssues) {
+		t.Errorf("Parse returned issues\n%v\nwant\n%v", strings.Join(issues, "\n"), strings.Join(wantIssues, "\n"))
+	}
+}
+
+func TestParse_origin(t *testing.T) {
+	res, err := Parse(strings.NewReader("www AAAA fd00::1\n"), &Options{Origin: "abc.def"})
+	if err != nil {
+		t.Fatalf("Parse returned error %v", err)
+	}
+	if *res.Zone.ZoneDomainName != "abc.def" || len(res.Records) != 1 || res.Records[0].TTL != nil {
+		t.Errorf("Parse returned zone %+v and records %v", res.Zone, describe(t, res.Records))
+	}
+
+	if _, err := Parse(strings.NewReader("www AAAA fd00::1\n"), nil); !errors.Is(err, ErrMissingOrigin) {
+		t.Errorf("Parse returned error %v for a relative name without origin", err)
+	}
+
+	res, err = Parse(strings.NewReader("www AAAA fd00::1 ( \n"), &Options{Origin: "abc.def."})
+	if err != nil || len(res.Records) != 0 || len(res.Issues) != 1 || res.Issues[0].Reason != "unbalanced parentheses" {
+		t.Errorf("Parse returned %+v, %v for unbalanced parentheses", res, err)
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"300", 300, true},
+		{"1h30m", 5400, true},
+		{"1W", 604800, true},
+		{"2d", 172800, true},
+		{"h", 0, false},
+		{"1x", 0, false},
+		{"IN", 0, false},
+		{"99999999999", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTTL(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseTTL(%q) returned (%d, %v), want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	zone := &enf.Zone{ZoneDomainName: enf.String("plant1.example"), Description: enf.String("Plant 1\ndevices")}
+	records := []*enf.Record{
+		{Name: enf.String("sensor-1"), TTL: enf.Int(300), Type: enf.RecordTypeAAAA.Ptr(), Data: &enf.RecordData{IPv6: enf.String("fd00::5")}},
+		{Name: enf.String("api"), Type: enf.RecordTypeCNAME.Ptr(), Data: &enf.RecordData{CNAME: enf.String("sensor-1.plant1.example.")}},
+		{Name: enf.String("legacy"), Type: enf.RecordType("A").Ptr(), Data: &enf.RecordData{}},
+		{Name: enf.String("@"), TTL: enf.Int(3600), Type: enf.RecordTypeTXT.Ptr(), Data: &enf.RecordData{TXT: []string{`say "hi"`, "tab\there"}}},
+	}
+
+	var buf bytes.Buffer
+	issues, err := Write(&buf, zone, records)
+	if err != nil {
+		t.Fatalf("Write returned error %v", err)
+	}
+	want := "$ORIGIN plant1.example.\n" +
+		"; Plant 1 devices\n" +
+		"@        3600 IN TXT   \"say \\\"hi\\\"\" \"tab\\009here\"\n" +
+		"api           IN CNAME sensor-1.plant1.example.\n" +
+		"sensor-1 300  IN AAAA  fd00::5\n" +
+		"; legacy\t\tIN\tA\n"
+	if buf.String() != want {
+		t.Errorf("Write wrote\n%v\nwant\n%v", buf.String(), want)
+	}
+	if len(issues) != 1 || issues[0].Line != 6 || issues[0].Reason != "record type A is not supported" {
+		t.Errorf("Write returned issues %v", issues)
+	}
+
+	// The written file parses back into the same records, except that
+	// records without a TTL take the TTL of the previous record.
+	res, err := Parse(&buf, nil)
+	if err != nil {
+		t.Fatalf("Parse returned error %v", err)
+	}
+	wantRecords := []string{
+		`@ 3600 TXT "say \"hi\"" "tab\009here"`,
+		`api 3600 CNAME sensor-1.plant1.example.`,
+		`sensor-1 300 AAAA fd00::5`,
+	}
+	if got := describe(t, res.Records); !reflect.DeepEqual(got, wantRecords) {
+		t.Errorf("Parse returned records\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(wantRecords, "\n"))
+	}
+}